@@ -0,0 +1,100 @@
+package ansible
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// remoteSourcePattern matches URLs and scp-like git sources.
+var remoteSourcePattern = regexp.MustCompile(`^(?:git\+)?(?:[a-z][a-z0-9+.-]*://|[^/@\s]+@[^:\s]+:)`)
+
+// NetworkAccessError is returned in air-gapped mode if the configuration
+// implies network access.
+type NetworkAccessError struct {
+	Field  string
+	Value  string
+	Reason string
+}
+
+func (e *NetworkAccessError) Error() string {
+	if e.Value == "" {
+		return fmt.Sprintf("air-gapped mode forbids %s: %s", e.Field, e.Reason)
+	}
+
+	return fmt.Sprintf("air-gapped mode forbids %q for %s: %s", e.Value, e.Field, e.Reason)
+}
+
+// remoteSource reports whether a source is fetched over the network.
+func remoteSource(source string) bool {
+	return remoteSourcePattern.MatchString(source) && !strings.HasPrefix(source, "file://")
+}
+
+// checkAirGapped fails if the configuration implies network access. Galaxy
+// requirements have to resolve from a mirror or local paths.
+func (p *AnsiblePlaybook) checkAirGapped() error {
+	values := map[string][]string{
+		"galaxy api server": {p.Config.GalaxyAPIServerURL},
+		"galaxy file":       {p.Config.GalaxyFile},
+		"extra vars":        p.Config.ExtraVars,
+		"inventories":       p.Config.Inventories,
+		"playbooks":         p.Config.Playbooks,
+	}
+
+	for _, server := range p.Config.GalaxyServers {
+		values["galaxy servers"] = append(values["galaxy servers"], server.URL)
+	}
+
+	for _, field := range sortedFields(values) {
+		for _, value := range values[field] {
+			if remoteSource(strings.TrimPrefix(value, "@")) {
+				return &NetworkAccessError{Field: field, Value: value, Reason: "is a remote source"}
+			}
+		}
+	}
+
+	switch {
+	case len(p.Config.InventoryPlugins) > 0:
+		return &NetworkAccessError{Field: "inventory plugins", Reason: "query cloud APIs"}
+	case p.Config.AnsibleCoreVersion != "":
+		return &NetworkAccessError{Field: "ansible core version", Reason: "bootstrapping installs packages"}
+	case p.Config.InstallPlaybookCollections && p.Config.GalaxyMirror == "":
+		return &NetworkAccessError{Field: "installing playbook collections", Reason: "requires a galaxy mirror"}
+	}
+
+	if p.Config.GalaxyFile == "" || p.Config.GalaxyMirror != "" {
+		return nil
+	}
+
+	reqs, err := readGalaxyRequirements(p.Config.GalaxyFile)
+	if err != nil {
+		return err
+	}
+
+	for _, req := range append(append([]galaxyRequirement{}, reqs.Roles...), reqs.Collections...) {
+		if !localRequirement(req) {
+			return &NetworkAccessError{
+				Field:  "galaxy requirements",
+				Value:  req.name(),
+				Reason: "does not resolve from a local path, configure a galaxy mirror",
+			}
+		}
+	}
+
+	return nil
+}
+
+// localRequirement reports whether a requirement is installed from a local
+// path.
+func localRequirement(req galaxyRequirement) bool {
+	source := req.Src
+	if source == "" || req.Type == "file" || req.Type == "dir" || req.Type == "subdirs" {
+		source = req.Name
+	}
+
+	if strings.HasPrefix(source, "file://") {
+		return true
+	}
+
+	return strings.HasPrefix(source, "/") || strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../")
+}
@@ -0,0 +1,51 @@
+package ansible
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestAirGapped tests configurations implying network access are rejected
+// in air-gapped mode.
+func TestAirGapped(t *testing.T) {
+	dir := t.TempDir()
+
+	local := filepath.Join(dir, "local.yml")
+	if err := os.WriteFile(local, []byte("roles:\n  - src: ./roles/web\n    name: web\ncollections:\n  - name: /srv/collections/community-general-7.5.0.tar.gz\n    type: file\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	remote := filepath.Join(dir, "remote.yml")
+	if err := os.WriteFile(remote, []byte("collections:\n  - community.general\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		config Config
+		field  string
+	}{
+		{Config{Playbooks: []string{"tests/test.yml"}, Inventories: []string{"localhost,"}}, ""},
+		{Config{GalaxyFile: local}, ""},
+		{Config{GalaxyFile: remote, GalaxyMirror: dir}, ""},
+		{Config{GalaxyFile: remote}, "galaxy requirements"},
+		{Config{Playbooks: []string{"https://example.com/site.yml"}}, "playbooks"},
+		{Config{ExtraVars: []string{"@https://example.com/vars.yml"}}, "extra vars"},
+		{Config{GalaxyServers: []GalaxyServer{{Name: "hub", URL: "https://hub.example.com/api/"}}}, "galaxy servers"},
+		{Config{InventoryPlugins: []InventoryPlugin{&AWSEC2Inventory{}}}, "inventory plugins"},
+		{Config{AnsibleCoreVersion: "2.16.0"}, "ansible core version"},
+	} {
+		playbook := &AnsiblePlaybook{Config: test.config}
+
+		err := playbook.checkAirGapped()
+
+		var network *NetworkAccessError
+		switch {
+		case test.field == "" && err != nil:
+			t.Errorf("Expected %+v to be allowed, got %v", test.config, err)
+		case test.field != "" && (!errors.As(err, &network) || network.Field != test.field):
+			t.Errorf("Expected a network access error for %s, got %v", test.field, err)
+		}
+	}
+}
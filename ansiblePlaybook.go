@@ -1,24 +1,69 @@
 package ansible
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
-
-	"github.com/pkg/errors"
+	"sync"
+	"time"
 )
 
+var unsafeShellChars = regexp.MustCompile(`[^\w@%+=:,./-]`)
+
 type Config struct {
+	ActionPluginPath                  []string
+	Advice                            bool
+	AirGapped                         bool
+	AnsibleBinDir                     string
+	AnsibleConfigFile                 string
+	AnsibleCoreVersion                string
+	AnsibleVersionConstraint          string
+	AnsibleVersions                   map[string]string
+	Approval                          ApprovalFunc `json:"-"`
+	ArtifactDir                       string
+	ArtifactStore                     ArtifactStore `json:"-"`
+	AsyncPoll                         time.Duration
+	AsyncTimeout                      time.Duration
+	AutoTune                          TuneFunc `json:"-"`
 	Become                            bool
+	BootstrapDir                      string
+	BootstrapPython                   string
 	BecomeMethod                      string
 	BecomeUser                        string
+	CACertFile                        string
+	CallbackPluginPath                []string
+	ChangeManifest                    string
 	Check                             bool
+	CheckCollections                  bool
+	ChrootDir                         string
+	Color                             ColorMode
+	CompactBanners                    bool
+	Confirm                           ConfirmFunc `json:"-"`
 	Connection                        string
+	ConnectTimeout                    time.Duration
+	CPUAffinity                       []int
+	ContinueOnError                   bool
+	DeadlineExtraVar                  bool
+	Debug                             bool
 	Diff                              bool
+	DirMode                           os.FileMode
+	DisableCommandWarnings            bool
+	DisableDeprecationWarnings        bool
+	Environment                       map[string]string
+	Events                            EventHandler `json:"-"`
+	ExportFacts                       bool
 	ExtraVars                         []string
+	FailOnChange                      bool
+	FileMode                          os.FileMode
+	FilterPluginPath                  []string
 	FlushCache                        bool
 	ForceHandlers                     bool
 	Forks                             int
@@ -30,51 +75,273 @@ type Config struct {
 	GalaxyForce                       bool
 	GalaxyForceWithDeps               bool
 	GalaxyIgnoreCerts                 bool
+	GalaxyIsolate                     bool
 	GalaxyIgnoreSignatureStatusCodes  []string
 	GalaxyKeyring                     string
+	GalaxyMirror                      string
 	GalaxyOffline                     bool
 	GalaxyPre                         bool
+	GalaxyProgress                    bool
 	GalaxyRequiredValidSignatureCount int
 	GalaxyRequirementsFile            string
+	GalaxyRetries                     int
+	GalaxyRetryDelay                  time.Duration
+	GalaxyRolesPath                   string
+	GalaxyServers                     []GalaxyServer
 	GalaxySignature                   string
 	GalaxyTimeout                     int
 	GalaxyUpgrade                     bool
 	GalaxyNoDeps                      bool
+	GalaxyOnly                        bool
+	GatherSubset                      []string
+	Gathering                         GatheringPolicy
+	HideOkHosts                       bool
+	HideSkippedHosts                  bool
+	HostResults                       HostResultFunc `json:"-"`
+	HTTPProxy                         string
+	HTTPSProxy                        string
+	IdempotencyCheck                  bool
+	InstallPlaybookCollections        bool
 	Inventories                       []string
+	InventoryPlugins                  []InventoryPlugin
+	InventorySources                  []InventorySource
 	Limit                             string
 	ListHosts                         bool
 	ListTags                          bool
 	ListTasks                         bool
+	MaintenanceWindows                []MaintenanceWindow
+	MemoryLimit                       int64
+	MergeInventories                  bool
+	MinRemainingTime                  time.Duration
 	ModulePath                        []string
+	ModuleUtilsPath                   []string
+	Nice                              int
+	NoLogSensitive                    bool
+	NoProxy                           string
+	OpenFilesLimit                    uint64
+	PlaybookCollectionVersions        map[string]string
+	PlaybookDir                       string
+	PlaybookExcludes                  []string
+	PlaybookOrderFile                 string
 	Playbooks                         []string
+	PollInterval                      int
+	PreflightInventoryScripts         bool
+	PreScan                           bool
+	PreScanConcurrency                int
+	PreScanExclude                    bool
+	PreScanTimeout                    time.Duration
 	PrivateKey                        string
 	PrivateKeyFile                    string
+	PrivateKeyPassphrase              string
+	ProfileTasks                      bool
+	ProtectedInventories              []string
+	ProtectedOverride                 string
+	Quiet                             bool
+	RequireKeyPassphrase              bool
 	Requirements                      string
+	RunID                             string
+	RunMetadata                       bool
+	RunMetadataVars                   map[string]string
+	RunTimeout                        time.Duration
+	RollbackPlaybooks                 []string
+	SafeMode                          bool
+	SBOMFile                          string
 	SCPExtraArgs                      string
 	SFTPExtraArgs                     string
 	SkipTags                          string
+	SkipVersionCheck                  bool
 	SSHCommonArgs                     string
+	SSHControlPersist                 time.Duration
+	SSHGroupArgs                      map[string]SSHArgs
 	SSHExtraArgs                      string
 	StartAtTask                       string
+	Strategy                          string
+	StrategyPluginPath                []string
+	StrictCrypto                      bool
+	StrictDeprecations                bool
 	SyntaxCheck                       bool
 	Tags                              string
+	TaskTimeout                       time.Duration
+	Timestamps                        bool
 	Timeout                           int
+	UnreachableRetries                int
+	UnreachableRetryDelay             time.Duration
 	User                              string
+	ValidateBeforeRun                 bool
 	VaultID                           string
+	VaultIDs                          []string
 	VaultPassword                     string
 	VaultPasswordFile                 string
+	VarsPluginPath                    []string
 	Verbose                           int
+	VerboseEnv                        bool
+	WaitForMaintenanceWindow          bool
+	WatchCheck                        bool
+	WatchDebounce                     time.Duration
+	WatchInterval                     time.Duration
 }
 
 type AnsiblePlaybook struct {
-	Config Config
+	Config  Config
+	Results []*RunResult
+
+	// Galaxy lists the roles and collections installed by the run.
+	Galaxy *GalaxyReport
+
+	// Output receives the output of the commands, os.Stdout if nil.
+	Output io.Writer
+
+	// Summary receives a JSON line summarizing the run once it finished.
+	Summary io.Writer
+
+	ctx     context.Context
+	tmpdir  string
+	env     []EnvVar
+	secrets []string
+
+	tracedEnv    string
+	runID        string
+	eventPlugins string
+	groupVars    string
+	options      map[string][]ArgOption
+	metadata     string
+	openFiles    uint64
+
+	galaxyMu sync.Mutex
 }
 
 func (p *AnsiblePlaybook) Exec() error {
-	if err := p.playbooks(); err != nil {
+	return p.ExecContext(context.Background())
+}
+
+// ExecContext runs the playbooks like Exec. If the context is canceled, the
+// running command is killed and the context error is returned.
+func (p *AnsiblePlaybook) ExecContext(ctx context.Context) error {
+	started := time.Now()
+
+	err := p.exec(ctx)
+
+	if p.Config.ChangeManifest != "" {
+		if manifestErr := p.WriteChangeManifest(p.Config.ChangeManifest); manifestErr != nil {
+			if err != nil {
+				fmt.Fprintf(p.output(), "change manifest: %v\n", manifestErr)
+			} else {
+				err = manifestErr
+			}
+		}
+	}
+
+	if p.Config.ArtifactStore != nil {
+		if uploadErr := p.uploadArtifacts(started, err); uploadErr != nil {
+			if err != nil {
+				fmt.Fprintf(p.output(), "artifacts: %v\n", uploadErr)
+			} else {
+				err = uploadErr
+			}
+		}
+	}
+
+	if p.Summary != nil {
+		p.writeSummary(started, err)
+	}
+
+	return err
+}
+
+func (p *AnsiblePlaybook) exec(ctx context.Context) error {
+	p.startRun()
+
+	if err := p.checkTimeouts(); err != nil {
+		return err
+	}
+
+	if p.Config.RunTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.Config.RunTimeout)
+		defer cancel()
+	}
+
+	p.ctx = ctx
+	defer func() { p.ctx = nil }()
+
+	p.Results = nil
+	p.Galaxy = nil
+	p.tracedEnv = ""
+	defer p.cleanup()
+
+	if p.Config.GalaxyOnly {
+		if p.Config.GalaxyFile == "" {
+			return errors.New("galaxy only mode requires a galaxy file")
+		}
+
+		if p.Config.GalaxyIsolate {
+			return errors.New("galaxy only mode can not be combined with isolated galaxy paths")
+		}
+	} else if err := p.playbooks(); err != nil {
 		return err
 	}
 
+	if p.Config.GalaxyFile != "" {
+		if err := p.checkGalaxyFile(); err != nil {
+			return err
+		}
+	}
+
+	if p.Config.RunMetadata {
+		p.metadata = p.runMetadataExtraVars()
+	}
+
+	if p.Config.StrictCrypto || p.Config.RequireKeyPassphrase {
+		if err := p.checkCryptoPolicy(); err != nil {
+			return err
+		}
+	}
+
+	// All files of the run are created in the run directory.
+	if _, err := p.runDir(); err != nil {
+		return err
+	}
+
+	if err := p.checkModulePaths(); err != nil {
+		return err
+	}
+
+	if err := p.checkFactGathering(); err != nil {
+		return err
+	}
+
+	if p.Config.ChrootDir != "" {
+		if err := p.checkChrootDir(); err != nil {
+			return err
+		}
+	}
+
+	if p.Config.AirGapped {
+		if err := p.checkAirGapped(); err != nil {
+			return err
+		}
+	}
+
+	if !p.Config.GalaxyOnly {
+		if err := p.checkProtectedInventories(); err != nil {
+			return err
+		}
+	}
+
+	if p.Config.SafeMode {
+		if err := p.checkSafeValues(); err != nil {
+			return err
+		}
+	}
+
+	if err := p.checkOptions(); err != nil {
+		return err
+	}
+
+	if p.Config.NoLogSensitive {
+		p.registerConfigSecrets()
+	}
+
 	if p.Config.PrivateKey != "" {
 		if err := p.privateKey(); err != nil {
 			return err
@@ -83,6 +350,16 @@ func (p *AnsiblePlaybook) Exec() error {
 		defer os.Remove(p.Config.PrivateKeyFile)
 	}
 
+	if p.Config.PrivateKeyPassphrase != "" && p.Config.PrivateKeyFile != "" {
+		keyFile := p.Config.PrivateKeyFile
+		if err := p.decryptPrivateKey(); err != nil {
+			return err
+		}
+
+		defer os.Remove(p.Config.PrivateKeyFile)
+		defer func() { p.Config.PrivateKeyFile = keyFile }()
+	}
+
 	if p.Config.VaultPassword != "" {
 		if err := p.vaultPass(); err != nil {
 			return err
@@ -91,95 +368,454 @@ func (p *AnsiblePlaybook) Exec() error {
 		defer os.Remove(p.Config.VaultPasswordFile)
 	}
 
-	commands := []*exec.Cmd{
-		p.versionCommand(),
+	if len(p.Config.InventorySources) > 0 {
+		inventories := p.Config.Inventories
+		files, err := p.inventorySources()
+		if err != nil {
+			return err
+		}
+
+		defer removeFiles(files)
+		defer func() { p.Config.Inventories = inventories }()
+	}
+
+	if p.Config.ExportFacts {
+		if err := p.factCache(); err != nil {
+			return err
+		}
+	}
+
+	if len(p.Config.SSHGroupArgs) > 0 {
+		if err := p.sshGroupVars(); err != nil {
+			return err
+		}
+	}
+
+	if p.Config.SSHControlPersist > 0 {
+		dir, err := p.sshControlDir()
+		if err != nil {
+			return err
+		}
+
+		defer p.closeSSHControlSockets(dir)
+	}
+
+	if p.Config.Events != nil || p.Config.HostResults != nil {
+		stop, err := p.streamEvents()
+		if err != nil {
+			return err
+		}
+
+		defer stop()
+	}
+
+	if len(p.Config.InventoryPlugins) > 0 {
+		inventories := p.Config.Inventories
+		if err := p.inventoryPlugins(); err != nil {
+			return err
+		}
+
+		defer func() { p.Config.Inventories = inventories }()
+	}
+
+	if err := p.checkEnv(); err != nil {
+		return err
+	}
+
+	if !p.Config.GalaxyOnly {
+		if err := p.checkInventories(); err != nil {
+			return err
+		}
+
+		if err := p.checkInventoryScripts(); err != nil {
+			return err
+		}
+	}
+
+	if len(p.Config.GalaxyServers) > 0 {
+		if err := p.galaxyServerConfig(); err != nil {
+			return err
+		}
+	}
+
+	if p.Config.GalaxyFile != "" && p.Config.GalaxyMirror != "" {
+		galaxyFile := p.Config.GalaxyFile
+		mirror, err := p.galaxyMirror()
+		if err != nil {
+			return err
+		}
+
+		defer os.RemoveAll(mirror)
+		defer func() { p.Config.GalaxyFile = galaxyFile }()
+	}
+
+	if p.Config.GalaxyIsolate {
+		collections, roles := p.Config.GalaxyCollectionsPath, p.Config.GalaxyRolesPath
+		dir, err := p.galaxyIsolate()
+		if err != nil {
+			return err
+		}
+
+		defer os.RemoveAll(dir)
+		defer func() {
+			p.Config.GalaxyCollectionsPath = collections
+			p.Config.GalaxyRolesPath = roles
+		}()
+	}
+
+	if p.Config.AnsibleVersionConstraint != "" || p.Config.AnsibleCoreVersion != "" {
+		bin := p.Config.AnsibleBinDir
+		defer func() { p.Config.AnsibleBinDir = bin }()
+	}
+
+	if p.Config.AnsibleVersionConstraint != "" {
+		if err := p.selectAnsibleVersion(); err != nil {
+			return err
+		}
+	}
+
+	if p.Config.AnsibleCoreVersion != "" {
+		if err := p.bootstrap(); err != nil {
+			return err
+		}
+	}
+
+	if p.Config.OpenFilesLimit > 0 {
+		if err := p.openFilesLimit(); err != nil {
+			return err
+		}
+	}
+
+	if !p.Config.SkipVersionCheck {
+		if err := p.runAuxiliary(p.versionCommand()); err != nil {
+			return err
+		}
 	}
 
 	if p.Config.GalaxyFile != "" {
-		commands = append(commands, p.galaxyRoleCommand())
-		commands = append(commands, p.galaxyCollectionCommand())
+		if err := p.runConcurrent(
+			p.runGalaxy(p.galaxyRoleCommand),
+			p.runGalaxy(p.galaxyCollectionCommand),
+		); err != nil {
+			return err
+		}
 	}
 
-	for _, inventory := range p.Config.Inventories {
-		commands = append(commands, p.ansibleCommand(inventory))
+	if p.Config.SBOMFile != "" {
+		if err := p.WriteSBOM(p.Config.SBOMFile); err != nil {
+			return err
+		}
 	}
 
-	for _, cmd := range commands {
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
+	if p.Config.GalaxyOnly {
+		return nil
+	}
 
-		cmd.Env = os.Environ()
-		cmd.Env = append(cmd.Env, "ANSIBLE_FORCE_COLOR=1")
-		cmd.Env = append(cmd.Env, "ANSIBLE_GALAXY_DISPLAY_PROGRESS=0")
+	if p.Config.CheckCollections {
+		if err := p.checkCollections(); err != nil {
+			return err
+		}
+	} else if p.Config.InstallPlaybookCollections {
+		if err := p.installPlaybookCollections(); err != nil {
+			return err
+		}
+	}
+
+	if err := p.checkCollectionPlaybooks(); err != nil {
+		return err
+	}
+
+	if p.Config.BecomeMethod != "" {
+		if err := p.checkBecomeMethod(); err != nil {
+			return err
+		}
+	}
+
+	if p.Config.Connection != "" && !p.Config.SkipVersionCheck {
+		if err := p.checkConnection(); err != nil {
+			return err
+		}
+	}
+
+	if p.Config.Strategy != "" && !p.Config.SkipVersionCheck {
+		if err := p.checkStrategy(); err != nil {
+			return err
+		}
+	}
+
+	if p.Config.ValidateBeforeRun {
+		if err := p.validate(); err != nil {
+			return err
+		}
+	}
+
+	var (
+		failures []*InventoryError
+		previous *RunResult
+	)
+
+	inventories := p.runInventories()
+
+	for i, inventory := range inventories {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if i > 0 && p.Config.Approval != nil {
+			if err := p.approve(inventory, previous); err != nil {
+				return err
+			}
+		}
+
+		if p.Config.Confirm != nil {
+			if err := p.confirm(inventory); err != nil {
+				return err
+			}
+		}
+
+		if p.Config.Advice || p.Config.AutoTune != nil {
+			if err := p.advise(inventory); err != nil {
+				return err
+			}
+		}
 
-		trace(cmd)
+		results := len(p.Results)
+		err := p.execInventory(inventory)
+
+		previous = nil
+		if len(p.Results) > results {
+			previous = p.Results[len(p.Results)-1]
+		}
+
+		if err == nil {
+			continue
+		}
 
-		if err := cmd.Run(); err != nil {
+		if !p.Config.ContinueOnError {
 			return err
 		}
+
+		failures = append(failures, &InventoryError{
+			Inventory: inventory,
+			Err:       err,
+		})
+	}
+
+	if len(failures) > 0 {
+		return &MultiError{
+			Inventories: len(inventories),
+			Failures:    failures,
+		}
+	}
+
+	if p.Config.FailOnChange {
+		return changedError(p.Results)
 	}
 
 	return nil
 }
 
-func (p *AnsiblePlaybook) privateKey() error {
-	tmpfile, err := os.CreateTemp("", "privateKey")
+// execInventory runs the playbooks against a single inventory.
+func (p *AnsiblePlaybook) execInventory(inventory string) error {
+	if len(p.Config.MaintenanceWindows) > 0 {
+		if err := p.checkMaintenanceWindow(inventory); err != nil {
+			return err
+		}
+	}
+
+	if p.Config.MinRemainingTime > 0 {
+		if err := p.checkDeadline(inventory); err != nil {
+			return err
+		}
+	}
+
+	if p.Config.PreScan {
+		restore, err := p.preScan(inventory)
+		if err != nil {
+			return err
+		}
+
+		defer restore()
+	}
+
+	result, err := p.runPlaybook(inventory)
+	p.Results = append(p.Results, result)
+
+	if err != nil && p.Config.UnreachableRetries > 0 {
+		result, err = p.retryUnreachable(inventory, result, err)
+	}
+
+	if p.Config.ExportFacts {
+		if err := p.exportFacts(); err != nil {
+			return err
+		}
+	}
+
 	if err != nil {
-		return errors.Wrap(err, "failed to create private key file")
+		if len(p.Config.RollbackPlaybooks) > 0 {
+			return p.rollback(inventory, result, err)
+		}
+
+		return err
 	}
 
-	if _, err := tmpfile.Write([]byte(p.Config.PrivateKey)); err != nil {
-		return errors.Wrap(err, "failed to write private key file")
+	if p.Config.StrictDeprecations && len(result.Deprecations) > 0 {
+		return &DeprecationError{
+			Inventory:    inventory,
+			Deprecations: result.Deprecations,
+		}
 	}
 
-	if err := tmpfile.Close(); err != nil {
-		return errors.Wrap(err, "failed to close private key file")
+	if p.Config.IdempotencyCheck {
+		if err := p.idempotencyCheck(inventory); err != nil {
+			return err
+		}
 	}
 
-	p.Config.PrivateKeyFile = tmpfile.Name()
 	return nil
 }
 
-func (p *AnsiblePlaybook) vaultPass() error {
-	tmpfile, err := os.CreateTemp("", "vaultPass")
+// runDir returns a temporary directory which is removed once the run
+// finished.
+func (p *AnsiblePlaybook) runDir() (string, error) {
+	if p.tmpdir != "" {
+		return p.tmpdir, nil
+	}
+
+	dir, err := os.MkdirTemp("", "ansible")
 	if err != nil {
-		return errors.Wrap(err, "failed to create vault password file")
+		return "", fmt.Errorf("failed to create run directory: %w", err)
+	}
+
+	if err := os.Chmod(dir, p.dirMode()); err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("failed to create run directory: %w", err)
+	}
+
+	p.tmpdir = dir
+	return dir, nil
+}
+
+func (p *AnsiblePlaybook) cleanup() {
+	if p.tmpdir != "" {
+		os.RemoveAll(p.tmpdir)
 	}
 
-	if _, err := tmpfile.Write([]byte(p.Config.VaultPassword)); err != nil {
-		return errors.Wrap(err, "failed to write vault password file")
+	p.tmpdir = ""
+	p.env = nil
+	p.groupVars = ""
+	p.metadata = ""
+	p.openFiles = 0
+}
+
+func (p *AnsiblePlaybook) privateKey() error {
+	file, err := p.tempFile("privateKey", []byte(p.Config.PrivateKey))
+	if err != nil {
+		return fmt.Errorf("failed to write private key file: %w", err)
 	}
 
-	if err := tmpfile.Close(); err != nil {
-		return errors.Wrap(err, "failed to close vault password file")
+	p.Config.PrivateKeyFile = file
+	return nil
+}
+
+func (p *AnsiblePlaybook) vaultPass() error {
+	file, err := p.tempFile("vaultPass", []byte(p.Config.VaultPassword))
+	if err != nil {
+		return fmt.Errorf("failed to write vault password file: %w", err)
 	}
 
-	p.Config.VaultPasswordFile = tmpfile.Name()
+	p.Config.VaultPasswordFile = file
 	return nil
 }
 
+func (p *AnsiblePlaybook) galaxyIsolate() (string, error) {
+	dir, err := p.tempDir("galaxy")
+	if err != nil {
+		return "", fmt.Errorf("failed to create galaxy directory: %w", err)
+	}
+
+	p.Config.GalaxyCollectionsPath = filepath.Join(dir, "collections")
+	p.Config.GalaxyRolesPath = filepath.Join(dir, "roles")
+	return dir, nil
+}
+
 func (p *AnsiblePlaybook) playbooks() error {
+	playbooks := p.globPlaybooks(p.Config.Playbooks)
+
+	if len(playbooks) == 0 {
+		return ErrNoPlaybooks
+	}
+
+	if p.Config.PlaybookOrderFile != "" {
+		ordered, err := p.orderPlaybooks(playbooks)
+		if err != nil {
+			return err
+		}
+
+		playbooks = ordered
+	}
+
+	p.Config.Playbooks = playbooks
+	return nil
+}
+
+// globPlaybooks resolves the playbook patterns. Relative patterns are
+// resolved in the playbook directory if configured, which results in
+// absolute paths, or else in the working directory. Patterns may contain **
+// to match any number of directories. The matches of every pattern are
+// sorted, playbooks matched by several patterns are only returned for the
+// first one, and playbooks matching an exclude pattern are skipped.
+func (p *AnsiblePlaybook) globPlaybooks(patterns []string) []string {
 	var (
 		playbooks []string
+		seen      = map[string]bool{}
 	)
 
-	for _, p := range p.Config.Playbooks {
-		files, err := filepath.Glob(p)
+	for _, pattern := range patterns {
+		path := p.playbookPath(pattern)
+
+		glob := filepath.Glob
+		if strings.Contains(path, "**") {
+			glob = globRecursive
+		}
+
+		files, err := glob(path)
 
 		if err != nil {
-			playbooks = append(playbooks, p)
+			playbooks = append(playbooks, path)
 			continue
 		}
 
-		playbooks = append(playbooks, files...)
+		// Playbooks of collections are referenced by their name.
+		if _, ok := playbookCollection(pattern); ok && len(files) == 0 {
+			files = []string{pattern}
+		}
+
+		sort.Strings(files)
+
+		for _, file := range files {
+			if !seen[filepath.Clean(file)] && !p.excludedPlaybook(file) {
+				seen[filepath.Clean(file)] = true
+				playbooks = append(playbooks, file)
+			}
+		}
 	}
 
-	if len(playbooks) == 0 {
-		return errors.New("failed to find playbook files")
+	return playbooks
+}
+
+// playbookPath returns the path of a playbook pattern in the playbook
+// directory.
+func (p *AnsiblePlaybook) playbookPath(pattern string) string {
+	if p.Config.PlaybookDir == "" || filepath.IsAbs(pattern) {
+		return pattern
 	}
 
-	p.Config.Playbooks = playbooks
-	return nil
+	if dir, err := filepath.Abs(p.Config.PlaybookDir); err == nil {
+		return filepath.Join(dir, pattern)
+	}
+
+	return filepath.Join(p.Config.PlaybookDir, pattern)
 }
 
 func (p *AnsiblePlaybook) versionCommand() *exec.Cmd {
@@ -188,7 +824,7 @@ func (p *AnsiblePlaybook) versionCommand() *exec.Cmd {
 	}
 
 	return exec.Command(
-		"ansible",
+		p.binary("ansible"),
 		args...,
 	)
 }
@@ -225,16 +861,19 @@ func (p *AnsiblePlaybook) galaxyRoleCommand() *exec.Cmd {
 		args = append(args, "--no-deps")
 	}
 
+	if p.Config.GalaxyRolesPath != "" {
+		args = append(args, "--roles-path", p.Config.GalaxyRolesPath)
+	}
+
 	if p.Config.GalaxyForceWithDeps {
 		args = append(args, "--force-with-deps")
 	}
 
-	if p.Config.Verbose > 0 {
-		args = append(args, fmt.Sprintf("-%s", strings.Repeat("v", p.Config.Verbose)))
-	}
+	args = append(args, p.verboseArgs()...)
+	args = append(args, p.optionArgs(CommandGalaxyRole)...)
 
 	return exec.Command(
-		"ansible-galaxy",
+		p.binary("ansible-galaxy"),
 		args...,
 	)
 }
@@ -275,6 +914,10 @@ func (p *AnsiblePlaybook) galaxyCollectionCommand() *exec.Cmd {
 		args = append(args, "--requirements-file", p.Config.GalaxyRequirementsFile)
 	}
 
+	if p.Config.GalaxyOffline || p.Config.GalaxyMirror != "" || p.Config.AirGapped {
+		args = append(args, "--offline")
+	}
+
 	if p.Config.GalaxyPre {
 		args = append(args, "--pre")
 	}
@@ -287,45 +930,61 @@ func (p *AnsiblePlaybook) galaxyCollectionCommand() *exec.Cmd {
 		args = append(args, "--force")
 	}
 
-	if p.Config.Verbose > 0 {
-		verboseFlag := fmt.Sprintf("-%s", strings.Repeat("v", p.Config.Verbose))
-		args = append(args, verboseFlag)
-	}
+	args = append(args, p.verboseArgs()...)
+	args = append(args, p.optionArgs(CommandGalaxyCollection)...)
 
 	return exec.Command(
-		"ansible-galaxy",
+		p.binary("ansible-galaxy"),
 		args...,
 	)
 }
 
 func (p *AnsiblePlaybook) ansibleCommand(inventory string) *exec.Cmd {
-	args := []string{
-		"--inventory",
-		inventory,
+	args := p.inventoryArgs(inventory)
+	if p.groupVars != "" {
+		args = append(args, "--inventory", p.groupVars)
 	}
 
 	if p.Config.SyntaxCheck {
 		args = append(args, "--syntax-check")
-		args = append(args, p.Config.Playbooks...)
+		args = append(args, playbookArgs(p.Config.Playbooks)...)
 
 		return exec.Command(
-			"ansible-playbook",
+			p.binary("ansible-playbook"),
 			args...,
 		)
 	}
 
 	if p.Config.ListHosts {
 		args = append(args, "--list-hosts")
-		args = append(args, p.Config.Playbooks...)
+		args = append(args, playbookArgs(p.Config.Playbooks)...)
 
 		return exec.Command(
-			"ansible-playbook",
+			p.binary("ansible-playbook"),
 			args...,
 		)
 	}
 
 	for _, v := range p.Config.ExtraVars {
-		args = append(args, "--extra-vars", v)
+		args = flagArg(args, "--extra-vars", v)
+	}
+
+	if p.runID != "" {
+		args = append(args, "--extra-vars", p.runIDExtraVars())
+	}
+
+	if p.metadata != "" {
+		args = append(args, "--extra-vars", p.metadata)
+	}
+
+	if vars := p.asyncExtraVars(); vars != "" {
+		args = append(args, "--extra-vars", vars)
+	}
+
+	if p.Config.DeadlineExtraVar {
+		if vars := p.deadlineExtraVars(); vars != "" {
+			args = append(args, "--extra-vars", vars)
+		}
 	}
 
 	if p.Config.Check {
@@ -349,7 +1008,7 @@ func (p *AnsiblePlaybook) ansibleCommand(inventory string) *exec.Cmd {
 	}
 
 	if p.Config.Limit != "" {
-		args = append(args, "--limit", p.Config.Limit)
+		args = flagArg(args, "--limit", p.Config.Limit)
 	}
 
 	if p.Config.ListTags {
@@ -365,21 +1024,25 @@ func (p *AnsiblePlaybook) ansibleCommand(inventory string) *exec.Cmd {
 	}
 
 	if p.Config.SkipTags != "" {
-		args = append(args, "--skip-tags", p.Config.SkipTags)
+		args = flagArg(args, "--skip-tags", p.Config.SkipTags)
 	}
 
 	if p.Config.StartAtTask != "" {
-		args = append(args, "--start-at-task", p.Config.StartAtTask)
+		args = flagArg(args, "--start-at-task", p.Config.StartAtTask)
 	}
 
 	if p.Config.Tags != "" {
-		args = append(args, "--tags", p.Config.Tags)
+		args = flagArg(args, "--tags", p.Config.Tags)
 	}
 
 	if p.Config.VaultID != "" {
 		args = append(args, "--vault-id", p.Config.VaultID)
 	}
 
+	for _, id := range p.Config.VaultIDs {
+		args = append(args, "--vault-id", id)
+	}
+
 	if p.Config.VaultPasswordFile != "" {
 		args = append(args, "--vault-password-file", p.Config.VaultPasswordFile)
 	}
@@ -389,15 +1052,15 @@ func (p *AnsiblePlaybook) ansibleCommand(inventory string) *exec.Cmd {
 	}
 
 	if p.Config.User != "" {
-		args = append(args, "--user", p.Config.User)
+		args = flagArg(args, "--user", p.Config.User)
 	}
 
 	if p.Config.Connection != "" {
 		args = append(args, "--connection", p.Config.Connection)
 	}
 
-	if p.Config.Timeout != 0 {
-		args = append(args, "--timeout", strconv.Itoa(p.Config.Timeout))
+	if timeout := p.Config.connectTimeout(); timeout != 0 {
+		args = append(args, "--timeout", strconv.Itoa(timeout))
 	}
 
 	if p.Config.SSHCommonArgs != "" {
@@ -425,22 +1088,58 @@ func (p *AnsiblePlaybook) ansibleCommand(inventory string) *exec.Cmd {
 	}
 
 	if p.Config.BecomeUser != "" {
-		args = append(args, "--become-user", p.Config.BecomeUser)
+		args = flagArg(args, "--become-user", p.Config.BecomeUser)
 	}
 
-	if p.Config.Verbose > 0 {
-		verboseFlag := fmt.Sprintf("-%s", strings.Repeat("v", p.Config.Verbose))
-		args = append(args, verboseFlag)
-	}
+	args = append(args, p.verboseArgs()...)
+	args = append(args, p.optionArgs(CommandPlaybook)...)
 
-	args = append(args, p.Config.Playbooks...)
+	args = append(args, playbookArgs(p.Config.Playbooks)...)
 
 	return exec.Command(
-		"ansible-playbook",
+		p.binary("ansible-playbook"),
 		args...,
 	)
 }
 
-func trace(cmd *exec.Cmd) {
-	fmt.Println("$", strings.Join(cmd.Args, " "))
+func (p *AnsiblePlaybook) trace(cmd *exec.Cmd) {
+	if p.Config.Debug {
+		p.traceEnv(p.environ())
+	}
+
+	fmt.Fprintln(p.output(), "$", string(p.redact([]byte(p.commandLine(cmd)))))
+}
+
+// commandLine returns the command line of the command followed by the run
+// ID, so every command of a run can be correlated with it.
+func (p *AnsiblePlaybook) commandLine(cmd *exec.Cmd) string {
+	line := shellJoin(cmd.Args)
+	if p.runID != "" {
+		line += "  # run " + p.runID
+	}
+
+	return line
+}
+
+// shellJoin joins the arguments to a command line which can be pasted into
+// a POSIX shell.
+func shellJoin(args []string) string {
+	quoted := make([]string, 0, len(args))
+	for _, arg := range args {
+		quoted = append(quoted, shellQuote(arg))
+	}
+
+	return strings.Join(quoted, " ")
+}
+
+func shellQuote(arg string) string {
+	if arg == "" {
+		return "''"
+	}
+
+	if !unsafeShellChars.MatchString(arg) {
+		return arg
+	}
+
+	return "'" + strings.ReplaceAll(arg, "'", `'"'"'`) + "'"
 }
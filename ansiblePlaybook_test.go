@@ -2,6 +2,8 @@ package ansible
 
 import (
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -88,3 +90,115 @@ func TestVaultPass(t *testing.T) {
 
 	// Cleanup (delete file) if necessary.
 }
+
+// TestGalaxyIsolate tests that galaxy content is installed into a per-run directory.
+func TestGalaxyIsolate(t *testing.T) {
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			GalaxyFile:    "requirements.yml",
+			GalaxyIsolate: true,
+		},
+	}
+
+	dir, err := playbook.galaxyIsolate()
+	if err != nil {
+		t.Fatalf("galaxyIsolate should not return an error, but received: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Assert that the collection install targets the per-run directory.
+	args := strings.Join(playbook.galaxyCollectionCommand().Args, " ")
+	if !strings.Contains(args, "--collections-path "+filepath.Join(dir, "collections")) {
+		t.Errorf("Expected collection install into per-run directory, got '%s'", args)
+	}
+
+	// Assert that the playbook environment points to the per-run directory.
+	env := strings.Join(playbook.environ(), "\n")
+	if !strings.Contains(env, "ANSIBLE_COLLECTIONS_PATH="+filepath.Join(dir, "collections")) {
+		t.Error("Expected ANSIBLE_COLLECTIONS_PATH to point to the per-run directory")
+	}
+}
+
+// TestSkipVersionCheck tests that the version command can be skipped.
+func TestSkipVersionCheck(t *testing.T) {
+	bin := t.TempDir()
+
+	// The fake ansible binary fails, so running it would fail the run.
+	if err := os.WriteFile(filepath.Join(bin, "ansible"), []byte("#!/bin/sh\nexit 1\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir:    bin,
+			Playbooks:        []string{"tests/test.yml"},
+			SkipVersionCheck: true,
+		},
+	}
+
+	if err := playbook.Exec(); err != nil {
+		t.Errorf("Exec should skip the version command, but received: %v", err)
+	}
+}
+
+// TestGalaxyOnly tests that only the galaxy commands run in galaxy only mode.
+func TestGalaxyOnly(t *testing.T) {
+	bin := t.TempDir()
+	log := filepath.Join(bin, "calls.log")
+
+	requirements := filepath.Join(bin, "requirements.yml")
+	if err := os.WriteFile(requirements, []byte("---\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"ansible", "ansible-galaxy", "ansible-playbook"} {
+		script := "#!/bin/sh\necho " + name + " >> " + log + "\n"
+		if err := os.WriteFile(filepath.Join(bin, name), []byte(script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir:    bin,
+			GalaxyFile:       requirements,
+			GalaxyOnly:       true,
+			Inventories:      []string{"production"},
+			Playbooks:        []string{"missing.yml"},
+			SkipVersionCheck: true,
+		},
+	}
+
+	if err := playbook.Exec(); err != nil {
+		t.Fatalf("Exec should execute without error, but received: %v", err)
+	}
+
+	content, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Assert that the role and collection install ran but no playbook.
+	if string(content) != "ansible-galaxy\nansible-galaxy\n" {
+		t.Errorf("Unexpected commands:\n%s", content)
+	}
+}
+
+// TestShellJoin tests the traced command lines are shell quoted.
+func TestShellJoin(t *testing.T) {
+	args := []string{
+		"ansible-playbook",
+		"--extra-vars",
+		`{"name": "it's"}`,
+		"--ssh-common-args",
+		"-o ProxyJump=bastion",
+		"--limit",
+		"",
+		"site.yml",
+	}
+
+	expected := `ansible-playbook --extra-vars '{"name": "it'"'"'s"}' --ssh-common-args '-o ProxyJump=bastion' --limit '' site.yml`
+	if line := shellJoin(args); line != expected {
+		t.Errorf("Expected command line %s, got %s", expected, line)
+	}
+}
@@ -0,0 +1,180 @@
+package ansible
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	sanityTestPattern   = regexp.MustCompile(`^Running sanity test '?"?([\w-]+)`)
+	sanityIssuePattern  = regexp.MustCompile(`^ERROR: ([^:\s]+):(\d+):(\d+): (.*)$`)
+	pytestFailedPattern = regexp.MustCompile(`^FAILED (\S+?)(?:::(\S+))?(?: - (.*))?$`)
+	testErrorPattern    = regexp.MustCompile(`^ERROR: (.*)$`)
+)
+
+// AnsibleTest configures a run of `ansible-test` in a collection.
+type AnsibleTest struct {
+	// Command is one of sanity, units or integration.
+	Command       string
+	CollectionDir string
+	Targets       []string
+	Tests         []string
+	SkipTests     []string
+	Docker        string
+	Venv          bool
+	Python        string
+	Requirements  bool
+	Coverage      bool
+}
+
+// AnsibleTestFailure is a single failure reported by ansible-test.
+type AnsibleTestFailure struct {
+	Test    string
+	Path    string
+	Line    int
+	Column  int
+	Message string
+}
+
+// AnsibleTestResult is the parsed result of an ansible-test run.
+type AnsibleTestResult struct {
+	Command  string
+	Passed   bool
+	Failures []AnsibleTestFailure
+}
+
+// AnsibleTest runs ansible-test and parses the reported failures. A failed
+// test run is reported in the result, the error is only set if ansible-test
+// could not be run.
+func (p *AnsiblePlaybook) AnsibleTest(test AnsibleTest) (*AnsibleTestResult, error) {
+	switch test.Command {
+	case "sanity", "units", "integration":
+	default:
+		return nil, fmt.Errorf("unsupported ansible-test command %q", test.Command)
+	}
+
+	var output bytes.Buffer
+
+	cmd := p.ansibleTestCommand(test)
+	cmd.Dir = test.CollectionDir
+	p.trace(cmd)
+
+	stdout := p.stdout()
+	err := p.runOutput(cmd, io.MultiWriter(stdout, &output))
+	stdout.Flush()
+
+	var exitErr *CommandError
+	if err != nil && !errors.As(err, &exitErr) {
+		return nil, fmt.Errorf("failed to run ansible-test: %w", err)
+	}
+
+	return &AnsibleTestResult{
+		Command:  test.Command,
+		Passed:   err == nil,
+		Failures: parseAnsibleTestOutput(test.Command, output.Bytes()),
+	}, nil
+}
+
+func (p *AnsiblePlaybook) ansibleTestCommand(test AnsibleTest) *exec.Cmd {
+	args := []string{
+		test.Command,
+	}
+
+	for _, name := range test.Tests {
+		args = append(args, "--test", name)
+	}
+
+	for _, name := range test.SkipTests {
+		args = append(args, "--skip-test", name)
+	}
+
+	if test.Docker != "" {
+		args = append(args, "--docker", test.Docker)
+	}
+
+	if test.Venv {
+		args = append(args, "--venv")
+	}
+
+	if test.Python != "" {
+		args = append(args, "--python", test.Python)
+	}
+
+	if test.Requirements {
+		args = append(args, "--requirements")
+	}
+
+	if test.Coverage {
+		args = append(args, "--coverage")
+	}
+
+	args = append(args, (&ArgBuilder{}).Verbose(p.verbosity()).Args()...)
+
+	args = append(args, test.Targets...)
+
+	return exec.Command(
+		p.binary("ansible-test"),
+		args...,
+	)
+}
+
+func parseAnsibleTestOutput(command string, output []byte) []AnsibleTestFailure {
+	var (
+		failures []AnsibleTestFailure
+		current  = command
+	)
+
+	scanner := bufio.NewScanner(bytes.NewReader(ansiEscapePattern.ReplaceAll(output, nil)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if match := sanityTestPattern.FindStringSubmatch(line); match != nil {
+			current = match[1]
+			continue
+		}
+
+		if match := sanityIssuePattern.FindStringSubmatch(line); match != nil {
+			row, _ := strconv.Atoi(match[2])
+			column, _ := strconv.Atoi(match[3])
+
+			failures = append(failures, AnsibleTestFailure{
+				Test:    current,
+				Path:    match[1],
+				Line:    row,
+				Column:  column,
+				Message: match[4],
+			})
+
+			continue
+		}
+
+		if match := pytestFailedPattern.FindStringSubmatch(line); match != nil {
+			failures = append(failures, AnsibleTestFailure{
+				Test:    match[2],
+				Path:    match[1],
+				Message: match[3],
+			})
+
+			continue
+		}
+
+		// Other errors of sanity tests only summarize the issues above.
+		if command == "integration" {
+			if match := testErrorPattern.FindStringSubmatch(line); match != nil {
+				failures = append(failures, AnsibleTestFailure{
+					Test:    current,
+					Message: match[1],
+				})
+			}
+		}
+	}
+
+	return failures
+}
@@ -0,0 +1,42 @@
+package ansible
+
+import (
+	"testing"
+)
+
+// TestParseAnsibleTestOutput tests parsing sanity and unit test failures.
+func TestParseAnsibleTestOutput(t *testing.T) {
+	sanity := []byte(`Running sanity test "pylint"
+ERROR: Found 1 pylint issue(s) which need to be resolved:
+ERROR: plugins/modules/system.py:12:1: unused-import: Unused import os
+Running sanity test "validate-modules"
+ERROR: plugins/modules/system.py:0:0: missing-documentation: No DOCUMENTATION provided
+`)
+
+	failures := parseAnsibleTestOutput("sanity", sanity)
+	if len(failures) != 2 {
+		t.Fatalf("Expected 2 failures, got %+v", failures)
+	}
+
+	expected := AnsibleTestFailure{
+		Test:    "pylint",
+		Path:    "plugins/modules/system.py",
+		Line:    12,
+		Column:  1,
+		Message: "unused-import: Unused import os",
+	}
+	if failures[0] != expected {
+		t.Errorf("Expected %+v, got %+v", expected, failures[0])
+	}
+
+	if failures[1].Test != "validate-modules" {
+		t.Errorf("Expected validate-modules failure, got %+v", failures[1])
+	}
+
+	units := []byte("FAILED tests/unit/plugins/modules/test_system.py::test_run - AssertionError: assert 1 == 2\n")
+
+	failures = parseAnsibleTestOutput("units", units)
+	if len(failures) != 1 || failures[0].Test != "test_run" || failures[0].Path != "tests/unit/plugins/modules/test_system.py" {
+		t.Errorf("Unexpected unit failures: %+v", failures)
+	}
+}
@@ -0,0 +1,42 @@
+package ansibletest
+
+import (
+	"testing"
+)
+
+// AssertCalled fails the test if the executable was not called exactly the
+// given number of times, and returns its calls.
+func (f *Fake) AssertCalled(t testing.TB, name string, times int) []Call {
+	t.Helper()
+
+	calls := f.CallsOf(name)
+	if len(calls) != times {
+		t.Fatalf("Expected %s to be called %d time(s), got %d: %v", name, times, len(calls), calls)
+	}
+
+	return calls
+}
+
+// AssertFlag fails the test if the call did not get the flag with the value.
+func AssertFlag(t testing.TB, call Call, flag, value string) {
+	t.Helper()
+
+	actual, ok := call.Flag(flag)
+	if !ok {
+		t.Errorf("Expected %s to be called with %s, got %v", call.Name, flag, call.Args)
+		return
+	}
+
+	if actual != value {
+		t.Errorf("Expected %s %s to be '%s', got '%s'", call.Name, flag, value, actual)
+	}
+}
+
+// AssertNoFlag fails the test if the call got the flag.
+func AssertNoFlag(t testing.TB, call Call, flag string) {
+	t.Helper()
+
+	if values := call.FlagValues(flag); len(values) > 0 || call.HasArg(flag) {
+		t.Errorf("Expected %s not to be called with %s, got %v", call.Name, flag, call.Args)
+	}
+}
@@ -0,0 +1,233 @@
+// Package ansibletest provides a fake ansible installation to test code
+// using the ansible package without ansible being installed.
+package ansibletest
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	ansible "github.com/arillso/go.ansible"
+)
+
+// Binaries are the executables provided by a fake installation.
+var Binaries = []string{
+	"ansible",
+	"ansible-galaxy",
+	"ansible-inventory",
+	"ansible-playbook",
+	"ansible-test",
+	"ansible-vault",
+}
+
+// Fake is a directory of fake ansible executables which record their calls.
+// Use Dir as AnsibleBinDir of the configuration.
+type Fake struct {
+	Dir string
+
+	t  testing.TB
+	mu sync.Mutex
+}
+
+// Call is a recorded invocation of a fake executable.
+type Call struct {
+	Name string
+	Args []string
+}
+
+// NewFake creates a fake installation in a temporary directory. All
+// executables succeed without output until configured otherwise.
+func NewFake(t testing.TB) *Fake {
+	t.Helper()
+
+	f := &Fake{
+		Dir: t.TempDir(),
+		t:   t,
+	}
+
+	if err := os.Mkdir(f.stateDir(), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range Binaries {
+		script := fmt.Sprintf(
+			"#!/bin/sh\nprintf '%%s\\0' %s \"$#\" \"$@\" >> %s\ncat %s 2>/dev/null\nexit \"$(cat %s 2>/dev/null || echo 0)\"\n",
+			name,
+			quote(f.logFile()),
+			quote(f.stateFile(name, "out")),
+			quote(f.stateFile(name, "exit")),
+		)
+
+		if err := os.WriteFile(filepath.Join(f.Dir, name), []byte(script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return f
+}
+
+// SetExitCode sets the exit code of an executable.
+func (f *Fake) SetExitCode(name string, code int) {
+	f.t.Helper()
+	f.write(name, "exit", strconv.Itoa(code))
+}
+
+// SetOutput sets the output an executable writes on every call.
+func (f *Fake) SetOutput(name, output string) {
+	f.t.Helper()
+	f.write(name, "out", output)
+}
+
+// Calls returns the recorded calls in the order they were made.
+func (f *Fake) Calls() []Call {
+	f.t.Helper()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	content, err := os.ReadFile(f.logFile())
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		f.t.Fatal(err)
+	}
+
+	var (
+		calls  []Call
+		fields = bytes.Split(bytes.TrimSuffix(content, []byte{0}), []byte{0})
+	)
+
+	for i := 0; i+1 < len(fields); {
+		n, err := strconv.Atoi(string(fields[i+1]))
+		if err != nil || i+2+n > len(fields) {
+			f.t.Fatalf("corrupt call log of fake ansible")
+		}
+
+		call := Call{Name: string(fields[i])}
+		for _, arg := range fields[i+2 : i+2+n] {
+			call.Args = append(call.Args, string(arg))
+		}
+
+		calls = append(calls, call)
+		i += 2 + n
+	}
+
+	return calls
+}
+
+// CallsOf returns the recorded calls of an executable.
+func (f *Fake) CallsOf(name string) []Call {
+	f.t.Helper()
+
+	var calls []Call
+	for _, call := range f.Calls() {
+		if call.Name == name {
+			calls = append(calls, call)
+		}
+	}
+
+	return calls
+}
+
+func (f *Fake) write(name, kind, content string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := os.WriteFile(f.stateFile(name, kind), []byte(content), 0o644); err != nil {
+		f.t.Fatal(err)
+	}
+}
+
+func (f *Fake) stateDir() string {
+	return filepath.Join(f.Dir, ".fake")
+}
+
+func (f *Fake) stateFile(name, kind string) string {
+	return filepath.Join(f.stateDir(), name+"."+kind)
+}
+
+func (f *Fake) logFile() string {
+	return filepath.Join(f.stateDir(), "calls")
+}
+
+// Flag returns the value of the last occurrence of a flag, either passed as
+// separate argument or as --flag=value.
+func (c Call) Flag(flag string) (string, bool) {
+	values := c.FlagValues(flag)
+	if len(values) == 0 {
+		return "", false
+	}
+
+	return values[len(values)-1], true
+}
+
+// FlagValues returns the values of all occurrences of a flag.
+func (c Call) FlagValues(flag string) []string {
+	var values []string
+
+	for i, arg := range c.Args {
+		switch {
+		case arg == flag && i+1 < len(c.Args):
+			values = append(values, c.Args[i+1])
+		case strings.HasPrefix(arg, flag+"="):
+			values = append(values, strings.TrimPrefix(arg, flag+"="))
+		}
+	}
+
+	return values
+}
+
+// HasArg reports whether the call got the argument.
+func (c Call) HasArg(arg string) bool {
+	for _, a := range c.Args {
+		if a == arg {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Recap returns the output of a playbook run with the play recap of the
+// given hosts, as written by the default stdout callback.
+func Recap(stats map[string]ansible.HostStats) string {
+	hosts := make([]string, 0, len(stats))
+	for host := range stats {
+		hosts = append(hosts, host)
+	}
+
+	sort.Strings(hosts)
+
+	var b strings.Builder
+	b.WriteString("PLAY RECAP *********************************************************************\n")
+
+	for _, host := range hosts {
+		s := stats[host]
+		fmt.Fprintf(
+			&b,
+			"%-26s : ok=%-4d changed=%-4d unreachable=%-4d failed=%-4d skipped=%-4d rescued=%-4d ignored=%d\n",
+			host,
+			s.Ok,
+			s.Changed,
+			s.Unreachable,
+			s.Failed,
+			s.Skipped,
+			s.Rescued,
+			s.Ignored,
+		)
+	}
+
+	return b.String()
+}
+
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
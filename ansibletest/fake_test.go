@@ -0,0 +1,48 @@
+package ansibletest
+
+import (
+	"testing"
+
+	ansible "github.com/arillso/go.ansible"
+)
+
+// TestFake tests the fake records the calls of a playbook run and returns
+// the configured output.
+func TestFake(t *testing.T) {
+	fake := NewFake(t)
+	fake.SetOutput("ansible-playbook", Recap(map[string]ansible.HostStats{
+		"web1": {Ok: 3, Changed: 1},
+		"web2": {Ok: 2, Failed: 1},
+	}))
+	fake.SetExitCode("ansible-playbook", 2)
+
+	playbook := &ansible.AnsiblePlaybook{
+		Config: ansible.Config{
+			AnsibleBinDir: fake.Dir,
+			ExtraVars:     []string{"message=hello world"},
+			Inventories:   []string{"../tests/inventories/production"},
+			Limit:         "web*",
+			Playbooks:     []string{"../tests/test.yml"},
+		},
+	}
+
+	if err := playbook.Exec(); err == nil {
+		t.Fatal("Expected the run to fail with the configured exit code")
+	}
+
+	fake.AssertCalled(t, "ansible", 1)
+	call := fake.AssertCalled(t, "ansible-playbook", 1)[0]
+
+	AssertFlag(t, call, "--inventory", "../tests/inventories/production")
+	if vars := call.FlagValues("--extra-vars"); len(vars) != 2 || vars[0] != "message=hello world" {
+		t.Errorf("Expected the extra vars and the run ID, got %v", vars)
+	}
+
+	AssertFlag(t, call, "--limit", "web*")
+	AssertNoFlag(t, call, "--check")
+
+	stats := playbook.Results[0].Stats
+	if stats["web1"].Changed != 1 || stats["web2"].Failed != 1 {
+		t.Errorf("Expected the canned recap to be parsed, got %+v", stats)
+	}
+}
@@ -0,0 +1,62 @@
+package ansibletest
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	ansible "github.com/arillso/go.ansible"
+)
+
+// UpdateGoldenEnv is the environment variable which, if set, makes
+// AssertGolden write the golden files instead of comparing them.
+const UpdateGoldenEnv = "ANSIBLETEST_UPDATE_GOLDEN"
+
+// AssertGolden fails the test if the commands built for the playbook differ
+// from the commands stored in the golden file.
+func AssertGolden(t testing.TB, path string, playbook *ansible.AnsiblePlaybook) {
+	t.Helper()
+
+	specs, err := playbook.BuildCommands()
+	if err != nil {
+		t.Fatalf("failed to build commands: %v", err)
+	}
+
+	AssertGoldenCommands(t, path, specs)
+}
+
+// AssertGoldenCommands fails the test if the commands differ from the
+// commands stored in the golden file.
+func AssertGoldenCommands(t testing.TB, path string, specs []ansible.CommandSpec) {
+	t.Helper()
+
+	actual, err := json.MarshalIndent(specs, "", "  ")
+	if err != nil {
+		t.Fatalf("failed to encode commands: %v", err)
+	}
+
+	actual = append(actual, '\n')
+
+	if os.Getenv(UpdateGoldenEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, actual, 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		return
+	}
+
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file, set %s=1 to create it: %v", UpdateGoldenEnv, err)
+	}
+
+	if !bytes.Equal(expected, actual) {
+		t.Errorf("commands differ from golden file %s, set %s=1 to update it\nexpected:\n%s\nactual:\n%s", path, UpdateGoldenEnv, expected, actual)
+	}
+}
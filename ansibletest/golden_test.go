@@ -0,0 +1,23 @@
+package ansibletest
+
+import (
+	"testing"
+
+	ansible "github.com/arillso/go.ansible"
+)
+
+// TestAssertGolden tests the commands are compared with the golden file.
+func TestAssertGolden(t *testing.T) {
+	playbook := &ansible.AnsiblePlaybook{
+		Config: ansible.Config{
+			Check:       true,
+			Forks:       5,
+			GalaxyFile:  "requirements.yml",
+			Inventories: []string{"production"},
+			Playbooks:   []string{"../tests/test.yml"},
+			Tags:        "deploy",
+		},
+	}
+
+	AssertGolden(t, "testdata/commands.golden.json", playbook)
+}
@@ -0,0 +1,37 @@
+package ansible
+
+import (
+	"context"
+	"fmt"
+)
+
+// ApprovalFunc is called before the run against every inventory but the first
+// with the result of the previous inventory. The run stops unless it returns
+// nil, e.g. to wait for a human approval between staging and production.
+type ApprovalFunc func(ctx context.Context, inventory string, previous *RunResult) error
+
+// ApprovalError is returned if the run against an inventory was not approved.
+type ApprovalError struct {
+	Inventory string
+	Err       error
+}
+
+func (e *ApprovalError) Error() string {
+	return fmt.Sprintf("run against inventory %s not approved: %v", e.Inventory, e.Err)
+}
+
+func (e *ApprovalError) Unwrap() error {
+	return e.Err
+}
+
+// approve asks for the approval of the run against the inventory.
+func (p *AnsiblePlaybook) approve(inventory string, previous *RunResult) error {
+	if err := p.Config.Approval(p.context(), inventory, previous); err != nil {
+		return &ApprovalError{
+			Inventory: inventory,
+			Err:       err,
+		}
+	}
+
+	return nil
+}
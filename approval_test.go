@@ -0,0 +1,63 @@
+package ansible
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestApproval tests the run stops before the next inventory unless it is
+// approved.
+func TestApproval(t *testing.T) {
+	bin := t.TempDir()
+	log := filepath.Join(bin, "calls.log")
+
+	script := "#!/bin/sh\necho \"$@\" >> " + log + "\n"
+	if err := os.WriteFile(filepath.Join(bin, "ansible-playbook"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	denied := errors.New("denied by operator")
+
+	var approvals []string
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir: bin,
+			Approval: func(ctx context.Context, inventory string, previous *RunResult) error {
+				approvals = append(approvals, inventory)
+
+				if previous == nil || previous.Inventory != "tests/inventories/staging" {
+					t.Errorf("Expected the result of staging, got %+v", previous)
+				}
+
+				return denied
+			},
+			Inventories:      []string{"tests/inventories/staging", "tests/inventories/production"},
+			Playbooks:        []string{"tests/test.yml"},
+			SkipVersionCheck: true,
+		},
+	}
+
+	err := playbook.Exec()
+
+	var approval *ApprovalError
+	if !errors.As(err, &approval) || !errors.Is(err, denied) || approval.Inventory != "tests/inventories/production" {
+		t.Fatalf("Expected an ApprovalError for production, got %v", err)
+	}
+
+	if len(approvals) != 1 {
+		t.Errorf("Expected a single approval, got %v", approvals)
+	}
+
+	content, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(content), "production") {
+		t.Errorf("Expected no run against production, got '%s'", content)
+	}
+}
@@ -0,0 +1,132 @@
+package ansible
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Commands options can be registered for.
+const (
+	CommandPlaybook         = "ansible-playbook"
+	CommandGalaxyRole       = "ansible-galaxy role install"
+	CommandGalaxyCollection = "ansible-galaxy collection install"
+)
+
+// ArgOption adds the arguments of an option to a command, e.g. a flag of an
+// ansible release which is not supported by the configuration yet.
+type ArgOption func(config *Config, args *ArgBuilder)
+
+// ArgBuilder collects the arguments of an ansible command. Values are passed
+// like the values of the built-in options, i.e. values starting with a dash
+// as --flag=value, and are checked in safe mode.
+type ArgBuilder struct {
+	args []string
+	safe bool
+	err  error
+}
+
+// Flag adds a flag without a value.
+func (b *ArgBuilder) Flag(flag string) *ArgBuilder {
+	if b.checkFlag(flag) {
+		b.args = append(b.args, flag)
+	}
+
+	return b
+}
+
+// Option adds a flag with its value. Empty values are skipped.
+func (b *ArgBuilder) Option(flag, value string) *ArgBuilder {
+	if value == "" || !b.checkFlag(flag) {
+		return b
+	}
+
+	if b.safe && b.err == nil {
+		b.err = checkSafeValue("option "+flag, value)
+	}
+
+	b.args = flagArg(b.args, flag, value)
+	return b
+}
+
+// Verbose adds the verbose flag of the level, limited to -vvvvvv.
+func (b *ArgBuilder) Verbose(level int) *ArgBuilder {
+	if level > maxVerbosity {
+		level = maxVerbosity
+	}
+
+	if level > 0 {
+		b.args = append(b.args, "-"+strings.Repeat("v", level))
+	}
+
+	return b
+}
+
+// Args returns the collected arguments.
+func (b *ArgBuilder) Args() []string {
+	return b.args
+}
+
+// Err returns the first invalid flag or, in safe mode, unsafe value.
+func (b *ArgBuilder) Err() error {
+	return b.err
+}
+
+func (b *ArgBuilder) checkFlag(flag string) bool {
+	if strings.HasPrefix(flag, "-") && !strings.ContainsAny(flag, " =") {
+		return true
+	}
+
+	if b.err == nil {
+		b.err = fmt.Errorf("invalid flag %q", flag)
+	}
+
+	return false
+}
+
+// RegisterOption registers an option for a command, one of the Command
+// constants. Registered options are applied after the built-in options in
+// the order of their registration.
+func (p *AnsiblePlaybook) RegisterOption(command string, option ArgOption) {
+	if p.options == nil {
+		p.options = map[string][]ArgOption{}
+	}
+
+	p.options[command] = append(p.options[command], option)
+}
+
+// optionArgs returns the arguments of the options registered for the
+// command.
+func (p *AnsiblePlaybook) optionArgs(command string) []string {
+	args, _ := p.buildOptions(command)
+	return args
+}
+
+func (p *AnsiblePlaybook) buildOptions(command string) ([]string, error) {
+	builder := &ArgBuilder{safe: p.Config.SafeMode}
+	for _, option := range p.options[command] {
+		option(&p.Config, builder)
+	}
+
+	return builder.Args(), builder.Err()
+}
+
+// checkOptions returns the first invalid flag or unsafe value of the
+// registered options.
+func (p *AnsiblePlaybook) checkOptions() error {
+	for _, command := range sortedKeys(optionCommands(p.options)) {
+		if _, err := p.buildOptions(command); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func optionCommands(options map[string][]ArgOption) map[string]bool {
+	commands := make(map[string]bool, len(options))
+	for command := range options {
+		commands[command] = true
+	}
+
+	return commands
+}
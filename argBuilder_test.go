@@ -0,0 +1,70 @@
+package ansible
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestRegisterOption tests registered options are added to their command
+// with the quoting of the built-in options.
+func TestRegisterOption(t *testing.T) {
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			GalaxyFile: "requirements.yml",
+			Playbooks:  []string{"tests/test.yml"},
+			Tags:       "deploy",
+		},
+	}
+
+	playbook.RegisterOption(CommandPlaybook, func(config *Config, args *ArgBuilder) {
+		args.Flag("--new-flag").Option("--new-option", "-value").Option("--empty", "")
+	})
+
+	playbook.RegisterOption(CommandGalaxyCollection, func(config *Config, args *ArgBuilder) {
+		args.Option("--signature", config.GalaxyFile+".asc")
+	})
+
+	args := strings.Join(playbook.ansibleCommand("tests/inventories/production").Args, " ")
+	if !strings.HasSuffix(args, "--new-flag --new-option=-value tests/test.yml") || strings.Contains(args, "--empty") {
+		t.Errorf("Expected the options before the playbooks, got %s", args)
+	}
+
+	if args := strings.Join(playbook.galaxyRoleCommand().Args, " "); strings.Contains(args, "--signature") {
+		t.Errorf("Expected no collection options for roles, got %s", args)
+	}
+
+	if args := strings.Join(playbook.galaxyCollectionCommand().Args, " "); !strings.HasSuffix(args, "--signature requirements.yml.asc") {
+		t.Errorf("Expected the signature option, got %s", args)
+	}
+}
+
+// TestRegisterOptionValidation tests invalid flags and, in safe mode, unsafe
+// values of registered options fail the run.
+func TestRegisterOptionValidation(t *testing.T) {
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			Inventories: []string{"tests/inventories/production"},
+			Playbooks:   []string{"tests/test.yml"},
+			SafeMode:    true,
+		},
+	}
+
+	playbook.RegisterOption(CommandPlaybook, func(config *Config, args *ArgBuilder) {
+		args.Option("--vars", "a\nb")
+	})
+
+	var unsafe *UnsafeValueError
+	if err := playbook.Exec(); !errors.As(err, &unsafe) || unsafe.Field != "option --vars" {
+		t.Errorf("Expected an UnsafeValueError for the option, got %v", err)
+	}
+
+	playbook.options = nil
+	playbook.RegisterOption(CommandPlaybook, func(config *Config, args *ArgBuilder) {
+		args.Flag("new-flag")
+	})
+
+	if _, err := playbook.BuildCommands(); err == nil || !strings.Contains(err.Error(), "invalid flag") {
+		t.Errorf("Expected an invalid flag error, got %v", err)
+	}
+}
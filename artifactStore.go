@@ -0,0 +1,191 @@
+package ansible
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ArtifactStore uploads the artifacts of a run once it completed, e.g. to
+// an object storage.
+type ArtifactStore interface {
+	// Upload uploads the files of the directory below the prefix.
+	Upload(ctx context.Context, dir, prefix string) error
+}
+
+// ArtifactUploadError is returned if the artifacts of a successful run
+// could not be uploaded.
+type ArtifactUploadError struct {
+	Prefix string
+	Err    error
+}
+
+func (e *ArtifactUploadError) Error() string {
+	return fmt.Sprintf("failed to upload artifacts to %s: %s", e.Prefix, e.Err)
+}
+
+func (e *ArtifactUploadError) Unwrap() error {
+	return e.Err
+}
+
+// S3Store uploads the artifacts to an S3 bucket with the aws CLI, which
+// resolves the credentials from its environment and configuration.
+type S3Store struct {
+	AWS      string
+	Bucket   string
+	Prefix   string
+	Region   string
+	Endpoint string
+	Profile  string
+}
+
+func (s *S3Store) Upload(ctx context.Context, dir, prefix string) error {
+	args := []string{
+		"s3",
+		"cp",
+		"--recursive",
+		"--only-show-errors",
+		dir,
+		"s3://" + path.Join(s.Bucket, s.Prefix, prefix) + "/",
+	}
+
+	if s.Region != "" {
+		args = append(args, "--region", s.Region)
+	}
+
+	if s.Endpoint != "" {
+		args = append(args, "--endpoint-url", s.Endpoint)
+	}
+
+	if s.Profile != "" {
+		args = append(args, "--profile", s.Profile)
+	}
+
+	return runStore(ctx, storeBinary(s.AWS, "aws"), args)
+}
+
+// GCSStore uploads the artifacts to a Google Cloud Storage bucket with the
+// gcloud CLI, which resolves the credentials from its configuration.
+type GCSStore struct {
+	Gcloud  string
+	Bucket  string
+	Prefix  string
+	Project string
+}
+
+func (s *GCSStore) Upload(ctx context.Context, dir, prefix string) error {
+	args := []string{
+		"storage",
+		"rsync",
+		"--recursive",
+		dir,
+		"gs://" + path.Join(s.Bucket, s.Prefix, prefix),
+	}
+
+	if s.Project != "" {
+		args = append(args, "--project", s.Project)
+	}
+
+	return runStore(ctx, storeBinary(s.Gcloud, "gcloud"), args)
+}
+
+// AzureBlobStore uploads the artifacts to an Azure Blob Storage container
+// with the az CLI, which resolves the credentials from its login or the
+// AZURE_STORAGE_* variables.
+type AzureBlobStore struct {
+	Az        string
+	Account   string
+	Container string
+	Prefix    string
+}
+
+func (s *AzureBlobStore) Upload(ctx context.Context, dir, prefix string) error {
+	args := []string{
+		"storage",
+		"blob",
+		"upload-batch",
+		"--source", dir,
+		"--destination", s.Container,
+		"--destination-path", path.Join(s.Prefix, prefix),
+		"--overwrite",
+		"--only-show-errors",
+	}
+
+	if s.Account != "" {
+		args = append(args, "--account-name", s.Account)
+	}
+
+	return runStore(ctx, storeBinary(s.Az, "az"), args)
+}
+
+func storeBinary(binary, name string) string {
+	if binary == "" {
+		return name
+	}
+
+	return binary
+}
+
+// runStore runs the CLI of a store, failing with its error output.
+func runStore(ctx context.Context, binary string, args []string) error {
+	var stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, binary, args...)
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s %s failed: %s", filepath.Base(binary), args[0], msg)
+		}
+
+		return fmt.Errorf("%s %s failed: %w", filepath.Base(binary), args[0], err)
+	}
+
+	return nil
+}
+
+// uploadArtifacts writes the summary and the results of the run to the
+// artifact directory, or a temporary directory if none is configured, and
+// uploads it to the artifact store below the run ID. The upload runs even if
+// the run was canceled.
+func (p *AnsiblePlaybook) uploadArtifacts(started time.Time, runErr error) error {
+	dir := p.Config.ArtifactDir
+	if dir == "" {
+		tmpdir, err := os.MkdirTemp("", "artifacts")
+		if err != nil {
+			return &ArtifactUploadError{Prefix: p.runID, Err: err}
+		}
+
+		defer os.RemoveAll(tmpdir)
+		dir = tmpdir
+	} else if err := p.mkdirAll(dir); err != nil {
+		return &ArtifactUploadError{Prefix: p.runID, Err: err}
+	}
+
+	for name, value := range map[string]interface{}{
+		"summary.json": p.runSummary(started, runErr),
+		"results.json": p.Results,
+	} {
+		content, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return &ArtifactUploadError{Prefix: p.runID, Err: err}
+		}
+
+		if err := p.writeFile(filepath.Join(dir, name), p.redact(append(content, '\n'))); err != nil {
+			return &ArtifactUploadError{Prefix: p.runID, Err: err}
+		}
+	}
+
+	if err := p.Config.ArtifactStore.Upload(context.Background(), dir, p.runID); err != nil {
+		return &ArtifactUploadError{Prefix: p.runID, Err: err}
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package ansible
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type fakeArtifactStore struct {
+	prefix string
+	files  []string
+	err    error
+}
+
+func (s *fakeArtifactStore) Upload(ctx context.Context, dir, prefix string) error {
+	s.prefix = prefix
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		s.files = append(s.files, entry.Name())
+	}
+
+	return s.err
+}
+
+// TestArtifactStore tests the summary and the results are uploaded with the
+// artifacts below the run ID, and upload failures fail successful runs.
+func TestArtifactStore(t *testing.T) {
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "ansible-playbook"), []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	artifacts := t.TempDir()
+	if err := os.WriteFile(filepath.Join(artifacts, "report.txt"), []byte("report\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	store := &fakeArtifactStore{}
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir:    bin,
+			ArtifactDir:      artifacts,
+			ArtifactStore:    store,
+			Inventories:      []string{"tests/inventories/production"},
+			Playbooks:        []string{"tests/test.yml"},
+			RunID:            "run-1",
+			SkipVersionCheck: true,
+		},
+	}
+
+	if err := playbook.Exec(); err != nil {
+		t.Fatalf("Exec should execute without error, but received: %v", err)
+	}
+
+	if store.prefix != "run-1" {
+		t.Errorf("Expected the prefix run-1, got %q", store.prefix)
+	}
+
+	if expected := []string{"report.txt", "results.json", "summary.json"}; !reflect.DeepEqual(store.files, expected) {
+		t.Errorf("Expected the files %v, got %v", expected, store.files)
+	}
+
+	store.err = errors.New("access denied")
+
+	var uploadErr *ArtifactUploadError
+	if err := playbook.Exec(); !errors.As(err, &uploadErr) || errorClass(err) != "artifact_upload" {
+		t.Errorf("Expected an ArtifactUploadError, got %v", err)
+	}
+}
+
+// TestObjectStores tests the commands of the S3, GCS and Azure Blob stores.
+func TestObjectStores(t *testing.T) {
+	bin := t.TempDir()
+	log := filepath.Join(bin, "calls.log")
+
+	for _, name := range []string{"aws", "gcloud", "az"} {
+		script := "#!/bin/sh\necho " + name + " \"$@\" >> " + log + "\n"
+		if err := os.WriteFile(filepath.Join(bin, name), []byte(script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stores := []ArtifactStore{
+		&S3Store{AWS: filepath.Join(bin, "aws"), Bucket: "runs", Prefix: "ansible", Region: "eu-central-1"},
+		&GCSStore{Gcloud: filepath.Join(bin, "gcloud"), Bucket: "runs", Project: "infra"},
+		&AzureBlobStore{Az: filepath.Join(bin, "az"), Account: "acme", Container: "runs", Prefix: "ansible"},
+	}
+
+	for _, store := range stores {
+		if err := store.Upload(context.Background(), "/tmp/artifacts", "run-1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	content, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"aws s3 cp --recursive --only-show-errors /tmp/artifacts s3://runs/ansible/run-1/ --region eu-central-1",
+		"gcloud storage rsync --recursive /tmp/artifacts gs://runs/run-1 --project infra",
+		"az storage blob upload-batch --source /tmp/artifacts --destination runs --destination-path ansible/run-1 --overwrite --only-show-errors --account-name acme",
+	}
+
+	if calls := strings.Split(strings.TrimSpace(string(content)), "\n"); !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Expected the calls %q, got %q", expected, calls)
+	}
+
+	failing := filepath.Join(bin, "failing")
+	if err := os.WriteFile(failing, []byte("#!/bin/sh\necho 'NoSuchBucket' >&2\nexit 1\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	err = (&S3Store{AWS: failing, Bucket: "runs"}).Upload(context.Background(), "/tmp/artifacts", "run-1")
+	if err == nil || !strings.Contains(err.Error(), "NoSuchBucket") {
+		t.Errorf("Expected the error output of the CLI, got %v", err)
+	}
+}
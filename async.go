@@ -0,0 +1,33 @@
+package ansible
+
+import "encoding/json"
+
+// Extra vars passing the async budget to the playbooks, e.g. for
+//
+//	async: "{{ arillso_async_timeout }}"
+//	poll: "{{ arillso_async_poll }}"
+const (
+	AsyncTimeoutVar = "arillso_async_timeout"
+	AsyncPollVar    = "arillso_async_poll"
+)
+
+// asyncExtraVars returns the extra vars argument passing AsyncTimeout and
+// AsyncPoll in seconds, or an empty string if neither is set.
+func (p *AnsiblePlaybook) asyncExtraVars() string {
+	vars := map[string]int{}
+
+	if p.Config.AsyncTimeout > 0 {
+		vars[AsyncTimeoutVar] = seconds(p.Config.AsyncTimeout)
+	}
+
+	if p.Config.AsyncPoll > 0 {
+		vars[AsyncPollVar] = seconds(p.Config.AsyncPoll)
+	}
+
+	if len(vars) == 0 {
+		return ""
+	}
+
+	content, _ := json.Marshal(vars)
+	return string(content)
+}
@@ -0,0 +1,56 @@
+package ansible
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestAsyncExtraVars tests the async budget is passed to the playbooks.
+func TestAsyncExtraVars(t *testing.T) {
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AsyncPoll:        30 * time.Second,
+			AsyncTimeout:     2 * time.Hour,
+			Inventories:      []string{"tests/inventories/production"},
+			Playbooks:        []string{"tests/test.yml"},
+			SkipVersionCheck: true,
+		},
+	}
+
+	specs, err := playbook.BuildCommands()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	args := strings.Join(specs[0].Args, " ")
+	if !strings.Contains(args, `--extra-vars {"arillso_async_poll":30,"arillso_async_timeout":7200}`) {
+		t.Errorf("Expected the async extra vars, got '%s'", args)
+	}
+}
+
+// TestCheckAsyncTimeouts tests the async budget has to fit into the task and
+// run timeouts.
+func TestCheckAsyncTimeouts(t *testing.T) {
+	tests := []struct {
+		config Config
+		valid  bool
+	}{
+		{Config{AsyncTimeout: time.Hour, AsyncPoll: time.Minute, RunTimeout: 2 * time.Hour}, true},
+		{Config{AsyncPoll: time.Minute}, false},
+		{Config{AsyncTimeout: time.Minute, AsyncPoll: time.Minute}, false},
+		{Config{AsyncTimeout: time.Hour, TaskTimeout: time.Minute}, false},
+		{Config{AsyncTimeout: time.Hour, RunTimeout: time.Hour}, false},
+		{Config{PollInterval: -1}, false},
+	}
+
+	for _, test := range tests {
+		playbook := &AnsiblePlaybook{Config: test.config}
+
+		err := playbook.checkTimeouts()
+		if test.valid != (err == nil) || (err != nil && !errors.Is(err, ErrInvalidTimeouts)) {
+			t.Errorf("Expected %+v to be valid: %t, got %v", test.config, test.valid, err)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package ansible
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// BatchError is returned if a batch exceeded the abort thresholds.
+type BatchError struct {
+	Inventory string
+	Batch     int
+	Failed    []string
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf(
+		"batches of inventory %s aborted after batch %d, %d host(s) failed: %s",
+		e.Inventory,
+		e.Batch,
+		len(e.Failed),
+		strings.Join(e.Failed, ", "),
+	)
+}
+
+// Batches runs a playbook against the hosts of every inventory in batches,
+// one batch after the other, like the serial keyword of a play but without
+// editing the playbook. The batches of an inventory stop once a batch
+// exceeds the abort thresholds.
+type Batches struct {
+	Playbook *AnsiblePlaybook
+
+	// Sizes are the numbers of hosts of the batches, e.g. 1, 5 and 10. The
+	// last size is repeated until all hosts ran.
+	Sizes []int
+
+	// MaxFailedHosts is the number of failed hosts tolerated per batch, and
+	// MaxFailPercentage, if set, additionally limits their share.
+	MaxFailedHosts    int
+	MaxFailPercentage float64
+}
+
+func (b *Batches) Exec() error {
+	return b.ExecContext(context.Background())
+}
+
+// ExecContext runs the batches like Exec. If the context is canceled, the
+// running batch is killed and the context error is returned.
+func (b *Batches) ExecContext(ctx context.Context) error {
+	if len(b.Sizes) == 0 {
+		return errors.New("batches require at least one size")
+	}
+
+	for _, size := range b.Sizes {
+		if size <= 0 {
+			return fmt.Errorf("invalid batch size %d", size)
+		}
+	}
+
+	b.Playbook.ctx = ctx
+	defer func() { b.Playbook.ctx = nil }()
+
+	b.Playbook.startRun()
+
+	first := true
+	for _, inventory := range b.Playbook.Config.Inventories {
+		hosts, err := b.Playbook.listHosts(inventory, b.Playbook.Config.Limit)
+		if err != nil {
+			return err
+		}
+
+		for i, batch := range b.split(hosts) {
+			playbook := b.Playbook.subRun()
+			playbook.Config.Inventories = []string{inventory}
+			playbook.Config.Limit = strings.Join(batch, ",")
+
+			// Dependencies only need to be installed once.
+			if !first {
+				playbook.Config.GalaxyFile = ""
+			}
+
+			first = false
+
+			err := playbook.ExecContext(ctx)
+			b.Playbook.Results = append(b.Playbook.Results, playbook.Results...)
+
+			failed := failedHosts(playbook.Results)
+			if err != nil && len(failed) == 0 {
+				return err
+			}
+
+			if exceedsThresholds(failed, playbook.Results, b.MaxFailedHosts, b.MaxFailPercentage) {
+				return &BatchError{
+					Inventory: inventory,
+					Batch:     i + 1,
+					Failed:    failed,
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// split splits the hosts into batches of the configured sizes.
+func (b *Batches) split(hosts []string) [][]string {
+	var batches [][]string
+
+	for i := 0; len(hosts) > 0; i++ {
+		size := b.Sizes[len(b.Sizes)-1]
+		if i < len(b.Sizes) {
+			size = b.Sizes[i]
+		}
+
+		if size > len(hosts) {
+			size = len(hosts)
+		}
+
+		batches = append(batches, hosts[:size])
+		hosts = hosts[size:]
+	}
+
+	return batches
+}
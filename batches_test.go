@@ -0,0 +1,102 @@
+package ansible
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestBatches tests the hosts run in batches of the configured sizes and the
+// batches stop once a batch exceeds the abort thresholds.
+func TestBatches(t *testing.T) {
+	bin, log := fakeRolloutBin(t)
+
+	batches := &Batches{
+		Playbook: &AnsiblePlaybook{
+			Config: Config{
+				AnsibleBinDir: bin,
+				Inventories:   []string{"tests/inventories/production"},
+				Playbooks:     []string{"tests/test.yml"},
+			},
+		},
+		Sizes:          []int{1, 2},
+		MaxFailedHosts: 1,
+	}
+
+	if err := batches.Exec(); err != nil {
+		t.Fatalf("Expected one failed host per batch to be tolerated, got %v", err)
+	}
+
+	content, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if limits := strings.Fields(string(content)); !reflect.DeepEqual(limits, []string{"h1", "h2,h3", "h4"}) {
+		t.Errorf("Unexpected batches %q", limits)
+	}
+
+	if len(batches.Playbook.Results) != 3 {
+		t.Errorf("Expected the results of 3 batches, got %d", len(batches.Playbook.Results))
+	}
+
+	// Assert that all batches share the ID of the run.
+	for _, result := range batches.Playbook.Results {
+		if result.RunID == "" || result.RunID != batches.Playbook.RunID() {
+			t.Errorf("Expected the run ID %q, got %q", batches.Playbook.RunID(), result.RunID)
+		}
+	}
+
+	os.Remove(log)
+	batches.Playbook.Results = nil
+	batches.MaxFailedHosts = 0
+
+	var batchErr *BatchError
+	if err := batches.Exec(); !errors.As(err, &batchErr) || batchErr.Batch != 2 || !reflect.DeepEqual(batchErr.Failed, []string{"h3"}) {
+		t.Fatalf("Expected the batches to abort after batch 2, got %v", err)
+	}
+
+	content, err = os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if limits := strings.Fields(string(content)); !reflect.DeepEqual(limits, []string{"h1", "h2,h3"}) {
+		t.Errorf("Unexpected batches %q", limits)
+	}
+
+	batches.Sizes = []int{2, 0}
+	if err := batches.Exec(); err == nil {
+		t.Error("Expected an error for an invalid batch size")
+	}
+}
+
+// TestBatchesCanceled tests that a canceled context stops the batches.
+func TestBatchesCanceled(t *testing.T) {
+	bin, log := fakeRolloutBin(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	batches := &Batches{
+		Playbook: &AnsiblePlaybook{
+			Config: Config{
+				AnsibleBinDir: bin,
+				Inventories:   []string{"tests/inventories/production"},
+				Playbooks:     []string{"tests/test.yml"},
+			},
+		},
+		Sizes: []int{1},
+	}
+
+	if err := batches.ExecContext(ctx); err == nil {
+		t.Error("Expected the canceled batches to fail")
+	}
+
+	if _, err := os.Stat(log); !os.IsNotExist(err) {
+		t.Error("Expected no batch to run")
+	}
+}
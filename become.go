@@ -0,0 +1,187 @@
+package ansible
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// becomeMethods maps the short names of the known become plugins to the
+// collection providing them since ansible 2.10. Before, all of them were
+// shipped with ansible.
+var becomeMethods = map[string]string{
+	"sudo":       "",
+	"su":         "",
+	"runas":      "",
+	"doas":       "community.general",
+	"dzdo":       "community.general",
+	"ksu":        "community.general",
+	"machinectl": "community.general",
+	"pbrun":      "community.general",
+	"pfexec":     "community.general",
+	"pmrun":      "community.general",
+	"sesu":       "community.general",
+	"sudosu":     "community.general",
+	"enable":     "ansible.netcommon",
+}
+
+// collectionsVersion is the first ansible version shipping plugins in
+// collections.
+var collectionsVersion = version{parts: [3]int{2, 10, 0}}
+
+// BecomeMethodError is returned if the become method is unknown or its
+// collection is not installed.
+type BecomeMethodError struct {
+	Method     string
+	Suggestion string
+	Collection string
+}
+
+func (e *BecomeMethodError) Error() string {
+	switch {
+	case e.Collection != "":
+		return fmt.Sprintf("become method %s requires the collection %s", e.Method, e.Collection)
+	case e.Suggestion != "":
+		return fmt.Sprintf("unknown become method %q, did you mean %q?", e.Method, e.Suggestion)
+	default:
+		return fmt.Sprintf("unknown become method %q", e.Method)
+	}
+}
+
+// BecomePreset is a become method together with the user and variables it
+// requires.
+type BecomePreset struct {
+	Method     string
+	User       string
+	Connection string
+	ExtraVars  map[string]string
+}
+
+// SudoBecome becomes the user with sudo, root if empty.
+func SudoBecome(user string) BecomePreset {
+	return BecomePreset{Method: "sudo", User: user}
+}
+
+// DoasBecome becomes the user with doas, e.g. on FreeBSD and OpenBSD which
+// ship without sudo. Their python is not installed in /usr/bin.
+func DoasBecome(user string) BecomePreset {
+	return BecomePreset{
+		Method: "doas",
+		User:   user,
+		ExtraVars: map[string]string{
+			"ansible_python_interpreter": "/usr/local/bin/python3",
+		},
+	}
+}
+
+// EnableBecome enters the privileged mode of network devices, which requires
+// the network_cli connection.
+func EnableBecome() BecomePreset {
+	return BecomePreset{
+		Method:     "enable",
+		Connection: "ansible.netcommon.network_cli",
+	}
+}
+
+// RunasBecome becomes the user on Windows hosts.
+func RunasBecome(user string) BecomePreset {
+	return BecomePreset{Method: "runas", User: user}
+}
+
+// UseBecome enables become with the method and user of the preset. The
+// connection of the preset is only set if none is configured.
+func (c *Config) UseBecome(preset BecomePreset) {
+	c.Become = true
+	c.BecomeMethod = preset.Method
+	c.BecomeUser = preset.User
+
+	if c.Connection == "" {
+		c.Connection = preset.Connection
+	}
+
+	if len(preset.ExtraVars) > 0 {
+		vars, _ := json.Marshal(preset.ExtraVars)
+		c.ExtraVars = append(c.ExtraVars, string(vars))
+	}
+}
+
+// checkBecomeMethod fails early on unknown become methods. Since ansible
+// 2.10 the collection of a method has to be installed as well. Fully
+// qualified names are not checked.
+func (p *AnsiblePlaybook) checkBecomeMethod() error {
+	method := p.Config.BecomeMethod
+	if fqcnPattern.MatchString(method) {
+		return nil
+	}
+
+	collection, ok := becomeMethods[method]
+	if !ok {
+		return &BecomeMethodError{Method: method, Suggestion: closest(method, sortedKeys(keySet(becomeMethods)))}
+	}
+
+	if collection == "" || p.Config.SkipVersionCheck {
+		return nil
+	}
+
+	installed, err := parseVersion(p.installedVersion())
+	if err != nil || installed.compare(collectionsVersion) < 0 {
+		return nil
+	}
+
+	if !p.collectionInstalled(collection) {
+		return &BecomeMethodError{Method: method, Collection: collection}
+	}
+
+	return nil
+}
+
+// closest returns the candidate with the smallest edit distance of at most
+// two, e.g. sudo for sudp.
+func closest(name string, candidates []string) string {
+	match, best := "", 3
+	for _, candidate := range candidates {
+		if d := editDistance(strings.ToLower(name), candidate); d < best {
+			match, best = candidate, d
+		}
+	}
+
+	return match
+}
+
+// editDistance returns the Levenshtein distance of the strings.
+func editDistance(a, b string) int {
+	row := make([]int, len(b)+1)
+	for j := range row {
+		row[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		previous := row[0]
+		row[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			current := row[j]
+			row[j] = min3(row[j]+1, row[j-1]+1, previous+cost)
+			previous = current
+		}
+	}
+
+	return row[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+
+	if c < a {
+		a = c
+	}
+
+	return a
+}
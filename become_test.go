@@ -0,0 +1,98 @@
+package ansible
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestCheckBecomeMethod tests typos in the become method fail early with a
+// suggestion.
+func TestCheckBecomeMethod(t *testing.T) {
+	tests := []struct {
+		method     string
+		suggestion string
+		valid      bool
+	}{
+		{"sudo", "", true},
+		{"doas", "", true},
+		{"community.general.pbrun", "", true},
+		{"sudp", "sudo", false},
+		{"enabel", "enable", false},
+		{"elevate", "", false},
+	}
+
+	for _, test := range tests {
+		playbook := &AnsiblePlaybook{Config: Config{BecomeMethod: test.method, SkipVersionCheck: true}}
+
+		err := playbook.checkBecomeMethod()
+		if test.valid {
+			if err != nil {
+				t.Errorf("Expected %s to be valid, got %v", test.method, err)
+			}
+
+			continue
+		}
+
+		var become *BecomeMethodError
+		if !errors.As(err, &become) || become.Suggestion != test.suggestion {
+			t.Errorf("Expected %s to be invalid with suggestion %q, got %v", test.method, test.suggestion, err)
+		}
+	}
+}
+
+// TestCheckBecomeMethodCollection tests the collection of a become method
+// has to be installed since ansible 2.10.
+func TestCheckBecomeMethodCollection(t *testing.T) {
+	bin := t.TempDir()
+
+	scripts := map[string]string{
+		"ansible":        "#!/bin/sh\necho 'ansible [core 2.15.0]'\n",
+		"ansible-galaxy": "#!/bin/sh\nexit 0\n",
+	}
+
+	for name, script := range scripts {
+		if err := os.WriteFile(filepath.Join(bin, name), []byte(script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	playbook := &AnsiblePlaybook{Config: Config{AnsibleBinDir: bin, BecomeMethod: "doas"}}
+
+	var become *BecomeMethodError
+	if err := playbook.checkBecomeMethod(); !errors.As(err, &become) || become.Collection != "community.general" {
+		t.Errorf("Expected community.general to be required, got %v", err)
+	}
+
+	scripts["ansible"] = "#!/bin/sh\necho 'ansible 2.9.27'\n"
+	if err := os.WriteFile(filepath.Join(bin, "ansible"), []byte(scripts["ansible"]), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := playbook.checkBecomeMethod(); err != nil {
+		t.Errorf("Expected doas to be shipped with ansible 2.9, got %v", err)
+	}
+}
+
+// TestUseBecome tests become presets enable become with their method.
+func TestUseBecome(t *testing.T) {
+	config := Config{}
+	config.UseBecome(DoasBecome("admin"))
+
+	if !config.Become || config.BecomeMethod != "doas" || config.BecomeUser != "admin" {
+		t.Errorf("Unexpected become settings %t %s %s", config.Become, config.BecomeMethod, config.BecomeUser)
+	}
+
+	if len(config.ExtraVars) != 1 || !strings.Contains(config.ExtraVars[0], "/usr/local/bin/python3") {
+		t.Errorf("Expected the python interpreter extra var, got %v", config.ExtraVars)
+	}
+
+	config = Config{Connection: "ssh"}
+	config.UseBecome(EnableBecome())
+
+	if config.Connection != "ssh" {
+		t.Errorf("Expected the configured connection to be kept, got %s", config.Connection)
+	}
+}
@@ -0,0 +1,96 @@
+package ansible
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var ansibleVersionPattern = regexp.MustCompile(`^ansible (?:\[core )?([^\]\s]+)`)
+
+func (p *AnsiblePlaybook) binary(name string) string {
+	if p.Config.AnsibleBinDir == "" {
+		return name
+	}
+
+	return filepath.Join(p.Config.AnsibleBinDir, name)
+}
+
+// installedVersion returns the ansible-core version reported by the ansible
+// binary, or an empty string if it is not installed.
+func (p *AnsiblePlaybook) installedVersion() string {
+	output, err := exec.Command(p.binary("ansible"), "--version").Output()
+	if err != nil {
+		return ""
+	}
+
+	line := strings.SplitN(string(output), "\n", 2)[0]
+	match := ansibleVersionPattern.FindStringSubmatch(line)
+	if match == nil {
+		return ""
+	}
+
+	return match[1]
+}
+
+func (p *AnsiblePlaybook) bootstrapDir() (string, error) {
+	if p.Config.BootstrapDir != "" {
+		return p.Config.BootstrapDir, nil
+	}
+
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find cache directory: %w", err)
+	}
+
+	return filepath.Join(cache, "go.ansible", "ansible-core-"+p.Config.AnsibleCoreVersion), nil
+}
+
+// bootstrap makes sure the pinned ansible-core version is available. If the
+// configured ansible binary is missing or reports another version, a
+// virtualenv with the pinned version is created and used for the run.
+func (p *AnsiblePlaybook) bootstrap() error {
+	if p.installedVersion() == p.Config.AnsibleCoreVersion {
+		return nil
+	}
+
+	dir, err := p.bootstrapDir()
+	if err != nil {
+		return err
+	}
+
+	p.Config.AnsibleBinDir = filepath.Join(dir, "bin")
+	if p.installedVersion() == p.Config.AnsibleCoreVersion {
+		return nil
+	}
+
+	python := p.Config.BootstrapPython
+	if python == "" {
+		python = "python3"
+	}
+
+	commands := []*exec.Cmd{
+		exec.Command(python, "-m", "venv", dir),
+		exec.Command(
+			filepath.Join(dir, "bin", "pip"),
+			"install",
+			"--disable-pip-version-check",
+			"ansible-core=="+p.Config.AnsibleCoreVersion,
+		),
+	}
+
+	for _, cmd := range commands {
+		if err := p.run(cmd); err != nil {
+			return fmt.Errorf("failed to bootstrap ansible-core %s: %w", p.Config.AnsibleCoreVersion, err)
+		}
+	}
+
+	if version := p.installedVersion(); version != p.Config.AnsibleCoreVersion {
+		return fmt.Errorf("bootstrapped ansible-core reports version %q instead of %q", version, p.Config.AnsibleCoreVersion)
+	}
+
+	return nil
+}
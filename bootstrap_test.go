@@ -0,0 +1,51 @@
+package ansible
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestBootstrapInstalled tests that no bootstrap happens if the pinned version is installed.
+func TestBootstrapInstalled(t *testing.T) {
+	bin := t.TempDir()
+
+	// Prepare a fake ansible binary reporting the pinned version.
+	script := "#!/bin/sh\necho 'ansible [core 2.15.5]'\n"
+	if err := os.WriteFile(filepath.Join(bin, "ansible"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir:      bin,
+			AnsibleCoreVersion: "2.15.5",
+			BootstrapPython:    "false",
+		},
+	}
+
+	if err := playbook.bootstrap(); err != nil {
+		t.Errorf("bootstrap should not return an error, but received: %v", err)
+	}
+
+	// Assert that the configured binary directory is kept.
+	if playbook.Config.AnsibleBinDir != bin {
+		t.Errorf("Expected AnsibleBinDir to be '%s', got '%s'", bin, playbook.Config.AnsibleBinDir)
+	}
+}
+
+// TestBootstrapFailure tests that a failing bootstrap is reported.
+func TestBootstrapFailure(t *testing.T) {
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir:      t.TempDir(),
+			AnsibleCoreVersion: "2.15.5",
+			BootstrapDir:       t.TempDir(),
+			BootstrapPython:    "false",
+		},
+	}
+
+	if err := playbook.bootstrap(); err == nil {
+		t.Error("bootstrap should return an error if the virtualenv cannot be created")
+	}
+}
@@ -0,0 +1,93 @@
+package ansible
+
+import (
+	"os/exec"
+	"path/filepath"
+)
+
+// CommandSpec is a serializable description of a command run by Exec.
+type CommandSpec struct {
+	Name string   `json:"name"`
+	Args []string `json:"args"`
+
+	// Env is the sanitized environment snapshot, only set in debug mode.
+	Env []string `json:"env,omitempty"`
+
+	// EnvVars are the sanitized contributions of the configuration and the
+	// subsystems to the environment with their source, only set in debug
+	// mode.
+	EnvVars []string `json:"env_vars,omitempty"`
+}
+
+// BuildCommands returns the commands Exec runs for the configuration,
+// without running them. Files generated at run time, like the private key
+// or the vault password file, are not created, so their flags are missing.
+// The run ID is only passed if it is set in the configuration.
+func (p *AnsiblePlaybook) BuildCommands() ([]CommandSpec, error) {
+	build := &AnsiblePlaybook{Config: p.Config, runID: p.Config.RunID, options: p.options}
+
+	var specs []CommandSpec
+
+	if !build.Config.GalaxyOnly {
+		if err := build.playbooks(); err != nil {
+			return nil, err
+		}
+
+		if err := build.checkProtectedInventories(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := build.checkOptions(); err != nil {
+		return nil, err
+	}
+
+	if build.Config.RunMetadata {
+		build.metadata = build.runMetadataExtraVars()
+	}
+
+	if !build.Config.SkipVersionCheck {
+		specs = append(specs, commandSpec(build.versionCommand()))
+	}
+
+	if build.Config.GalaxyFile != "" {
+		specs = append(
+			specs,
+			commandSpec(build.galaxyRoleCommand()),
+			commandSpec(build.galaxyCollectionCommand()),
+		)
+	}
+
+	if !build.Config.GalaxyOnly {
+		for _, inventory := range build.runInventories() {
+			specs = append(specs, commandSpec(build.ansibleCommand(inventory)))
+		}
+	}
+
+	env := build.EnvBuilder()
+	if conflicts := env.Conflicts(); len(conflicts) > 0 {
+		return nil, &EnvConflictError{Conflicts: conflicts}
+	}
+
+	if build.Config.Debug {
+		snapshot, vars := build.envSnapshot(env.Environ()), env.Dump()
+		for i := range specs {
+			specs[i].Env = snapshot
+			specs[i].EnvVars = vars
+		}
+	}
+
+	return specs, nil
+}
+
+// String returns the command line quoted for a POSIX shell.
+func (s CommandSpec) String() string {
+	return shellJoin(append([]string{s.Name}, s.Args...))
+}
+
+func commandSpec(cmd *exec.Cmd) CommandSpec {
+	return CommandSpec{
+		Name: filepath.Base(cmd.Args[0]),
+		Args: append([]string{}, cmd.Args[1:]...),
+	}
+}
@@ -0,0 +1,75 @@
+package ansible
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestBuildCommands tests the commands of a run are built without running
+// them.
+func TestBuildCommands(t *testing.T) {
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir: "/opt/ansible/bin",
+			Forks:         5,
+			GalaxyFile:    "requirements.yml",
+			Inventories:   []string{"staging", "production"},
+			Limit:         "web",
+			Playbooks:     []string{"tests/test.yml"},
+		},
+	}
+
+	specs, err := playbook.BuildCommands()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, spec := range specs {
+		names = append(names, spec.Name)
+	}
+
+	expected := []string{"ansible", "ansible-galaxy", "ansible-galaxy", "ansible-playbook", "ansible-playbook"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("Expected commands %v, got %v", expected, names)
+	}
+
+	args := []string{"--inventory", "production", "--limit", "web", "tests/test.yml"}
+	if !reflect.DeepEqual(specs[4].Args, args) {
+		t.Errorf("Expected args %v, got %v", args, specs[4].Args)
+	}
+
+	if playbook.Config.Playbooks[0] != "tests/test.yml" {
+		t.Errorf("BuildCommands should not change the configuration")
+	}
+}
+
+// TestBuildCommandsPlaybookDir tests playbooks are resolved in the playbook
+// directory to absolute paths.
+func TestBuildCommandsPlaybookDir(t *testing.T) {
+	dir, err := filepath.Abs("tests")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			Forks:            5,
+			Inventories:      []string{"production"},
+			PlaybookDir:      "tests",
+			Playbooks:        []string{"test.yml", "arillso.system.site"},
+			SkipVersionCheck: true,
+		},
+	}
+
+	specs, err := playbook.BuildCommands()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	args := []string{"--inventory", "production", filepath.Join(dir, "test.yml"), "arillso.system.site"}
+	if !reflect.DeepEqual(specs[0].Args, args) {
+		t.Errorf("Expected args %v, got %v", args, specs[0].Args)
+	}
+}
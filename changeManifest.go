@@ -0,0 +1,197 @@
+package ansible
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ChangeManifest lists the tasks which reported changes in a run grouped by
+// host and role, e.g. to attach it to a change management ticket.
+type ChangeManifest struct {
+	RunID     string         `json:"run_id,omitempty"`
+	Generated time.Time      `json:"generated"`
+	Changes   int            `json:"changes"`
+	Hosts     []ManifestHost `json:"hosts"`
+}
+
+// ManifestHost are the changes of a host.
+type ManifestHost struct {
+	Host  string         `json:"host"`
+	Roles []ManifestRole `json:"roles"`
+}
+
+// ManifestRole are the changed tasks of a role on a host. Tasks of plays
+// outside of roles have no role.
+type ManifestRole struct {
+	Role  string         `json:"role,omitempty"`
+	Tasks []ManifestTask `json:"tasks"`
+}
+
+// ManifestTask is a changed task.
+type ManifestTask struct {
+	Inventory string `json:"inventory,omitempty"`
+	Play      string `json:"play"`
+	Task      string `json:"task"`
+}
+
+// jsonCallbackOutput is the output of the ansible.posix.json stdout
+// callback.
+type jsonCallbackOutput struct {
+	Plays []struct {
+		Play struct {
+			Name string `json:"name"`
+		} `json:"play"`
+		Tasks []struct {
+			Task struct {
+				Name string `json:"name"`
+			} `json:"task"`
+			Hosts map[string]struct {
+				Changed     bool `json:"changed"`
+				Failed      bool `json:"failed"`
+				Skipped     bool `json:"skipped"`
+				Unreachable bool `json:"unreachable"`
+			} `json:"hosts"`
+		} `json:"tasks"`
+	} `json:"plays"`
+	Stats map[string]struct {
+		Ok          int `json:"ok"`
+		Changed     int `json:"changed"`
+		Unreachable int `json:"unreachable"`
+		Failures    int `json:"failures"`
+		Skipped     int `json:"skipped"`
+		Rescued     int `json:"rescued"`
+		Ignored     int `json:"ignored"`
+	} `json:"stats"`
+}
+
+// parseJSONCallback builds a run result from the output of the JSON stdout
+// callback. Warnings and deprecations may precede the JSON document.
+func parseJSONCallback(output []byte) (*RunResult, bool) {
+	start := bytes.IndexByte(output, '{')
+	if start < 0 || (start > 0 && output[start-1] != '\n') {
+		return nil, false
+	}
+
+	var callback jsonCallbackOutput
+	if err := json.NewDecoder(bytes.NewReader(output[start:])).Decode(&callback); err != nil || callback.Plays == nil {
+		return nil, false
+	}
+
+	result := &RunResult{
+		Stats: map[string]HostStats{},
+	}
+
+	for _, play := range callback.Plays {
+		for _, task := range play.Tasks {
+			hosts := make([]string, 0, len(task.Hosts))
+			for host := range task.Hosts {
+				hosts = append(hosts, host)
+			}
+
+			sort.Strings(hosts)
+
+			for _, host := range hosts {
+				status := StatusOk
+				switch h := task.Hosts[host]; {
+				case h.Unreachable:
+					status = StatusUnreachable
+				case h.Failed:
+					status = StatusFailed
+				case h.Skipped:
+					status = StatusSkipped
+				case h.Changed:
+					status = StatusChanged
+				}
+
+				result.Tasks = append(result.Tasks, TaskResult{
+					Play:   play.Play.Name,
+					Task:   task.Task.Name,
+					Host:   host,
+					Status: status,
+				})
+			}
+		}
+	}
+
+	for host, stats := range callback.Stats {
+		result.Stats[host] = HostStats{
+			Ok:          stats.Ok,
+			Changed:     stats.Changed,
+			Unreachable: stats.Unreachable,
+			Failed:      stats.Failures,
+			Skipped:     stats.Skipped,
+			Rescued:     stats.Rescued,
+			Ignored:     stats.Ignored,
+		}
+	}
+
+	return result, true
+}
+
+// NewChangeManifest lists the changed tasks of the run results. The role of a
+// task is the prefix of its name, which ansible adds to the tasks of roles.
+func NewChangeManifest(results []*RunResult) *ChangeManifest {
+	manifest := &ChangeManifest{
+		Generated: time.Now().UTC().Truncate(time.Second),
+		Hosts:     []ManifestHost{},
+	}
+
+	hosts := map[string]int{}
+	for _, result := range results {
+		if manifest.RunID == "" {
+			manifest.RunID = result.RunID
+		}
+
+		for _, task := range result.Filter(StatusChanged) {
+			i, ok := hosts[task.Host]
+			if !ok {
+				i = len(manifest.Hosts)
+				hosts[task.Host] = i
+				manifest.Hosts = append(manifest.Hosts, ManifestHost{Host: task.Host})
+			}
+
+			role, name := "", task.Task
+			if parts := strings.SplitN(task.Task, " : ", 2); len(parts) == 2 {
+				role, name = parts[0], parts[1]
+			}
+
+			manifest.Hosts[i].add(role, ManifestTask{
+				Inventory: result.Inventory,
+				Play:      task.Play,
+				Task:      name,
+			})
+			manifest.Changes++
+		}
+	}
+
+	return manifest
+}
+
+func (h *ManifestHost) add(role string, task ManifestTask) {
+	for i := range h.Roles {
+		if h.Roles[i].Role == role {
+			h.Roles[i].Tasks = append(h.Roles[i].Tasks, task)
+			return
+		}
+	}
+
+	h.Roles = append(h.Roles, ManifestRole{Role: role, Tasks: []ManifestTask{task}})
+}
+
+// WriteChangeManifest writes the change manifest of the last run as JSON.
+func (p *AnsiblePlaybook) WriteChangeManifest(path string) error {
+	content, err := json.MarshalIndent(NewChangeManifest(p.Results), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode change manifest: %w", err)
+	}
+
+	if err := p.writeFile(path, append(content, '\n')); err != nil {
+		return fmt.Errorf("failed to write change manifest: %w", err)
+	}
+
+	return nil
+}
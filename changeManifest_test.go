@@ -0,0 +1,142 @@
+package ansible
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const jsonCallbackFixture = `[WARNING]: Invalid characters were found in group names
+{
+    "custom_stats": {},
+    "plays": [
+        {
+            "play": {"id": "1", "name": "web"},
+            "tasks": [
+                {
+                    "task": {"id": "2", "name": "nginx : Install nginx"},
+                    "hosts": {
+                        "web2": {"action": "apt", "changed": false},
+                        "web1": {"action": "apt", "changed": true}
+                    }
+                },
+                {
+                    "task": {"id": "3", "name": "Deploy site"},
+                    "hosts": {
+                        "web1": {"action": "copy", "changed": true},
+                        "web2": {"action": "copy", "failed": true, "msg": "No space left"}
+                    }
+                },
+                {
+                    "task": {"id": "4", "name": "nginx : Restart nginx"},
+                    "hosts": {
+                        "web1": {"action": "service", "changed": true},
+                        "web2": {"action": "service", "skipped": true}
+                    }
+                }
+            ]
+        }
+    ],
+    "stats": {
+        "web1": {"changed": 3, "failures": 0, "ignored": 0, "ok": 3, "rescued": 0, "skipped": 0, "unreachable": 0},
+        "web2": {"changed": 0, "failures": 1, "ignored": 0, "ok": 1, "rescued": 0, "skipped": 1, "unreachable": 0}
+    }
+}
+`
+
+// TestParseJSONCallback tests the output of the JSON stdout callback is
+// parsed into a run result.
+func TestParseJSONCallback(t *testing.T) {
+	result := ParseRunResult([]byte(jsonCallbackFixture))
+
+	expected := []TaskResult{
+		{Play: "web", Task: "nginx : Install nginx", Host: "web1", Status: StatusChanged},
+		{Play: "web", Task: "nginx : Install nginx", Host: "web2", Status: StatusOk},
+		{Play: "web", Task: "Deploy site", Host: "web1", Status: StatusChanged},
+		{Play: "web", Task: "Deploy site", Host: "web2", Status: StatusFailed},
+		{Play: "web", Task: "nginx : Restart nginx", Host: "web1", Status: StatusChanged},
+		{Play: "web", Task: "nginx : Restart nginx", Host: "web2", Status: StatusSkipped},
+	}
+
+	if !reflect.DeepEqual(result.Tasks, expected) {
+		t.Errorf("Expected the tasks %+v, got %+v", expected, result.Tasks)
+	}
+
+	if stats := result.Stats["web2"]; stats != (HostStats{Ok: 1, Failed: 1, Skipped: 1}) {
+		t.Errorf("Unexpected stats of web2 %+v", stats)
+	}
+
+	if len(result.Warnings) != 1 {
+		t.Errorf("Expected the warning, got %+v", result.Warnings)
+	}
+}
+
+// TestChangeManifest tests the manifest of a run lists the changed tasks
+// grouped by host and role.
+func TestChangeManifest(t *testing.T) {
+	bin := t.TempDir()
+	output := filepath.Join(bin, "output.json")
+	if err := os.WriteFile(output, []byte(jsonCallbackFixture), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	script := "#!/bin/sh\ncat " + output + "\n"
+	if err := os.WriteFile(filepath.Join(bin, "ansible-playbook"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "changes.json")
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir:    bin,
+			ChangeManifest:   path,
+			Environment:      map[string]string{"ANSIBLE_STDOUT_CALLBACK": "ansible.posix.json"},
+			Inventories:      []string{"tests/inventories/production"},
+			Playbooks:        []string{"tests/test.yml"},
+			RunID:            "run-1",
+			SkipVersionCheck: true,
+		},
+		Output: &bytes.Buffer{},
+	}
+
+	if err := playbook.Exec(); err != nil {
+		t.Fatalf("Exec should execute without error, but received: %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var manifest ChangeManifest
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		t.Fatal(err)
+	}
+
+	inventory := "tests/inventories/production"
+	expected := []ManifestHost{
+		{
+			Host: "web1",
+			Roles: []ManifestRole{
+				{Role: "nginx", Tasks: []ManifestTask{
+					{Inventory: inventory, Play: "web", Task: "Install nginx"},
+					{Inventory: inventory, Play: "web", Task: "Restart nginx"},
+				}},
+				{Tasks: []ManifestTask{
+					{Inventory: inventory, Play: "web", Task: "Deploy site"},
+				}},
+			},
+		},
+	}
+
+	if manifest.RunID != "run-1" || manifest.Changes != 3 || manifest.Generated.IsZero() {
+		t.Errorf("Unexpected manifest %+v", manifest)
+	}
+
+	if !reflect.DeepEqual(manifest.Hosts, expected) {
+		t.Errorf("Expected the hosts %+v, got %+v", expected, manifest.Hosts)
+	}
+}
@@ -0,0 +1,44 @@
+package ansible
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ChangedError is returned by runs with FailOnChange if at least one task
+// reported changes. Pipelines can check for it to trigger follow-up actions
+// only when something actually changed.
+type ChangedError struct {
+	Changes []TaskResult `json:"changes"`
+}
+
+func (e *ChangedError) Error() string {
+	hosts := map[string]bool{}
+	for _, change := range e.Changes {
+		hosts[change.Host] = true
+	}
+
+	return fmt.Sprintf(
+		"%d task(s) changed on %d host(s): %s",
+		len(e.Changes),
+		len(hosts),
+		strings.Join(sortedKeys(hosts), ", "),
+	)
+}
+
+// changedError returns a ChangedError if any of the run results reported
+// changes, by task or in the play recap.
+func changedError(results []*RunResult) error {
+	var changes []TaskResult
+	for _, result := range results {
+		changes = append(changes, result.changes()...)
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+
+	return &ChangedError{
+		Changes: changes,
+	}
+}
@@ -0,0 +1,46 @@
+package ansible
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestChangedError tests the changes are collected over all run results.
+func TestChangedError(t *testing.T) {
+	if err := changedError([]*RunResult{{Tasks: []TaskResult{{Host: "web1", Status: StatusOk}}}}); err != nil {
+		t.Fatalf("Expected no error without changes, got %v", err)
+	}
+
+	err := changedError([]*RunResult{
+		{Tasks: []TaskResult{{Task: "Install nginx", Host: "web2", Status: StatusChanged}}},
+		{Tasks: []TaskResult{{Task: "Install nginx", Host: "web1", Status: StatusChanged}, {Host: "web3", Status: StatusOk}}},
+	})
+
+	var changed *ChangedError
+	if !errors.As(err, &changed) {
+		t.Fatalf("Expected a ChangedError, got %v", err)
+	}
+
+	if len(changed.Changes) != 2 {
+		t.Errorf("Expected 2 changes, got %+v", changed.Changes)
+	}
+
+	if expected := "2 task(s) changed on 2 host(s): web1, web2"; err.Error() != expected {
+		t.Errorf("Expected error '%s', got '%s'", expected, err.Error())
+	}
+}
+
+// TestChangedErrorRecap tests the changes counted by the play recap are
+// used if no changed tasks were parsed.
+func TestChangedErrorRecap(t *testing.T) {
+	err := changedError([]*RunResult{{Stats: map[string]HostStats{"web1": {Ok: 3, Changed: 2}, "web2": {Ok: 3}}}})
+
+	var changed *ChangedError
+	if !errors.As(err, &changed) {
+		t.Fatalf("Expected a ChangedError, got %v", err)
+	}
+
+	if expected := "2 task(s) changed on 1 host(s): web1"; err.Error() != expected {
+		t.Errorf("Expected error '%s', got '%s'", expected, err.Error())
+	}
+}
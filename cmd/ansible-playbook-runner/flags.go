@@ -0,0 +1,138 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+
+	ansible "github.com/arillso/go.ansible"
+)
+
+// envPrefix is the prefix of the environment variables setting the flags.
+const envPrefix = "ANSIBLE_RUNNER_"
+
+var (
+	durationType  = reflect.TypeOf(time.Duration(0))
+	stringPtrType = reflect.TypeOf((*string)(nil))
+)
+
+// registerConfigFlags registers a flag for every string, bool, integer,
+// duration and string slice field of the config. Fields of other types can
+// only be set using the library.
+func registerConfigFlags(fs *flag.FlagSet, config *ansible.Config) {
+	value := reflect.ValueOf(config).Elem()
+
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		target := value.Field(i)
+		name := flagName(field.Name)
+
+		switch {
+		case field.Type == durationType:
+			fs.DurationVar(target.Addr().Interface().(*time.Duration), name, 0, "Config."+field.Name)
+		case field.Type.Kind() == reflect.String:
+			// Named string types like ColorMode are set as plain strings.
+			fs.StringVar(target.Addr().Convert(stringPtrType).Interface().(*string), name, "", "Config."+field.Name)
+		case field.Type.Kind() == reflect.Bool:
+			fs.BoolVar(target.Addr().Interface().(*bool), name, false, "Config."+field.Name)
+		case field.Type.Kind() == reflect.Int:
+			fs.IntVar(target.Addr().Interface().(*int), name, int(target.Int()), "Config."+field.Name)
+		case field.Type.Kind() == reflect.Int64:
+			fs.Int64Var(target.Addr().Interface().(*int64), name, target.Int(), "Config."+field.Name)
+		case field.Type.Kind() == reflect.Uint64:
+			fs.Uint64Var(target.Addr().Interface().(*uint64), name, target.Uint(), "Config."+field.Name)
+		case field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.String:
+			fs.Var((*stringSlice)(target.Addr().Interface().(*[]string)), name, "Config."+field.Name+" (repeatable)")
+		}
+	}
+}
+
+// loadEnv sets all flags which were not set on the command line from their
+// environment variables, e.g. --galaxy-file from ANSIBLE_RUNNER_GALAXY_FILE.
+// String slices are split on commas.
+func loadEnv(fs *flag.FlagSet) error {
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if set[f.Name] || err != nil {
+			return
+		}
+
+		value, ok := os.LookupEnv(envName(f.Name))
+		if !ok {
+			return
+		}
+
+		if slice, ok := f.Value.(*stringSlice); ok {
+			*slice = nil
+			for _, item := range strings.Split(value, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					*slice = append(*slice, item)
+				}
+			}
+
+			return
+		}
+
+		if e := f.Value.Set(value); e != nil {
+			err = fmt.Errorf("invalid value %q for %s: %v", value, envName(f.Name), e)
+		}
+	})
+
+	return err
+}
+
+// flagName converts a field name to a flag name, e.g. HTTPSProxy to
+// https-proxy.
+func flagName(field string) string {
+	runes := []rune(field)
+
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prevLower := unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1]) && !pluralAcronym(runes, i+1)
+
+			if prevLower || (unicode.IsUpper(runes[i-1]) && nextLower) {
+				b.WriteByte('-')
+			}
+		}
+
+		b.WriteRune(unicode.ToLower(r))
+	}
+
+	return b.String()
+}
+
+// pluralAcronym reports whether the rune at i is the plural s of an acronym,
+// e.g. in VaultIDs.
+func pluralAcronym(runes []rune, i int) bool {
+	return runes[i] == 's' && (i+1 == len(runes) || unicode.IsUpper(runes[i+1]))
+}
+
+func envName(flag string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))
+}
+
+type stringSlice []string
+
+func (s *stringSlice) String() string {
+	if s == nil {
+		return ""
+	}
+
+	return strings.Join(*s, ",")
+}
+
+func (s *stringSlice) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
@@ -0,0 +1,142 @@
+// Command ansible-playbook-runner runs ansible playbooks with the
+// configuration of the ansible package, so it can be used in pipelines
+// without writing Go code.
+//
+// Usage:
+//
+//	ansible-playbook-runner <plan|exec|lint> [flags]
+//
+// Every flag can also be set with an environment variable prefixed with
+// ANSIBLE_RUNNER_, e.g. --galaxy-file with ANSIBLE_RUNNER_GALAXY_FILE.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	ansible "github.com/arillso/go.ansible"
+)
+
+// Exit codes of the runner.
+const (
+	exitOk      = 0
+	exitFailed  = 1
+	exitUsage   = 2
+	exitChanged = 3
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr, nil)
+		return exitUsage
+	}
+
+	command := args[0]
+
+	fs := flag.NewFlagSet(command, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	playbook := &ansible.AnsiblePlaybook{
+		Config: ansible.Config{
+			Forks: 5,
+		},
+	}
+	registerConfigFlags(fs, &playbook.Config)
+	fs.Usage = func() { usage(stderr, fs) }
+
+	switch command {
+	case "plan", "exec", "lint":
+	case "-h", "-help", "--help", "help":
+		usage(stdout, fs)
+		return exitOk
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n", command)
+		usage(stderr, nil)
+		return exitUsage
+	}
+
+	if err := fs.Parse(args[1:]); err != nil {
+		return exitUsage
+	}
+
+	if err := loadEnv(fs); err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+
+	// Remaining arguments are playbooks.
+	playbook.Config.Playbooks = append(playbook.Config.Playbooks, fs.Args()...)
+
+	var err error
+	switch command {
+	case "plan":
+		err = plan(playbook, stdout)
+	case "lint":
+		playbook.Config.SyntaxCheck = true
+		err = playbook.Exec()
+	case "exec":
+		err = playbook.Exec()
+	}
+
+	var changed *ansible.ChangedError
+	switch {
+	case err == nil:
+		return exitOk
+	case errors.As(err, &changed):
+		fmt.Fprintln(stderr, err)
+		return exitChanged
+	default:
+		fmt.Fprintln(stderr, err)
+		return exitFailed
+	}
+}
+
+// plan prints the commands exec would run.
+func plan(playbook *ansible.AnsiblePlaybook, stdout io.Writer) error {
+	specs, err := playbook.BuildCommands()
+	if err != nil {
+		return err
+	}
+
+	// In debug mode all commands share the environment snapshot.
+	if len(specs) > 0 && len(specs[0].Env) > 0 {
+		fmt.Fprintln(stdout, "# environment")
+		for _, entry := range specs[0].Env {
+			fmt.Fprintln(stdout, "#  ", entry)
+		}
+	}
+
+	for _, spec := range specs {
+		fmt.Fprintln(stdout, spec)
+	}
+
+	return nil
+}
+
+func usage(w io.Writer, fs *flag.FlagSet) {
+	fmt.Fprintln(w, "Usage: ansible-playbook-runner <plan|exec|lint> [flags] [playbooks...]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	fmt.Fprintln(w, "  plan  print the commands which would be run")
+	fmt.Fprintln(w, "  exec  run the playbooks")
+	fmt.Fprintln(w, "  lint  check the syntax of the playbooks")
+
+	if fs == nil {
+		return
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Flags, also settable as %s<FLAG> environment variables:\n", envPrefix)
+
+	output := fs.Output()
+	fs.SetOutput(w)
+	fs.PrintDefaults()
+	fs.SetOutput(output)
+}
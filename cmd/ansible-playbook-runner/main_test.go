@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"strings"
+	"testing"
+
+	ansible "github.com/arillso/go.ansible"
+)
+
+// TestFlagName tests field names are converted to flag names.
+func TestFlagName(t *testing.T) {
+	tests := map[string]string{
+		"AnsibleBinDir": "ansible-bin-dir",
+		"HTTPSProxy":    "https-proxy",
+		"CACertFile":    "ca-cert-file",
+		"SSHCommonArgs": "ssh-common-args",
+		"GalaxyAPIKey":  "galaxy-api-key",
+		"VaultIDs":      "vault-ids",
+	}
+
+	for field, expected := range tests {
+		if name := flagName(field); name != expected {
+			t.Errorf("Expected flag %s for %s, got %s", expected, field, name)
+		}
+	}
+}
+
+// TestLoadEnv tests flags not set on the command line are read from the
+// environment.
+func TestLoadEnv(t *testing.T) {
+	t.Setenv("ANSIBLE_RUNNER_LIMIT", "db")
+	t.Setenv("ANSIBLE_RUNNER_TAGS", "deploy")
+	t.Setenv("ANSIBLE_RUNNER_INVENTORIES", "staging, production")
+	t.Setenv("ANSIBLE_RUNNER_CHECK", "true")
+
+	var config ansible.Config
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	registerConfigFlags(fs, &config)
+
+	if err := fs.Parse([]string{"--limit", "web"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := loadEnv(fs); err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Limit != "web" || config.Tags != "deploy" || !config.Check {
+		t.Errorf("Expected flags to override the environment, got %+v", config)
+	}
+
+	if strings.Join(config.Inventories, ",") != "staging,production" {
+		t.Errorf("Expected inventories from the environment, got %v", config.Inventories)
+	}
+}
+
+// TestPlan tests the plan command prints the commands.
+func TestPlan(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	code := run([]string{
+		"plan",
+		"--skip-version-check",
+		"--inventories", "production",
+		"--extra-vars", "message=hello world",
+		"../../tests/test.yml",
+	}, &stdout, &stderr)
+	if code != exitOk {
+		t.Fatalf("Expected exit code 0, got %d: %s", code, stderr.String())
+	}
+
+	expected := "ansible-playbook --inventory production --extra-vars 'message=hello world' ../../tests/test.yml\n"
+	if stdout.String() != expected {
+		t.Errorf("Expected plan '%s', got '%s'", expected, stdout.String())
+	}
+}
+
+// TestUnknownCommand tests unknown commands are rejected.
+func TestUnknownCommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	if code := run([]string{"deploy"}, &stdout, &stderr); code != exitUsage {
+		t.Errorf("Expected exit code %d, got %d", exitUsage, code)
+	}
+}
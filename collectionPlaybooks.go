@@ -0,0 +1,199 @@
+package ansible
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// fqcnPattern matches the fully qualified names of the playbooks, roles and
+// modules of collections, i.e. namespace.collection.name.
+var fqcnPattern = regexp.MustCompile(`^[a-z_][a-z0-9_]*\.[a-z_][a-z0-9_]*\.[a-z_][a-z0-9_.]*$`)
+
+// MissingPlaybookError is returned if the collection of a collection
+// playbook is not installed or has no such playbook.
+type MissingPlaybookError struct {
+	Playbook   string
+	Collection string
+	Path       string
+}
+
+func (e *MissingPlaybookError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("collection %s of playbook %s is not installed", e.Collection, e.Playbook)
+	}
+
+	return fmt.Sprintf("collection %s installed at %s but has no playbook %s", e.Collection, e.Path, e.Playbook)
+}
+
+// playbookCollection returns the namespace.collection of a collection
+// playbook reference.
+func playbookCollection(playbook string) (string, bool) {
+	if !fqcnPattern.MatchString(playbook) {
+		return "", false
+	}
+
+	parts := strings.SplitN(playbook, ".", 3)
+	return parts[0] + "." + parts[1], true
+}
+
+// installPlaybookCollections installs the collections of all collection
+// playbooks which are not installed yet.
+func (p *AnsiblePlaybook) installPlaybookCollections() error {
+	seen := map[string]bool{}
+
+	for _, playbook := range p.Config.Playbooks {
+		collection, ok := playbookCollection(playbook)
+		if !ok || seen[collection] {
+			continue
+		}
+
+		seen[collection] = true
+
+		if p.collectionInstalled(collection) {
+			continue
+		}
+
+		if err := p.runConcurrent(p.runGalaxy(func() *exec.Cmd {
+			return p.collectionInstallCommand(collection)
+		})); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkCollectionPlaybooks fails with a MissingPlaybookError for the first
+// collection playbook whose collection is not installed or has no such
+// playbook.
+func (p *AnsiblePlaybook) checkCollectionPlaybooks() error {
+	for _, playbook := range p.Config.Playbooks {
+		collection, ok := playbookCollection(playbook)
+		if !ok {
+			continue
+		}
+
+		dirs := p.collectionDirs(collection)
+		if len(dirs) == 0 {
+			return &MissingPlaybookError{Playbook: playbook, Collection: collection}
+		}
+
+		if !collectionHasPlaybook(dirs[0], playbook) {
+			return &MissingPlaybookError{Playbook: playbook, Collection: collection, Path: dirs[0]}
+		}
+	}
+
+	return nil
+}
+
+// collectionHasPlaybook reports whether the collection directory contains
+// the playbook. Further dots in the playbook name are subdirectories of the
+// playbooks directory.
+func collectionHasPlaybook(dir, playbook string) bool {
+	name := strings.SplitN(playbook, ".", 3)[2]
+	path := filepath.Join(dir, "playbooks", filepath.Join(strings.Split(name, ".")...))
+
+	for _, ext := range []string{".yml", ".yaml"} {
+		if info, err := os.Stat(path + ext); err == nil && !info.IsDir() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// collectionInstalled reports whether the collection is installed in one of
+// the collection paths.
+func (p *AnsiblePlaybook) collectionInstalled(collection string) bool {
+	return len(p.collectionDirs(collection)) > 0
+}
+
+// collectionDirs returns the directories the collection is installed in, in
+// the order of precedence of the collection paths.
+func (p *AnsiblePlaybook) collectionDirs(collection string) []string {
+	args := []string{"collection", "list", collection, "--format", "json"}
+	if p.Config.GalaxyCollectionsPath != "" {
+		args = append(args, "--collections-path", p.Config.GalaxyCollectionsPath)
+	}
+
+	var output bytes.Buffer
+	if err := p.runOutput(exec.Command(p.binary("ansible-galaxy"), args...), &output); err != nil {
+		return nil
+	}
+
+	// The JSON object maps every collection path to its collections.
+	start := bytes.IndexByte(output.Bytes(), '{')
+	if start < 0 {
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(output.Bytes()[start:]))
+
+	// The paths are read in order, a map would lose their precedence.
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil
+	}
+
+	parts := strings.SplitN(collection, ".", 2)
+
+	var dirs []string
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil
+		}
+
+		var collections map[string]interface{}
+		if err := decoder.Decode(&collections); err != nil {
+			return nil
+		}
+
+		if _, ok := collections[collection]; ok {
+			dirs = append(dirs, filepath.Join(token.(string), parts[0], parts[1]))
+		}
+	}
+
+	return dirs
+}
+
+// collectionInstallCommand installs the collection with its configured
+// version constraint.
+func (p *AnsiblePlaybook) collectionInstallCommand(collection string) *exec.Cmd {
+	name := collection
+	if version := p.Config.PlaybookCollectionVersions[collection]; version != "" {
+		name += ":" + version
+	}
+
+	args := []string{
+		"collection",
+		"install",
+		name,
+	}
+
+	if p.Config.GalaxyAPIServerURL != "" {
+		args = append(args, "--server", p.Config.GalaxyAPIServerURL)
+	}
+
+	if p.Config.GalaxyAPIKey != "" {
+		args = append(args, "--api-key", p.Config.GalaxyAPIKey)
+	}
+
+	if p.Config.GalaxyIgnoreCerts {
+		args = append(args, "--ignore-certs")
+	}
+
+	if p.Config.GalaxyCollectionsPath != "" {
+		args = append(args, "--collections-path", p.Config.GalaxyCollectionsPath)
+	}
+
+	return exec.Command(
+		p.binary("ansible-galaxy"),
+		args...,
+	)
+}
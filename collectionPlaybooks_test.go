@@ -0,0 +1,139 @@
+package ansible
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestInstallPlaybookCollections tests only the missing collections of
+// collection playbooks are installed.
+func TestInstallPlaybookCollections(t *testing.T) {
+	bin := t.TempDir()
+	log := filepath.Join(bin, "calls.log")
+	collections := filepath.Join(t.TempDir(), "ansible_collections")
+
+	for _, path := range []string{"arillso/system/playbooks/site.yml", "arillso/container/playbooks/docker.yml"} {
+		if err := os.MkdirAll(filepath.Join(collections, filepath.Dir(path)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(filepath.Join(collections, path), []byte("---\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// arillso.container is listed once it was installed.
+	galaxy := `#!/bin/sh
+echo "$@" >> ` + log + `
+[ "$2" = "install" ] && touch ` + filepath.Join(bin, "installed") + `
+if [ "$2" = "list" ] && { [ "$3" = "arillso.system" ] || [ -f ` + filepath.Join(bin, "installed") + ` ]; }; then
+  echo "{\"` + collections + `\": {\"$3\": {\"version\": \"1.0.0\"}}}"
+fi
+`
+	for name, script := range map[string]string{
+		"ansible-galaxy":   galaxy,
+		"ansible-playbook": "#!/bin/sh\necho \"$@\" >> " + log + "\n",
+	} {
+		if err := os.WriteFile(filepath.Join(bin, name), []byte(script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir:              bin,
+			Inventories:                []string{"tests/inventories/production"},
+			InstallPlaybookCollections: true,
+			PlaybookCollectionVersions: map[string]string{"arillso.container": ">=1.2.0"},
+			Playbooks:                  []string{"arillso.system.site", "arillso.container.docker", "tests/test.yml"},
+			SkipVersionCheck:           true,
+		},
+		Output: &bytes.Buffer{},
+	}
+
+	if err := playbook.Exec(); err != nil {
+		t.Fatalf("Exec should execute without error, but received: %v", err)
+	}
+
+	content, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls := strings.Split(strings.TrimSpace(string(content)), "\n")
+	expected := []string{
+		"collection list arillso.system --format json",
+		"collection list arillso.container --format json",
+		"collection install arillso.container:>=1.2.0",
+		"collection list arillso.system --format json",
+		"collection list arillso.container --format json",
+	}
+
+	if len(calls) != 6 || strings.Join(calls[:5], "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("Expected calls %q before the playbook run, got %q", expected, calls)
+	}
+
+	if !strings.HasSuffix(calls[5], " arillso.system.site arillso.container.docker tests/test.yml") {
+		t.Errorf("Expected the collection playbooks to be passed by name, got '%s'", calls[5])
+	}
+}
+
+// TestCheckCollectionPlaybooks tests collection playbooks are verified
+// before the playbooks run.
+func TestCheckCollectionPlaybooks(t *testing.T) {
+	bin := t.TempDir()
+	collections := filepath.Join(t.TempDir(), "ansible_collections")
+
+	if err := os.MkdirAll(filepath.Join(collections, "arillso/system/playbooks/base"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(collections, "arillso/system/playbooks/base/site.yaml"), []byte("---\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	galaxy := `#!/bin/sh
+if [ "$3" = "arillso.system" ]; then
+  echo "{\"` + collections + `\": {\"arillso.system\": {\"version\": \"1.0.0\"}}}"
+fi
+`
+	if err := os.WriteFile(filepath.Join(bin, "ansible-galaxy"), []byte(galaxy), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		playbook string
+		path     string
+	}{
+		{"arillso.system.base.site", ""},
+		{"arillso.system.docker", filepath.Join(collections, "arillso", "system")},
+		{"arillso.container.docker", ""},
+	}
+
+	for _, test := range tests {
+		playbook := &AnsiblePlaybook{
+			Config: Config{
+				AnsibleBinDir: bin,
+				Playbooks:     []string{"tests/test.yml", test.playbook},
+			},
+		}
+
+		err := playbook.checkCollectionPlaybooks()
+		if test.playbook == "arillso.system.base.site" {
+			if err != nil {
+				t.Errorf("Expected %s to exist, got %v", test.playbook, err)
+			}
+
+			continue
+		}
+
+		var missing *MissingPlaybookError
+		if !errors.As(err, &missing) || missing.Playbook != test.playbook || missing.Path != test.path {
+			t.Errorf("Expected a missing playbook %s at %q, got %v", test.playbook, test.path, err)
+		}
+	}
+}
@@ -0,0 +1,295 @@
+package ansible
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// builtinCollections are shipped with ansible-core and never installed.
+var builtinCollections = map[string]bool{
+	"ansible.builtin": true,
+	"ansible.legacy":  true,
+}
+
+// blockKeys contain nested task lists.
+var blockKeys = []string{"block", "rescue", "always"}
+
+// MissingCollectionsError is returned if collections used by the playbooks
+// are not installed.
+type MissingCollectionsError struct {
+	Collections []string
+}
+
+func (e *MissingCollectionsError) Error() string {
+	return fmt.Sprintf("missing collections: %s", strings.Join(e.Collections, ", "))
+}
+
+// RequiredCollections returns the collections of the modules, roles and
+// playbooks referenced by their fully qualified name in the playbooks, their
+// static imports and local roles, and of the collections keyword of plays.
+func RequiredCollections(playbooks []string) ([]string, error) {
+	scan := &collectionScan{
+		collections: map[string]bool{},
+		seen:        map[string]bool{},
+	}
+
+	for _, playbook := range playbooks {
+		if collection, ok := playbookCollection(playbook); ok {
+			scan.add(collection)
+			continue
+		}
+
+		if err := scan.playbook(playbook); err != nil {
+			return nil, err
+		}
+	}
+
+	return sortedKeys(scan.collections), nil
+}
+
+// MissingCollections returns the collections required by the playbooks
+// which are not installed.
+func (p *AnsiblePlaybook) MissingCollections() ([]string, error) {
+	required, err := RequiredCollections(p.globPlaybooks(p.Config.Playbooks))
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []string
+	for _, collection := range required {
+		if !p.collectionInstalled(collection) {
+			missing = append(missing, collection)
+		}
+	}
+
+	return missing, nil
+}
+
+// checkCollections installs the missing collections with
+// InstallPlaybookCollections or fails with a MissingCollectionsError.
+func (p *AnsiblePlaybook) checkCollections() error {
+	missing, err := p.MissingCollections()
+	if err != nil || len(missing) == 0 {
+		return err
+	}
+
+	if !p.Config.InstallPlaybookCollections {
+		return &MissingCollectionsError{Collections: missing}
+	}
+
+	for _, collection := range missing {
+		collection := collection
+		if err := p.runConcurrent(p.runGalaxy(func() *exec.Cmd {
+			return p.collectionInstallCommand(collection)
+		})); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type collectionScan struct {
+	collections map[string]bool
+	seen        map[string]bool
+}
+
+func (s *collectionScan) add(collection string) {
+	if !builtinCollections[collection] {
+		s.collections[collection] = true
+	}
+}
+
+// fqcn adds the collection of a fully qualified name.
+func (s *collectionScan) fqcn(name string) {
+	if !fqcnPattern.MatchString(name) {
+		return
+	}
+
+	parts := strings.SplitN(name, ".", 3)
+	s.add(parts[0] + "." + parts[1])
+}
+
+// load parses a YAML file once. Files which were scanned before return nil.
+func (s *collectionScan) load(path string) ([]interface{}, error) {
+	path = filepath.Clean(path)
+	if s.seen[path] {
+		return nil, nil
+	}
+
+	s.seen[path] = true
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var items []interface{}
+	if err := yaml.Unmarshal(content, &items); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return items, nil
+}
+
+func (s *collectionScan) playbook(path string) error {
+	plays, err := s.load(path)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+
+	for _, item := range plays {
+		play, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		if imported, ok := play["import_playbook"].(string); ok {
+			if fqcnPattern.MatchString(imported) {
+				s.fqcn(imported)
+			} else if static(imported) {
+				if err := s.playbook(filepath.Join(dir, imported)); err != nil {
+					return err
+				}
+			}
+
+			continue
+		}
+
+		for _, collection := range stringList(play["collections"]) {
+			s.add(collection)
+		}
+
+		for _, role := range toList(play["roles"]) {
+			name, _ := role.(string)
+			if m, ok := role.(map[string]interface{}); ok {
+				name, _ = m["role"].(string)
+				if name == "" {
+					name, _ = m["name"].(string)
+				}
+			}
+
+			if err := s.role(dir, name); err != nil {
+				return err
+			}
+		}
+
+		for _, key := range []string{"pre_tasks", "tasks", "post_tasks", "handlers"} {
+			if err := s.tasks(dir, toList(play[key])); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *collectionScan) tasks(dir string, tasks []interface{}) error {
+	for _, item := range tasks {
+		task, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		for _, key := range blockKeys {
+			if err := s.tasks(dir, toList(task[key])); err != nil {
+				return err
+			}
+		}
+
+		for key, value := range task {
+			s.fqcn(key)
+
+			switch strings.TrimPrefix(key, "ansible.builtin.") {
+			case "import_role", "include_role":
+				if args, ok := value.(map[string]interface{}); ok {
+					name, _ := args["name"].(string)
+					if err := s.role(dir, name); err != nil {
+						return err
+					}
+				}
+			case "import_tasks", "include_tasks":
+				file, _ := value.(string)
+				if args, ok := value.(map[string]interface{}); ok {
+					file, _ = args["file"].(string)
+				}
+
+				if err := s.taskFile(filepath.Join(dir, file), file); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// taskFile scans a task file if its name is static and it exists.
+func (s *collectionScan) taskFile(path, name string) error {
+	if name == "" || !static(name) {
+		return nil
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		return nil
+	}
+
+	tasks, err := s.load(path)
+	if err != nil {
+		return err
+	}
+
+	return s.tasks(filepath.Dir(path), tasks)
+}
+
+// role adds the collection of a role or scans the tasks and handlers of a
+// local role next to the playbook.
+func (s *collectionScan) role(dir, name string) error {
+	if name == "" || !static(name) {
+		return nil
+	}
+
+	if fqcnPattern.MatchString(name) {
+		s.fqcn(name)
+		return nil
+	}
+
+	for _, sub := range []string{"tasks", "handlers"} {
+		for _, file := range []string{"main.yml", "main.yaml"} {
+			path := filepath.Join(dir, "roles", name, sub, file)
+			if err := s.taskFile(path, file); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// static reports whether the value contains no templates.
+func static(value string) bool {
+	return !strings.Contains(value, "{{")
+}
+
+func toList(value interface{}) []interface{} {
+	list, _ := value.([]interface{})
+	return list
+}
+
+func stringList(value interface{}) []string {
+	var list []string
+	for _, item := range toList(value) {
+		if s, ok := item.(string); ok {
+			list = append(list, s)
+		}
+	}
+
+	return list
+}
@@ -0,0 +1,119 @@
+package ansible
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestRequiredCollections tests the collections are collected from the
+// playbooks, their imports and local roles.
+func TestRequiredCollections(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"site.yml": `---
+- import_playbook: k8s.yml
+- hosts: all
+  roles:
+    - web
+    - role: arillso.system.base
+  tasks:
+    - ansible.builtin.ping:
+    - block:
+        - community.general.ufw:
+            rule: allow
+      rescue:
+        - ansible.posix.sysctl:
+            name: vm.swappiness
+    - include_tasks: "{{ tasks_file }}"
+`,
+		"k8s.yml": `---
+- hosts: k8s
+  collections:
+    - kubernetes.core
+    - ansible.builtin
+  tasks:
+    - k8s_info:
+        kind: Pod
+`,
+		"roles/web/tasks/main.yml": `---
+- import_tasks: containers.yml
+`,
+		"roles/web/tasks/containers.yml": `---
+- community.docker.docker_container:
+    name: web
+`,
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	collections, err := RequiredCollections([]string{filepath.Join(dir, "site.yml"), "arillso.container.docker"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"ansible.posix",
+		"arillso.container",
+		"arillso.system",
+		"community.docker",
+		"community.general",
+		"kubernetes.core",
+	}
+
+	if !reflect.DeepEqual(collections, expected) {
+		t.Errorf("Expected collections %v, got %v", expected, collections)
+	}
+}
+
+// TestCheckCollections tests the run fails before the playbooks are run if
+// required collections are missing.
+func TestCheckCollections(t *testing.T) {
+	bin := t.TempDir()
+	log := filepath.Join(bin, "calls.log")
+
+	for _, name := range []string{"ansible-galaxy", "ansible-playbook"} {
+		script := "#!/bin/sh\necho " + name + " \"$@\" >> " + log + "\n"
+		if err := os.WriteFile(filepath.Join(bin, name), []byte(script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir:    bin,
+			CheckCollections: true,
+			Inventories:      []string{"tests/inventories/production"},
+			Playbooks:        []string{"tests/test.yml", "arillso.system.site"},
+			SkipVersionCheck: true,
+		},
+		Output: &bytes.Buffer{},
+	}
+
+	var missing *MissingCollectionsError
+	if err := playbook.Exec(); !errors.As(err, &missing) || !reflect.DeepEqual(missing.Collections, []string{"arillso.system"}) {
+		t.Fatalf("Expected arillso.system to be missing, got %v", err)
+	}
+
+	content, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bytes.Contains(content, []byte("ansible-playbook")) {
+		t.Errorf("Expected no playbook run, got '%s'", content)
+	}
+}
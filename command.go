@@ -0,0 +1,358 @@
+package ansible
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+func (p *AnsiblePlaybook) environ() []string {
+	return p.EnvBuilder().Environ()
+}
+
+// EnvBuilder returns the builder of the environment of the ansible commands
+// of the run with the contributions of the configuration and the subsystems.
+func (p *AnsiblePlaybook) EnvBuilder() *EnvBuilder {
+	b := NewEnvBuilder(os.Environ())
+	b.Redact = p.redact
+
+	if p.Config.AnsibleBinDir != "" {
+		b.Set(EnvSourceConfig, "PATH", p.Config.AnsibleBinDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	}
+
+	if p.Config.AnsibleConfigFile != "" {
+		b.Set(EnvSourceConfig, "ANSIBLE_CONFIG", p.Config.AnsibleConfigFile)
+	}
+
+	b.Add(EnvSourcePlugins, p.pluginPaths()...)
+	b.Add(EnvSourceOutput, p.verbosityEnv()...)
+	b.Add(EnvSourceConfig, p.pollIntervalEnv()...)
+	b.Add(EnvSourceConfig, p.taskTimeoutEnv()...)
+	b.Add(EnvSourceFacts, p.factGatheringEnv()...)
+	b.Add(EnvSourceConfig, p.strategyEnv()...)
+
+	for _, v := range p.env {
+		b.Set(v.Source, v.Name, v.Value)
+	}
+
+	for _, name := range sortedKeys(keySet(p.Config.Environment)) {
+		b.Set(EnvSourceEnvironment, name, p.Config.Environment[name])
+	}
+
+	if p.Config.GalaxyIsolate {
+		b.Set(EnvSourceGalaxy, "ANSIBLE_COLLECTIONS_PATH", p.Config.GalaxyCollectionsPath)
+		b.Set(EnvSourceGalaxy, "ANSIBLE_ROLES_PATH", p.Config.GalaxyRolesPath)
+	}
+
+	for _, proxy := range []struct{ name, value string }{
+		{"HTTP_PROXY", p.Config.HTTPProxy},
+		{"HTTPS_PROXY", p.Config.HTTPSProxy},
+		{"NO_PROXY", p.Config.NoProxy},
+	} {
+		if proxy.value != "" {
+			b.Set(EnvSourceProxy, proxy.name, proxy.value)
+			b.Set(EnvSourceProxy, strings.ToLower(proxy.name), proxy.value)
+		}
+	}
+
+	if p.Config.CACertFile != "" {
+		b.Set(EnvSourceProxy, "SSL_CERT_FILE", p.Config.CACertFile)
+		b.Set(EnvSourceProxy, "REQUESTS_CA_BUNDLE", p.Config.CACertFile)
+	}
+
+	if p.Config.NoLogSensitive {
+		b.Add(EnvSourceOutput, "ANSIBLE_NO_LOG=True", "ANSIBLE_NO_TARGET_SYSLOG=True")
+	}
+
+	if p.Config.DisableDeprecationWarnings {
+		b.Set(EnvSourceOutput, "ANSIBLE_DEPRECATION_WARNINGS", "False")
+	}
+
+	if p.Config.DisableCommandWarnings {
+		b.Set(EnvSourceOutput, "ANSIBLE_COMMAND_WARNINGS", "False")
+	}
+
+	if p.Config.HideSkippedHosts {
+		b.Set(EnvSourceOutput, "ANSIBLE_DISPLAY_SKIPPED_HOSTS", "False")
+	}
+
+	if p.Config.HideOkHosts {
+		b.Set(EnvSourceOutput, "ANSIBLE_DISPLAY_OK_HOSTS", "False")
+	}
+
+	if callbacks := p.callbacks(); len(callbacks) > 0 {
+		if enabled := os.Getenv("ANSIBLE_CALLBACKS_ENABLED"); enabled != "" {
+			callbacks = append([]string{enabled}, callbacks...)
+		}
+
+		b.Set(EnvSourceOutput, "ANSIBLE_CALLBACKS_ENABLED", strings.Join(callbacks, ","))
+	}
+
+	// The defaults go last, every other contribution overrides them.
+	p.colorEnv(b)
+	p.galaxyProgressEnv(b)
+
+	return b
+}
+
+// galaxyProgressEnv disables the progress spinner of ansible-galaxy by
+// default, as it garbles captured output.
+func (p *AnsiblePlaybook) galaxyProgressEnv(b *EnvBuilder) {
+	if p.Config.GalaxyProgress {
+		b.Set(EnvSourceGalaxy, "ANSIBLE_GALAXY_DISPLAY_PROGRESS", "1")
+		return
+	}
+
+	b.Default(EnvSourceGalaxy, "ANSIBLE_GALAXY_DISPLAY_PROGRESS", "0")
+}
+
+// pluginPaths returns the environment variables for the configured plugin,
+// module and module utils directories, so they apply to every ansible command
+// of the run and not only to ansible-playbook.
+func (p *AnsiblePlaybook) pluginPaths() []string {
+	var env []string
+
+	callbackPaths := p.Config.CallbackPluginPath
+	if p.eventPlugins != "" {
+		callbackPaths = append([]string{p.eventPlugins}, callbackPaths...)
+	}
+
+	for name, paths := range map[string][]string{
+		"ANSIBLE_ACTION_PLUGINS":   p.Config.ActionPluginPath,
+		"ANSIBLE_CALLBACK_PLUGINS": callbackPaths,
+		"ANSIBLE_FILTER_PLUGINS":   p.Config.FilterPluginPath,
+		"ANSIBLE_LIBRARY":          p.Config.ModulePath,
+		"ANSIBLE_MODULE_UTILS":     p.Config.ModuleUtilsPath,
+		"ANSIBLE_STRATEGY_PLUGINS": p.Config.StrategyPluginPath,
+		"ANSIBLE_VARS_PLUGINS":     p.Config.VarsPluginPath,
+	} {
+		if len(paths) > 0 {
+			env = append(env, name+"="+strings.Join(paths, string(os.PathListSeparator)))
+		}
+	}
+
+	sort.Strings(env)
+	return env
+}
+
+// callbacks returns the callback plugins enabled by the configuration.
+func (p *AnsiblePlaybook) callbacks() []string {
+	var callbacks []string
+
+	if p.Config.ProfileTasks {
+		callbacks = append(callbacks, "ansible.posix.profile_tasks")
+	}
+
+	if p.eventPlugins != "" {
+		callbacks = append(callbacks, eventCallback)
+	}
+
+	return callbacks
+}
+
+func (p *AnsiblePlaybook) run(cmd *exec.Cmd) error {
+	stdout := p.stdout()
+	defer stdout.Flush()
+
+	cmd.Stdout = stdout
+	cmd.Stderr = stdout
+	cmd.Env = p.environ()
+
+	p.trace(cmd)
+
+	return p.runCommand(cmd)
+}
+
+func (p *AnsiblePlaybook) runOutput(cmd *exec.Cmd, output io.Writer) error {
+	cmd.Stdout = output
+	cmd.Stderr = output
+	cmd.Env = p.environ()
+
+	return p.runCommand(cmd)
+}
+
+// runCommand runs the command and kills it once the context of the run is
+// canceled.
+func (p *AnsiblePlaybook) runCommand(cmd *exec.Cmd) error {
+	ctx := p.context()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	hold, err := p.wrapCommand(cmd)
+	if err != nil {
+		return err
+	}
+
+	if hold != nil {
+		defer hold.close()
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	// The command waits for the limits before it is executed, so they apply
+	// to every process it forks.
+	if hold != nil {
+		cleanup, err := p.applyResourceLimits(cmd.Process.Pid)
+		defer cleanup()
+
+		if err == nil {
+			err = hold.release()
+		}
+
+		if err != nil {
+			cmd.Process.Kill()
+			cmd.Wait()
+
+			return err
+		}
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			cmd.Process.Kill()
+		case <-done:
+		}
+	}()
+
+	err = cmd.Wait()
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+
+	return commandError(cmd, err)
+}
+
+func (p *AnsiblePlaybook) context() context.Context {
+	if p.ctx == nil {
+		return context.Background()
+	}
+
+	return p.ctx
+}
+
+// sleep waits for the duration and fails with the context error if the
+// run is canceled before.
+func (p *AnsiblePlaybook) sleep(d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-p.context().Done():
+		return p.context().Err()
+	}
+}
+
+// withConfig runs f with the configuration changed by modify and restores
+// the configuration afterwards. Sub-runs share the state of the run, such as
+// the context, output, run ID and secrets.
+func (p *AnsiblePlaybook) withConfig(modify func(config *Config), f func() error) error {
+	config := p.Config
+	defer func() { p.Config = config }()
+
+	modify(&p.Config)
+	return f()
+}
+
+// output returns the writer receiving the output of the commands.
+func (p *AnsiblePlaybook) output() io.Writer {
+	if p.Output == nil {
+		return os.Stdout
+	}
+
+	return p.Output
+}
+
+// runPlaybook runs the playbooks against the inventory while streaming the
+// output and parses the output into a run result.
+func (p *AnsiblePlaybook) runPlaybook(inventory string) (*RunResult, error) {
+	var output bytes.Buffer
+
+	cmd := p.ansibleCommand(inventory)
+	p.trace(cmd)
+
+	stdout := p.stdout()
+	err := p.runOutput(cmd, io.MultiWriter(stdout, &output))
+	stdout.Flush()
+
+	result := ParseRunResult(p.redact(output.Bytes()))
+	result.Inventory = inventory
+	result.RunID = p.runID
+
+	return result, err
+}
+
+// runAuxiliary runs a command which is not a playbook run. In quiet mode
+// the output is only printed if the command fails.
+func (p *AnsiblePlaybook) runAuxiliary(cmd *exec.Cmd) error {
+	if !p.Config.Quiet {
+		return p.run(cmd)
+	}
+
+	var output bytes.Buffer
+	fmt.Fprintln(&output, "$", p.commandLine(cmd))
+
+	err := p.runOutput(cmd, &output)
+	if err != nil {
+		p.output().Write(p.format(output.Bytes()))
+	}
+
+	return err
+}
+
+// runConcurrent runs independent tasks in parallel. The output of every
+// task is buffered and written in one piece once the task finished, so the
+// output of concurrent tasks does not interleave. In quiet mode only the
+// output of failed tasks is written.
+func (p *AnsiblePlaybook) runConcurrent(tasks ...func(output io.Writer) error) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs = make([]error, len(tasks))
+	)
+
+	for i, task := range tasks {
+		wg.Add(1)
+
+		go func(i int, task func(output io.Writer) error) {
+			defer wg.Done()
+
+			var output bytes.Buffer
+			errs[i] = task(&output)
+
+			if errs[i] == nil && p.Config.Quiet {
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			p.output().Write(p.format(output.Bytes()))
+		}(i, task)
+	}
+
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
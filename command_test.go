@@ -0,0 +1,194 @@
+package ansible
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// TestRunConcurrent tests that runConcurrent waits for all tasks and reports failures.
+func TestRunConcurrent(t *testing.T) {
+	playbook := &AnsiblePlaybook{}
+
+	succeed := func(output io.Writer) error { return nil }
+	fail := func(output io.Writer) error { return errors.New("failed") }
+
+	// Execute two successful tasks concurrently.
+	if err := playbook.runConcurrent(succeed, succeed); err != nil {
+		t.Errorf("runConcurrent should execute without error, but received: %v", err)
+	}
+
+	// Assert that a failing task is reported.
+	if err := playbook.runConcurrent(succeed, fail); err == nil {
+		t.Error("runConcurrent should return an error if a task fails")
+	}
+}
+
+// TestEnvironProxy tests that proxy and CA settings are passed to the commands.
+func TestEnvironProxy(t *testing.T) {
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			HTTPSProxy: "http://proxy.example.com:3128",
+			NoProxy:    "localhost,.example.com",
+			CACertFile: "/etc/ssl/corporate.pem",
+		},
+	}
+
+	env := strings.Join(playbook.environ(), "\n")
+
+	// Assert that the expected variables are present.
+	for _, expected := range []string{
+		"HTTPS_PROXY=http://proxy.example.com:3128",
+		"no_proxy=localhost,.example.com",
+		"SSL_CERT_FILE=/etc/ssl/corporate.pem",
+		"REQUESTS_CA_BUNDLE=/etc/ssl/corporate.pem",
+	} {
+		if !strings.Contains(env, expected) {
+			t.Errorf("Expected environment to contain '%s'", expected)
+		}
+	}
+}
+
+// captureStdout returns everything written to os.Stdout while fn runs.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = writer
+	defer func() { os.Stdout = stdout }()
+
+	done := make(chan string)
+	go func() {
+		content, _ := io.ReadAll(reader)
+		done <- string(content)
+	}()
+
+	fn()
+
+	writer.Close()
+	return <-done
+}
+
+// TestRunAuxiliaryQuiet tests that quiet mode only prints the output of failed commands.
+func TestRunAuxiliaryQuiet(t *testing.T) {
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			Quiet: true,
+		},
+		runID: "run-1",
+	}
+
+	output := captureStdout(t, func() {
+		if err := playbook.runAuxiliary(exec.Command("echo", "success")); err != nil {
+			t.Errorf("runAuxiliary should execute without error, but received: %v", err)
+		}
+	})
+
+	if output != "" {
+		t.Errorf("Expected no output, got '%s'", output)
+	}
+
+	output = captureStdout(t, func() {
+		if err := playbook.runAuxiliary(exec.Command("sh", "-c", "echo failure; exit 1")); err == nil {
+			t.Error("runAuxiliary should return an error")
+		}
+	})
+
+	if !strings.Contains(output, "failure") {
+		t.Errorf("Expected the output of the failed command, got '%s'", output)
+	}
+
+	if !strings.Contains(output, "  # run run-1\n") {
+		t.Errorf("Expected the command line with the run ID, got '%s'", output)
+	}
+}
+
+// TestEnvironDisplayToggles tests the warning and display toggles.
+func TestEnvironDisplayToggles(t *testing.T) {
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			DisableDeprecationWarnings: true,
+			HideSkippedHosts:           true,
+		},
+	}
+
+	env := strings.Join(playbook.environ(), "\n")
+
+	for _, expected := range []string{"ANSIBLE_DEPRECATION_WARNINGS=False", "ANSIBLE_DISPLAY_SKIPPED_HOSTS=False"} {
+		if !strings.Contains(env, expected) {
+			t.Errorf("Expected environment to contain '%s'", expected)
+		}
+	}
+
+	for _, unexpected := range []string{"ANSIBLE_COMMAND_WARNINGS=", "ANSIBLE_DISPLAY_OK_HOSTS="} {
+		if strings.Contains(env, unexpected) {
+			t.Errorf("Expected environment not to contain '%s'", unexpected)
+		}
+	}
+}
+
+// TestEnvironPluginPaths tests the plugin, module and module utils
+// directories are passed in the environment.
+func TestEnvironPluginPaths(t *testing.T) {
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			CallbackPluginPath: []string{"plugins/callback"},
+			FilterPluginPath:   []string{"plugins/filter", "vendor/filter"},
+			ModulePath:         []string{"library"},
+			ModuleUtilsPath:    []string{"module_utils"},
+		},
+	}
+
+	env := strings.Join(playbook.environ(), "\n")
+
+	for _, expected := range []string{"ANSIBLE_CALLBACK_PLUGINS=plugins/callback", "ANSIBLE_FILTER_PLUGINS=plugins/filter:vendor/filter", "ANSIBLE_LIBRARY=library", "ANSIBLE_MODULE_UTILS=module_utils"} {
+		if !strings.Contains(env, expected) {
+			t.Errorf("Expected environment to contain '%s'", expected)
+		}
+	}
+
+	for _, unexpected := range []string{"ANSIBLE_ACTION_PLUGINS=", "ANSIBLE_VARS_PLUGINS="} {
+		if strings.Contains(env, unexpected) {
+			t.Errorf("Expected environment not to contain '%s'", unexpected)
+		}
+	}
+}
+
+// TestEnvDefaults tests the defaults of the environment can be overridden by
+// the environment of the process and Config.Environment.
+func TestEnvDefaults(t *testing.T) {
+	playbook := &AnsiblePlaybook{}
+
+	env := "\n" + strings.Join(playbook.environ(), "\n") + "\n"
+	for _, expected := range []string{"ANSIBLE_FORCE_COLOR=1", "ANSIBLE_GALAXY_DISPLAY_PROGRESS=0"} {
+		if !strings.Contains(env, "\n"+expected+"\n") {
+			t.Errorf("Expected the default %s", expected)
+		}
+	}
+
+	t.Setenv("ANSIBLE_FORCE_COLOR", "0")
+
+	playbook.Config.Environment = map[string]string{"ANSIBLE_GALAXY_DISPLAY_PROGRESS": "1"}
+
+	env = "\n" + strings.Join(playbook.environ(), "\n") + "\n"
+	for _, unexpected := range []string{"ANSIBLE_FORCE_COLOR=1", "ANSIBLE_GALAXY_DISPLAY_PROGRESS=0"} {
+		if strings.Contains(env, "\n"+unexpected+"\n") {
+			t.Errorf("Expected the default %s to be overridden", unexpected)
+		}
+	}
+
+	playbook.Config.Environment = nil
+	playbook.Config.GalaxyProgress = true
+
+	if env := strings.Join(playbook.environ(), "\n"); !strings.Contains(env, "ANSIBLE_GALAXY_DISPLAY_PROGRESS=1") {
+		t.Error("Expected the galaxy progress to be enabled")
+	}
+}
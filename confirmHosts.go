@@ -0,0 +1,95 @@
+package ansible
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ConfirmFunc is called before the run against an inventory with the hosts
+// the playbooks target after applying the limit. The run stops unless it
+// returns nil, e.g. if a mistyped limit matches far more hosts than expected.
+type ConfirmFunc func(ctx context.Context, inventory string, hosts []string) error
+
+// ConfirmationError is returned if the hosts of an inventory were declined.
+type ConfirmationError struct {
+	Inventory string
+	Hosts     []string
+	Err       error
+}
+
+func (e *ConfirmationError) Error() string {
+	return fmt.Sprintf("run against %d hosts of inventory %s declined: %v", len(e.Hosts), e.Inventory, e.Err)
+}
+
+func (e *ConfirmationError) Unwrap() error {
+	return e.Err
+}
+
+// PreviewHosts returns the deduplicated hosts the playbooks target in the
+// inventory, i.e. the host patterns of all plays restricted by the limit.
+func (p *AnsiblePlaybook) PreviewHosts(inventory string) ([]string, error) {
+	args := append(p.inventoryArgs(inventory), "--list-hosts")
+	if p.Config.Limit != "" {
+		args = append(args, "--limit", p.Config.Limit)
+	}
+
+	cmd := exec.Command(
+		p.binary("ansible-playbook"),
+		append(args, playbookArgs(p.Config.Playbooks)...)...,
+	)
+	cmd.Env = p.environ()
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list hosts of inventory %s: %s: %w", inventory, strings.TrimSpace(stderr.String()), err)
+	}
+
+	return parsePlayHosts(output), nil
+}
+
+// parsePlayHosts returns the hosts listed below the host count of every play.
+func parsePlayHosts(output []byte) []string {
+	seen := map[string]bool{}
+	inHosts := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(ansiEscapePattern.ReplaceAll(output, nil)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case hostCountPattern.MatchString(line):
+			inHosts = true
+		case line == "" || strings.HasPrefix(line, "play #") || strings.HasPrefix(line, "pattern:"):
+			inHosts = false
+		case inHosts:
+			seen[line] = true
+		}
+	}
+
+	return sortedKeys(seen)
+}
+
+// confirm passes the hosts of the inventory to the confirmation hook.
+func (p *AnsiblePlaybook) confirm(inventory string) error {
+	hosts, err := p.PreviewHosts(inventory)
+	if err != nil {
+		return err
+	}
+
+	if err := p.Config.Confirm(p.context(), inventory, hosts); err != nil {
+		return &ConfirmationError{
+			Inventory: inventory,
+			Hosts:     hosts,
+			Err:       err,
+		}
+	}
+
+	return nil
+}
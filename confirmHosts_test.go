@@ -0,0 +1,68 @@
+package ansible
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestConfirmHosts tests the hosts of every play are previewed with the limit
+// and a declined preview stops the run.
+func TestConfirmHosts(t *testing.T) {
+	bin := t.TempDir()
+	log := filepath.Join(bin, "calls.log")
+
+	script := `#!/bin/sh
+echo "$@" >> ` + log + `
+case "$*" in *--list-hosts*)
+printf '\nplaybook: test.yml\n\n  play #1 (web): web\tTAGS: []\n    pattern: ['"'"'web'"'"']\n    hosts (2):\n      web2\n      web1\n\n'
+printf '  play #2 (all): all\tTAGS: []\n    pattern: ['"'"'all'"'"']\n    hosts (3):\n      web1\n      db1\n      web2\n'
+esac
+`
+	if err := os.WriteFile(filepath.Join(bin, "ansible-playbook"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	declined := errors.New("too many hosts")
+
+	var previews [][]string
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir: bin,
+			Confirm: func(ctx context.Context, inventory string, hosts []string) error {
+				previews = append(previews, hosts)
+				return declined
+			},
+			Inventories:      []string{"tests/inventories/production"},
+			Limit:            "web*",
+			Playbooks:        []string{"tests/test.yml"},
+			SkipVersionCheck: true,
+		},
+	}
+
+	err := playbook.Exec()
+
+	var confirmation *ConfirmationError
+	if !errors.As(err, &confirmation) || !errors.Is(err, declined) {
+		t.Fatalf("Expected a ConfirmationError, got %v", err)
+	}
+
+	expected := [][]string{{"db1", "web1", "web2"}}
+	if !reflect.DeepEqual(previews, expected) {
+		t.Errorf("Expected previews %v, got %v", expected, previews)
+	}
+
+	content, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(calls) != 1 || !strings.Contains(calls[0], "--list-hosts --limit web*") {
+		t.Errorf("Expected a single list hosts call with the limit, got %q", calls)
+	}
+}
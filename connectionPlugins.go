@@ -0,0 +1,88 @@
+package ansible
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ConnectionPluginError is returned if the connection plugin is not
+// installed.
+type ConnectionPluginError struct {
+	Connection string
+	Suggestion string
+}
+
+func (e *ConnectionPluginError) Error() string {
+	if e.Suggestion != "" {
+		return fmt.Sprintf("unknown connection plugin %q, did you mean %q?", e.Connection, e.Suggestion)
+	}
+
+	return fmt.Sprintf("unknown connection plugin %q", e.Connection)
+}
+
+// ConnectionPlugins returns the names of the installed connection plugins
+// as listed by ansible-doc.
+func (p *AnsiblePlaybook) ConnectionPlugins() ([]string, error) {
+	return p.plugins("connection")
+}
+
+// plugins returns the names of the installed plugins of the type.
+func (p *AnsiblePlaybook) plugins(kind string) ([]string, error) {
+	cmd := exec.Command(p.binary("ansible-doc"), "--type", kind, "--list")
+
+	var output bytes.Buffer
+	if err := p.runOutput(cmd, &output); err != nil {
+		return nil, fmt.Errorf("failed to list %s plugins: %w", kind, err)
+	}
+
+	var plugins []string
+
+	scanner := bufio.NewScanner(&output)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "[") {
+			continue
+		}
+
+		plugins = append(plugins, fields[0])
+	}
+
+	return plugins, nil
+}
+
+// checkConnection fails early if the connection plugin is not installed,
+// instead of on the first task of the run. Short names match the builtin
+// plugins and the plugins they are redirected to, e.g. docker to
+// community.docker.docker.
+func (p *AnsiblePlaybook) checkConnection() error {
+	plugins, err := p.ConnectionPlugins()
+	if err != nil {
+		return err
+	}
+
+	connection := p.Config.Connection
+	if names, ok := matchPlugin(connection, plugins); !ok {
+		return &ConnectionPluginError{Connection: connection, Suggestion: closest(connection, names)}
+	}
+
+	return nil
+}
+
+// matchPlugin reports whether the name is one of the plugins or the short
+// name of one. Otherwise it returns the short names of the plugins.
+func matchPlugin(name string, plugins []string) ([]string, bool) {
+	var names []string
+	for _, plugin := range plugins {
+		short := plugin[strings.LastIndexByte(plugin, '.')+1:]
+		if plugin == name || short == name {
+			return nil, true
+		}
+
+		names = append(names, short)
+	}
+
+	return names, false
+}
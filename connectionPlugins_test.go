@@ -0,0 +1,56 @@
+package ansible
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestCheckConnection tests the connection is validated against the
+// installed connection plugins.
+func TestCheckConnection(t *testing.T) {
+	bin := t.TempDir()
+
+	script := `#!/bin/sh
+echo '[WARNING]: Collection foo.bar does not support Ansible version 2.15.0'
+echo 'ansible.builtin.local        execute on controller'
+echo 'ansible.builtin.ssh          connect via SSH client binary'
+echo 'ansible.builtin.winrm        Run tasks over Microsoft WinRM'
+echo 'community.docker.docker      Run tasks in docker containers'
+`
+	if err := os.WriteFile(filepath.Join(bin, "ansible-doc"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		connection string
+		suggestion string
+		valid      bool
+	}{
+		{"ssh", "", true},
+		{"ansible.builtin.winrm", "", true},
+		{"docker", "", true},
+		{"community.docker.docker", "", true},
+		{"winrn", "winrm", false},
+		{"containers.podman.podman", "", false},
+	}
+
+	for _, test := range tests {
+		playbook := &AnsiblePlaybook{Config: Config{AnsibleBinDir: bin, Connection: test.connection}}
+
+		err := playbook.checkConnection()
+		if test.valid {
+			if err != nil {
+				t.Errorf("Expected %s to be valid, got %v", test.connection, err)
+			}
+
+			continue
+		}
+
+		var connection *ConnectionPluginError
+		if !errors.As(err, &connection) || connection.Suggestion != test.suggestion {
+			t.Errorf("Expected %s to be invalid with suggestion %q, got %v", test.connection, test.suggestion, err)
+		}
+	}
+}
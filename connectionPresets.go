@@ -0,0 +1,154 @@
+package ansible
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// remoteTmp is the temporary directory on targets whose home directory may
+// not exist, e.g. minimal image roots. Ansible creates it on demand.
+const remoteTmp = "/tmp/.ansible/tmp"
+
+// ConnectionPreset is a connection plugin together with the variables,
+// environment and privileges it requires.
+type ConnectionPreset struct {
+	Connection  string
+	ExtraVars   map[string]string
+	Environment map[string]string
+	Become      bool
+	ChrootDir   string
+}
+
+// LocalConnection runs the tasks on the controller with the python
+// interpreter of ansible-playbook instead of the system python.
+func LocalConnection() ConnectionPreset {
+	return ConnectionPreset{
+		Connection: "local",
+		ExtraVars: map[string]string{
+			"ansible_python_interpreter": "{{ ansible_playbook_python }}",
+		},
+	}
+}
+
+// DockerConnection runs the tasks in docker containers with the docker CLI.
+// The inventory hostnames are the container names. An empty host uses the
+// default docker daemon.
+func DockerConnection(host string) ConnectionPreset {
+	preset := ConnectionPreset{Connection: "docker"}
+	if host != "" {
+		preset.Environment = map[string]string{"DOCKER_HOST": host}
+	}
+
+	return preset
+}
+
+// CommunityDockerConnection runs the tasks in docker containers with the
+// docker API of the community.docker collection, which does not require the
+// docker CLI on the controller. An empty host uses the default docker daemon.
+func CommunityDockerConnection(host string) ConnectionPreset {
+	preset := ConnectionPreset{Connection: "community.docker.docker_api"}
+	if host != "" {
+		preset.ExtraVars = map[string]string{"ansible_docker_host": host}
+		preset.Environment = map[string]string{"DOCKER_HOST": host}
+	}
+
+	return preset
+}
+
+// PodmanConnection runs the tasks in podman containers. An empty executable
+// uses podman from the PATH.
+func PodmanConnection(executable string) ConnectionPreset {
+	preset := ConnectionPreset{Connection: "containers.podman.podman"}
+	if executable != "" {
+		preset.ExtraVars = map[string]string{"ansible_podman_executable": executable}
+	}
+
+	return preset
+}
+
+// KubectlConnection runs the tasks in kubernetes pods with kubectl. Empty
+// values use the defaults of the kubeconfig.
+func KubectlConnection(kubeconfig, kubeContext, namespace string) ConnectionPreset {
+	preset := ConnectionPreset{
+		Connection: defaultKubernetesConnection,
+		ExtraVars:  map[string]string{},
+	}
+
+	for name, value := range map[string]string{
+		"ansible_kubectl_kubeconfig": kubeconfig,
+		"ansible_kubectl_context":    kubeContext,
+		"ansible_kubectl_namespace":  namespace,
+	} {
+		if value != "" {
+			preset.ExtraVars[name] = value
+		}
+	}
+
+	if kubeconfig != "" {
+		preset.Environment = map[string]string{"K8S_AUTH_KUBECONFIG": kubeconfig}
+	}
+
+	return preset
+}
+
+// ChrootConnection runs the tasks in the root directory, e.g. of an image
+// being built. Entering the chroot requires root privileges, so become is
+// enabled. The directory has to exist when the playbooks run.
+func ChrootConnection(dir string) ConnectionPreset {
+	return ConnectionPreset{
+		Connection: "community.general.chroot",
+		ExtraVars: map[string]string{
+			"ansible_host":       dir,
+			"ansible_remote_tmp": remoteTmp,
+		},
+		Become:    true,
+		ChrootDir: dir,
+	}
+}
+
+// BuildahConnection runs the tasks in a buildah working container, e.g. to
+// build an image without a running container daemon.
+func BuildahConnection(container string) ConnectionPreset {
+	return ConnectionPreset{
+		Connection: "containers.podman.buildah",
+		ExtraVars: map[string]string{
+			"ansible_host":       container,
+			"ansible_remote_tmp": remoteTmp,
+		},
+	}
+}
+
+// UseConnection sets the connection of the preset and adds its variables and
+// environment to the configuration.
+func (c *Config) UseConnection(preset ConnectionPreset) {
+	c.Connection = preset.Connection
+	c.ChrootDir = preset.ChrootDir
+
+	if preset.Become {
+		c.Become = true
+	}
+
+	if len(preset.ExtraVars) > 0 {
+		vars, _ := json.Marshal(preset.ExtraVars)
+		c.ExtraVars = append(c.ExtraVars, string(vars))
+	}
+
+	if len(preset.Environment) > 0 && c.Environment == nil {
+		c.Environment = map[string]string{}
+	}
+
+	for name, value := range preset.Environment {
+		c.Environment[name] = value
+	}
+}
+
+// checkChrootDir returns ErrChrootDirNotFound if the chroot directory is not a
+// directory.
+func (p *AnsiblePlaybook) checkChrootDir() error {
+	if info, err := os.Stat(p.Config.ChrootDir); err != nil || !info.IsDir() {
+		return fmt.Errorf("%w: %s", ErrChrootDirNotFound, p.Config.ChrootDir)
+	}
+
+	return nil
+}
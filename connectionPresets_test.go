@@ -0,0 +1,72 @@
+package ansible
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestUseConnection tests a preset sets the connection, its variables and its
+// environment.
+func TestUseConnection(t *testing.T) {
+	config := Config{ExtraVars: []string{"app=web"}}
+	config.UseConnection(KubectlConnection("/etc/kube/config", "", "apps"))
+
+	if config.Connection != defaultKubernetesConnection {
+		t.Errorf("Expected connection %s, got %s", defaultKubernetesConnection, config.Connection)
+	}
+
+	expected := []string{"app=web", `{"ansible_kubectl_kubeconfig":"/etc/kube/config","ansible_kubectl_namespace":"apps"}`}
+	if !reflect.DeepEqual(config.ExtraVars, expected) {
+		t.Errorf("Expected extra vars %v, got %v", expected, config.ExtraVars)
+	}
+
+	playbook := &AnsiblePlaybook{Config: config}
+
+	env := strings.Join(playbook.environ(), "\n")
+	if !strings.Contains(env, "K8S_AUTH_KUBECONFIG=/etc/kube/config") {
+		t.Errorf("Expected environment to contain the kubeconfig, got %s", env)
+	}
+
+	args := strings.Join(playbook.ansibleCommand("tests/inventories/production").Args, " ")
+	if !strings.Contains(args, "--connection "+defaultKubernetesConnection) {
+		t.Errorf("Expected the kubectl connection, got %s", args)
+	}
+}
+
+// TestConnectionPresetDefaults tests presets without options add no variables
+// or environment.
+func TestConnectionPresetDefaults(t *testing.T) {
+	for _, preset := range []ConnectionPreset{DockerConnection(""), CommunityDockerConnection(""), PodmanConnection("")} {
+		config := Config{}
+		config.UseConnection(preset)
+
+		if config.Connection != preset.Connection || config.ExtraVars != nil || config.Environment != nil {
+			t.Errorf("Expected only the connection %s, got %+v", preset.Connection, config)
+		}
+	}
+}
+
+// TestChrootConnection tests the chroot preset enables become and the run
+// fails if the root directory does not exist.
+func TestChrootConnection(t *testing.T) {
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			Inventories:      []string{"tests/inventories/production"},
+			Playbooks:        []string{"tests/test.yml"},
+			SkipVersionCheck: true,
+		},
+	}
+
+	playbook.Config.UseConnection(ChrootConnection(filepath.Join(t.TempDir(), "rootfs")))
+
+	if !playbook.Config.Become {
+		t.Error("Expected become to be enabled")
+	}
+
+	if err := playbook.Exec(); !errors.Is(err, ErrChrootDirNotFound) {
+		t.Errorf("Expected ErrChrootDirNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,246 @@
+package ansible
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+)
+
+// minRSABits is the smallest RSA modulus accepted in strict crypto mode.
+const minRSABits = 2048
+
+// minBcryptRounds is the smallest number of bcrypt rounds accepted for
+// passphrase protected OpenSSH keys in strict crypto mode.
+const minBcryptRounds = 16
+
+var opensshKeyMagic = []byte("openssh-key-v1\x00")
+
+// CryptoPolicyError is returned if a private key or the directory for
+// temporary files violates the crypto policy.
+type CryptoPolicyError struct {
+	Subject string
+	Reason  string
+}
+
+func (e *CryptoPolicyError) Error() string {
+	return fmt.Sprintf("crypto policy rejects %s: %s", e.Subject, e.Reason)
+}
+
+// sshKeyInfo describes a private key without decrypting it.
+type sshKeyInfo struct {
+	Type      string
+	Bits      int
+	Encrypted bool
+	Cipher    string
+	Rounds    int
+	LegacyPEM bool
+}
+
+// checkCryptoPolicy checks the private key and, in strict crypto mode, the
+// directory the run directory is created in.
+func (p *AnsiblePlaybook) checkCryptoPolicy() error {
+	if p.Config.StrictCrypto {
+		if err := checkTempDir(os.TempDir()); err != nil {
+			return err
+		}
+	}
+
+	subject, key := "private key", []byte(p.Config.PrivateKey)
+	if p.Config.PrivateKey == "" {
+		if p.Config.PrivateKeyFile == "" {
+			return nil
+		}
+
+		content, err := os.ReadFile(p.Config.PrivateKeyFile)
+		if err != nil {
+			return fmt.Errorf("failed to read private key file: %w", err)
+		}
+
+		subject, key = "private key file "+p.Config.PrivateKeyFile, content
+	}
+
+	info, err := parseSSHKey(key)
+	if err != nil {
+		return &CryptoPolicyError{Subject: subject, Reason: err.Error()}
+	}
+
+	if p.Config.RequireKeyPassphrase && !info.Encrypted {
+		return &CryptoPolicyError{Subject: subject, Reason: "key is not protected by a passphrase"}
+	}
+
+	if p.Config.StrictCrypto {
+		if reason := weakKey(info); reason != "" {
+			return &CryptoPolicyError{Subject: subject, Reason: reason}
+		}
+	}
+
+	return nil
+}
+
+// checkTempDir rejects directories for temporary files which other users can
+// read, e.g. /tmp, since the private key and vault password are written to
+// the run directory in it.
+func checkTempDir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("failed to check temporary directory: %w", err)
+	}
+
+	if info.Mode().Perm()&0o004 != 0 {
+		return &CryptoPolicyError{
+			Subject: "temporary directory " + dir,
+			Reason:  "directory is world-readable, set TMPDIR to a private directory",
+		}
+	}
+
+	return nil
+}
+
+// weakKey returns why a key is rejected in strict crypto mode, or an empty
+// string for an acceptable key.
+func weakKey(info *sshKeyInfo) string {
+	switch {
+	case info.LegacyPEM:
+		return "legacy PEM encryption derives the key with MD5"
+	case info.Type == "ssh-dss":
+		return "DSA keys are not allowed"
+	case info.Type == "ssh-rsa" && info.Bits > 0 && info.Bits < minRSABits:
+		return fmt.Sprintf("RSA keys require at least %d bits, got %d", minRSABits, info.Bits)
+	case strings.Contains(info.Type, "ed25519"):
+		return "Ed25519 keys are not FIPS 140-2 approved"
+	case info.Type != "" && info.Type != "ssh-rsa" && !strings.Contains(info.Type, "ecdsa-sha2-nistp"):
+		return fmt.Sprintf("key type %s is not allowed", info.Type)
+	case info.Cipher != "" && info.Cipher != "none" && !strings.HasPrefix(info.Cipher, "aes"):
+		return fmt.Sprintf("cipher %s is not allowed", info.Cipher)
+	case info.Rounds > 0 && info.Rounds < minBcryptRounds:
+		return fmt.Sprintf("passphrase requires at least %d bcrypt rounds, got %d", minBcryptRounds, info.Rounds)
+	}
+
+	return ""
+}
+
+// parseSSHKey returns the type, size and protection of a PEM encoded
+// private key. The type of encrypted PKCS #8 keys is unknown.
+func parseSSHKey(content []byte) (*sshKeyInfo, error) {
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, fmt.Errorf("key is not PEM encoded")
+	}
+
+	info := &sshKeyInfo{}
+	if strings.Contains(block.Headers["Proc-Type"], "ENCRYPTED") {
+		info.Encrypted = true
+		info.LegacyPEM = true
+	}
+
+	switch block.Type {
+	case "OPENSSH PRIVATE KEY":
+		return parseOpenSSHKey(block.Bytes)
+	case "DSA PRIVATE KEY":
+		info.Type = "ssh-dss"
+	case "ENCRYPTED PRIVATE KEY":
+		info.Encrypted = true
+	case "RSA PRIVATE KEY":
+		info.Type = "ssh-rsa"
+		if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+			info.Bits = key.N.BitLen()
+		}
+	case "EC PRIVATE KEY":
+		info.Type = "ecdsa-sha2-nistp"
+		if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+			info.Bits = key.Curve.Params().BitSize
+		}
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse key: %w", err)
+		}
+
+		switch key := key.(type) {
+		case *rsa.PrivateKey:
+			info.Type, info.Bits = "ssh-rsa", key.N.BitLen()
+		case *ecdsa.PrivateKey:
+			info.Type, info.Bits = "ecdsa-sha2-nistp", key.Curve.Params().BitSize
+		default:
+			info.Type = strings.ToLower(strings.TrimPrefix(fmt.Sprintf("%T", key), "*"))
+		}
+	default:
+		return nil, fmt.Errorf("unsupported key format %s", block.Type)
+	}
+
+	return info, nil
+}
+
+// parseOpenSSHKey reads the cipher, key derivation and public key of the
+// OpenSSH private key format, which are stored unencrypted.
+func parseOpenSSHKey(data []byte) (*sshKeyInfo, error) {
+	if !bytes.HasPrefix(data, opensshKeyMagic) {
+		return nil, fmt.Errorf("invalid OpenSSH key")
+	}
+
+	r := &sshReader{data: data[len(opensshKeyMagic):]}
+
+	info := &sshKeyInfo{Cipher: string(r.string())}
+	info.Encrypted = info.Cipher != "none"
+
+	kdf, options := string(r.string()), &sshReader{data: r.string()}
+	if kdf == "bcrypt" {
+		options.string()
+		info.Rounds = int(options.uint32())
+	}
+
+	if r.uint32() < 1 {
+		return nil, fmt.Errorf("OpenSSH key contains no keys")
+	}
+
+	public := &sshReader{data: r.string()}
+	info.Type = string(public.string())
+
+	if info.Type == "ssh-rsa" {
+		public.string()
+		info.Bits = new(big.Int).SetBytes(public.string()).BitLen()
+	}
+
+	if r.err || public.err {
+		return nil, fmt.Errorf("invalid OpenSSH key")
+	}
+
+	return info, nil
+}
+
+// sshReader reads the wire format of SSH keys. Reading past the end sets err
+// and returns zero values.
+type sshReader struct {
+	data []byte
+	err  bool
+}
+
+func (r *sshReader) uint32() uint32 {
+	if len(r.data) < 4 {
+		r.err = true
+		return 0
+	}
+
+	value := binary.BigEndian.Uint32(r.data)
+	r.data = r.data[4:]
+	return value
+}
+
+func (r *sshReader) string() []byte {
+	length := r.uint32()
+	if r.err || uint32(len(r.data)) < length {
+		r.err = true
+		return nil
+	}
+
+	value := r.data[:length]
+	r.data = r.data[length:]
+	return value
+}
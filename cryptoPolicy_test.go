@@ -0,0 +1,147 @@
+package ansible
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+// opensshKey encodes the unencrypted header of an OpenSSH private key.
+func opensshKey(cipher, kdf string, kdfOptions, public []byte) []byte {
+	var data []byte
+	str := func(b []byte) {
+		data = append(data, sshUint32(uint32(len(b)))...)
+		data = append(data, b...)
+	}
+
+	data = append(data, opensshKeyMagic...)
+	str([]byte(cipher))
+	str([]byte(kdf))
+	str(kdfOptions)
+	data = append(data, sshUint32(1)...)
+	str(public)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "OPENSSH PRIVATE KEY", Bytes: data})
+}
+
+func sshString(s string) []byte {
+	return append(sshUint32(uint32(len(s))), s...)
+}
+
+func sshUint32(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, v)
+	return b
+}
+
+// TestCryptoPolicyKeys tests weak keys are rejected in strict crypto mode.
+func TestCryptoPolicyKeys(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TMPDIR", dir)
+
+	if err := os.Chmod(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	weakRSA, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ec, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ecDER, err := x509.MarshalPKCS8PrivateKey(ec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bcrypt := append(sshString("salt"), sshUint32(4)...)
+
+	tests := []struct {
+		name   string
+		key    []byte
+		reason string
+	}{
+		{"dsa", pem.EncodeToMemory(&pem.Block{Type: "DSA PRIVATE KEY", Bytes: []byte{0}}), "DSA"},
+		{"rsa 1024", pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(weakRSA)}), "2048 bits"},
+		{"ecdsa", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: ecDER}), ""},
+		{"openssh ed25519", opensshKey("none", "none", nil, sshString("ssh-ed25519")), "Ed25519"},
+		{"openssh ecdsa", opensshKey("aes256-ctr", "bcrypt", append(sshString("salt"), 0, 0, 0, 16), sshString("ecdsa-sha2-nistp384")), ""},
+		{"openssh few rounds", opensshKey("aes256-ctr", "bcrypt", bcrypt, sshString("ecdsa-sha2-nistp256")), "bcrypt rounds"},
+		{"openssh chacha", opensshKey("chacha20-poly1305@openssh.com", "bcrypt", bcrypt, sshString("ecdsa-sha2-nistp256")), "cipher"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			playbook := &AnsiblePlaybook{Config: Config{PrivateKey: string(test.key), StrictCrypto: true}}
+
+			err := playbook.checkCryptoPolicy()
+
+			var policy *CryptoPolicyError
+			switch {
+			case test.reason == "" && err != nil:
+				t.Errorf("Expected the key to be accepted, got %v", err)
+			case test.reason != "" && (!errors.As(err, &policy) || !strings.Contains(policy.Reason, test.reason)):
+				t.Errorf("Expected a CryptoPolicyError containing '%s', got %v", test.reason, err)
+			}
+		})
+	}
+}
+
+// TestCryptoPolicyPassphrase tests unencrypted keys are rejected if a
+// passphrase is required.
+func TestCryptoPolicyPassphrase(t *testing.T) {
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			PrivateKey:           string(opensshKey("none", "none", nil, sshString("ecdsa-sha2-nistp256"))),
+			RequireKeyPassphrase: true,
+		},
+	}
+
+	var policy *CryptoPolicyError
+	if err := playbook.checkCryptoPolicy(); !errors.As(err, &policy) {
+		t.Errorf("Expected a CryptoPolicyError, got %v", err)
+	}
+
+	playbook.Config.PrivateKey = string(opensshKey("aes256-ctr", "bcrypt", append(sshString("salt"), 0, 0, 0, 16), sshString("ecdsa-sha2-nistp256")))
+	if err := playbook.checkCryptoPolicy(); err != nil {
+		t.Errorf("Expected the encrypted key to be accepted, got %v", err)
+	}
+}
+
+// TestCryptoPolicyTempDir tests world-readable directories for temporary
+// files are rejected in strict crypto mode.
+func TestCryptoPolicyTempDir(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TMPDIR", dir)
+
+	if err := os.Chmod(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	playbook := &AnsiblePlaybook{Config: Config{StrictCrypto: true}}
+
+	var policy *CryptoPolicyError
+	if err := playbook.checkCryptoPolicy(); !errors.As(err, &policy) {
+		t.Errorf("Expected a CryptoPolicyError, got %v", err)
+	}
+
+	if err := os.Chmod(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := playbook.checkCryptoPolicy(); err != nil {
+		t.Errorf("Expected a private directory to be accepted, got %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package ansible
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+)
+
+// DeadlineVar is the extra var passing the remaining seconds until the
+// deadline of the context to the playbooks.
+const DeadlineVar = "arillso_deadline_seconds"
+
+// DeadlineError is returned if a playbook run is not started because less
+// than MinRemainingTime is left until the deadline of the context.
+type DeadlineError struct {
+	Inventory string
+	Remaining time.Duration
+	Minimum   time.Duration
+}
+
+func (e *DeadlineError) Error() string {
+	return fmt.Sprintf(
+		"not starting run against inventory %s, %s left until the deadline but %s required",
+		e.Inventory,
+		e.Remaining.Round(time.Second),
+		e.Minimum,
+	)
+}
+
+// remaining returns the time left until the deadline of the context.
+func (p *AnsiblePlaybook) remaining() (time.Duration, bool) {
+	deadline, ok := p.context().Deadline()
+	if !ok {
+		return 0, false
+	}
+
+	return time.Until(deadline), true
+}
+
+// checkDeadline fails if less than the minimum remaining time is left.
+func (p *AnsiblePlaybook) checkDeadline(inventory string) error {
+	remaining, ok := p.remaining()
+	if !ok || remaining >= p.Config.MinRemainingTime {
+		return nil
+	}
+
+	return &DeadlineError{
+		Inventory: inventory,
+		Remaining: remaining,
+		Minimum:   p.Config.MinRemainingTime,
+	}
+}
+
+// deadlineExtraVars returns the extra vars argument passing the remaining
+// seconds, or an empty string if the context has no deadline.
+func (p *AnsiblePlaybook) deadlineExtraVars() string {
+	remaining, ok := p.remaining()
+	if !ok {
+		return ""
+	}
+
+	seconds := int(math.Max(0, math.Floor(remaining.Seconds())))
+
+	vars, _ := json.Marshal(map[string]int{DeadlineVar: seconds})
+	return string(vars)
+}
@@ -0,0 +1,76 @@
+package ansible
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"errors"
+)
+
+// TestDeadlineExtraVar tests the remaining seconds are passed to the
+// playbook.
+func TestDeadlineExtraVar(t *testing.T) {
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "ansible-playbook"), []byte("#!/bin/sh\necho \"$@\"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	var output bytes.Buffer
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir:    bin,
+			DeadlineExtraVar: true,
+			Inventories:      []string{"tests/inventories/production"},
+			Playbooks:        []string{"tests/test.yml"},
+			SkipVersionCheck: true,
+		},
+		Output: &output,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+
+	if err := playbook.ExecContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if !regexp.MustCompile(`\{"arillso_deadline_seconds":35\d\d\}`).MatchString(output.String()) {
+		t.Errorf("Expected the remaining seconds, got '%s'", output.String())
+	}
+
+	output.Reset()
+	if err := playbook.Exec(); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(output.String(), DeadlineVar) {
+		t.Errorf("Expected no remaining seconds without a deadline, got '%s'", output.String())
+	}
+}
+
+// TestMinRemainingTime tests runs are not started close to the deadline.
+func TestMinRemainingTime(t *testing.T) {
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			Inventories:      []string{"tests/inventories/production"},
+			MinRemainingTime: time.Hour,
+			Playbooks:        []string{"tests/test.yml"},
+			SkipVersionCheck: true,
+		},
+		Output: &bytes.Buffer{},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	var deadline *DeadlineError
+	if err := playbook.ExecContext(ctx); !errors.As(err, &deadline) || deadline.Inventory != "tests/inventories/production" {
+		t.Errorf("Expected a deadline error, got %v", err)
+	}
+}
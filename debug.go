@@ -0,0 +1,102 @@
+package ansible
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// envSnapshot returns the variables of the environment which affect ansible,
+// i.e. the ANSIBLE_* and PYTHON* variables, PATH, the virtualenv and the
+// proxies. Values of sensitive variables and proxy credentials are
+// redacted.
+func (p *AnsiblePlaybook) envSnapshot(env []string) []string {
+	values := map[string]string{}
+
+	for _, entry := range env {
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || !snapshotVariable(parts[0]) {
+			continue
+		}
+
+		values[parts[0]] = string(p.redact([]byte(sanitizeEnvValue(parts[0], parts[1]))))
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	snapshot := make([]string, 0, len(keys))
+	for _, key := range keys {
+		snapshot = append(snapshot, key+"="+values[key])
+	}
+
+	return snapshot
+}
+
+// sanitizeEnvValue redacts the value of a sensitive variable and the
+// credentials of a proxy.
+func sanitizeEnvValue(key, value string) string {
+	switch {
+	case sensitiveKeyPattern.MatchString(strings.TrimPrefix(key, "ANSIBLE_")):
+		return redacted
+	case strings.HasSuffix(strings.ToUpper(key), "_PROXY"):
+		return redactURL(value)
+	}
+
+	return value
+}
+
+func snapshotVariable(key string) bool {
+	upper := strings.ToUpper(key)
+
+	switch {
+	case strings.HasPrefix(key, "ANSIBLE_"), strings.HasPrefix(key, "PYTHON"):
+		return true
+	case key == "PATH", key == "VIRTUAL_ENV":
+		return true
+	case upper == "HTTP_PROXY", upper == "HTTPS_PROXY", upper == "NO_PROXY":
+		return true
+	}
+
+	return false
+}
+
+// redactURL removes the password of an URL.
+func redactURL(value string) string {
+	u, err := url.Parse(value)
+	if err != nil || u.User == nil {
+		return value
+	}
+
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), "redacted")
+	}
+
+	return u.String()
+}
+
+// traceEnv writes the environment snapshot in debug mode, if it changed
+// since the last command.
+func (p *AnsiblePlaybook) traceEnv(env []string) {
+	snapshot := strings.Join(p.envSnapshot(env), "\n")
+	if snapshot == p.tracedEnv {
+		return
+	}
+
+	p.tracedEnv = snapshot
+	fmt.Fprintf(p.output(), "# environment\n%s\n", prefixLines(snapshot, "#   "))
+}
+
+func prefixLines(text, prefix string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = prefix + line
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,53 @@
+package ansible
+
+// ResultDiff is a task whose status on a host differs between two runs. An
+// empty status means the task did not run on the host.
+type ResultDiff struct {
+	Play   string `json:"play"`
+	Task   string `json:"task"`
+	Host   string `json:"host"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// DiffRunResults returns the tasks whose status changed between two runs.
+func DiffRunResults(before, after *RunResult) []ResultDiff {
+	var diffs []ResultDiff
+
+	key := func(task TaskResult) [3]string {
+		return [3]string{task.Play, task.Task, task.Host}
+	}
+
+	previous := map[[3]string]string{}
+	for _, task := range before.Tasks {
+		previous[key(task)] = task.Status
+	}
+
+	seen := map[[3]string]bool{}
+	for _, task := range after.Tasks {
+		seen[key(task)] = true
+
+		if status := previous[key(task)]; status != task.Status {
+			diffs = append(diffs, ResultDiff{
+				Play:   task.Play,
+				Task:   task.Task,
+				Host:   task.Host,
+				Before: status,
+				After:  task.Status,
+			})
+		}
+	}
+
+	for _, task := range before.Tasks {
+		if !seen[key(task)] {
+			diffs = append(diffs, ResultDiff{
+				Play:   task.Play,
+				Task:   task.Task,
+				Host:   task.Host,
+				Before: task.Status,
+			})
+		}
+	}
+
+	return diffs
+}
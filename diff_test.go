@@ -0,0 +1,41 @@
+package ansible
+
+import (
+	"testing"
+)
+
+// TestDiffRunResults tests that status changes between runs are reported.
+func TestDiffRunResults(t *testing.T) {
+	before := &RunResult{
+		Tasks: []TaskResult{
+			{Play: "Deploy", Task: "Install", Host: "web1", Status: StatusOk},
+			{Play: "Deploy", Task: "Install", Host: "web2", Status: StatusOk},
+			{Play: "Deploy", Task: "Cleanup", Host: "web1", Status: StatusChanged},
+		},
+	}
+
+	after := &RunResult{
+		Tasks: []TaskResult{
+			{Play: "Deploy", Task: "Install", Host: "web1", Status: StatusOk},
+			{Play: "Deploy", Task: "Install", Host: "web2", Status: StatusFailed},
+			{Play: "Deploy", Task: "Migrate", Host: "web1", Status: StatusChanged},
+		},
+	}
+
+	expected := []ResultDiff{
+		{Play: "Deploy", Task: "Install", Host: "web2", Before: StatusOk, After: StatusFailed},
+		{Play: "Deploy", Task: "Migrate", Host: "web1", After: StatusChanged},
+		{Play: "Deploy", Task: "Cleanup", Host: "web1", Before: StatusChanged},
+	}
+
+	diffs := DiffRunResults(before, after)
+	if len(diffs) != len(expected) {
+		t.Fatalf("Expected %d diffs, got %+v", len(expected), diffs)
+	}
+
+	for i := range expected {
+		if diffs[i] != expected[i] {
+			t.Errorf("Expected diff %+v, got %+v", expected[i], diffs[i])
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package ansible
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Sources of the contributions to the environment of the ansible commands.
+const (
+	EnvSourceConfig      = "config"
+	EnvSourceOutput      = "output"
+	EnvSourceGalaxy      = "galaxy"
+	EnvSourcePlugins     = "plugins"
+	EnvSourceFacts       = "facts"
+	EnvSourceSSH         = "ssh"
+	EnvSourceEvents      = "events"
+	EnvSourceInventory   = "inventory"
+	EnvSourceProxy       = "proxy"
+	EnvSourceEnvironment = "environment"
+)
+
+// EnvBuilder composes the environment of the ansible commands from the
+// environment of the process and the contributions of the subsystems of a
+// run. Contributions setting a variable to different values are recorded as
+// conflicts.
+type EnvBuilder struct {
+	// Redact removes secrets from the values of Dump.
+	Redact func([]byte) []byte
+
+	base      []string
+	names     []string
+	values    map[string]EnvVar
+	conflicts []EnvConflict
+}
+
+// EnvVar is a contribution to the environment.
+type EnvVar struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// EnvConflict is a variable set to different values by two sources.
+type EnvConflict struct {
+	Previous EnvVar `json:"previous"`
+	Current  EnvVar `json:"current"`
+}
+
+func (c EnvConflict) String() string {
+	return fmt.Sprintf("%s set by %s and %s", c.Current.Name, c.Previous.Source, c.Current.Source)
+}
+
+// EnvConflictError is returned if the subsystems of a run set a variable of
+// the environment to different values.
+type EnvConflictError struct {
+	Conflicts []EnvConflict
+}
+
+func (e *EnvConflictError) Error() string {
+	conflicts := make([]string, len(e.Conflicts))
+	for i, conflict := range e.Conflicts {
+		conflicts[i] = conflict.String()
+	}
+
+	return "conflicting environment: " + strings.Join(conflicts, ", ")
+}
+
+// NewEnvBuilder returns a builder on top of the base environment.
+func NewEnvBuilder(base []string) *EnvBuilder {
+	return &EnvBuilder{
+		base:   base,
+		values: map[string]EnvVar{},
+	}
+}
+
+// Set sets the variable. A different value of another source is a conflict,
+// the last value wins.
+func (b *EnvBuilder) Set(source, name, value string) {
+	if previous, ok := b.values[name]; ok {
+		if previous.Value != value && previous.Source != source {
+			b.conflicts = append(b.conflicts, EnvConflict{
+				Previous: previous,
+				Current:  EnvVar{Name: name, Value: value, Source: source},
+			})
+		}
+	} else {
+		b.names = append(b.names, name)
+	}
+
+	b.values[name] = EnvVar{Name: name, Value: value, Source: source}
+}
+
+// Add sets the NAME=value entries.
+func (b *EnvBuilder) Add(source string, entries ...string) {
+	for _, entry := range entries {
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) == 2 {
+			b.Set(source, parts[0], parts[1])
+		}
+	}
+}
+
+// Default sets the variable unless the base environment or a contribution
+// sets it, so it can be overridden.
+func (b *EnvBuilder) Default(source, name, value string) {
+	if _, ok := b.Lookup(name); ok {
+		return
+	}
+
+	b.Set(source, name, value)
+}
+
+// Lookup returns the value of the variable, contributions take precedence
+// over the base environment.
+func (b *EnvBuilder) Lookup(name string) (string, bool) {
+	if v, ok := b.values[name]; ok {
+		return v.Value, true
+	}
+
+	for i := len(b.base) - 1; i >= 0; i-- {
+		if strings.HasPrefix(b.base[i], name+"=") {
+			return b.base[i][len(name)+1:], true
+		}
+	}
+
+	return "", false
+}
+
+// Conflicts returns the variables set to different values.
+func (b *EnvBuilder) Conflicts() []EnvConflict {
+	return b.conflicts
+}
+
+// Vars returns the contributions in the order they were first set.
+func (b *EnvBuilder) Vars() []EnvVar {
+	vars := make([]EnvVar, len(b.names))
+	for i, name := range b.names {
+		vars[i] = b.values[name]
+	}
+
+	return vars
+}
+
+// Environ returns the base environment followed by the contributions.
+func (b *EnvBuilder) Environ() []string {
+	env := append([]string{}, b.base...)
+	for _, v := range b.Vars() {
+		env = append(env, v.Name+"="+v.Value)
+	}
+
+	return env
+}
+
+// Dump returns the contributions sorted by name with their source, e.g. for
+// a plan of the run. Values of sensitive variables, proxy credentials and
+// secrets are redacted.
+func (b *EnvBuilder) Dump() []string {
+	vars := b.Vars()
+	sort.Slice(vars, func(i, j int) bool {
+		return vars[i].Name < vars[j].Name
+	})
+
+	dump := make([]string, len(vars))
+	for i, v := range vars {
+		value := sanitizeEnvValue(v.Name, v.Value)
+		if b.Redact != nil {
+			value = string(b.Redact([]byte(value)))
+		}
+
+		dump[i] = fmt.Sprintf("%s=%s (%s)", v.Name, value, v.Source)
+	}
+
+	return dump
+}
+
+// setEnv adds a variable of a subsystem to the environment of the run.
+func (p *AnsiblePlaybook) setEnv(source, name, value string) {
+	p.env = append(p.env, EnvVar{Name: name, Value: value, Source: source})
+}
+
+// checkEnv fails with an EnvConflictError if the subsystems of the run set a
+// variable to different values.
+func (p *AnsiblePlaybook) checkEnv() error {
+	if conflicts := p.EnvBuilder().Conflicts(); len(conflicts) > 0 {
+		return &EnvConflictError{Conflicts: conflicts}
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package ansible
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	// ErrNoPlaybooks is returned if none of the configured playbook patterns
+	// matches a file.
+	ErrNoPlaybooks = errors.New("failed to find playbook files")
+
+	// ErrGalaxyFileNotFound is returned if the configured galaxy file does
+	// not exist.
+	ErrGalaxyFileNotFound = errors.New("failed to find galaxy file")
+
+	// ErrModulePathNotFound is returned if a configured module or module
+	// utils path is not a directory.
+	ErrModulePathNotFound = errors.New("failed to find module path")
+
+	// ErrChrootDirNotFound is returned if the root directory of the chroot
+	// connection is not a directory.
+	ErrChrootDirNotFound = errors.New("failed to find chroot directory")
+
+	// ErrInvalidTimeouts is returned if the connect, task and run timeouts
+	// contradict each other.
+	ErrInvalidTimeouts = errors.New("invalid timeouts")
+
+	// ErrInvalidFactGathering is returned for unknown gather subsets and
+	// gathering policies.
+	ErrInvalidFactGathering = errors.New("invalid fact gathering")
+
+	// ErrOutsideWindow is returned if a run against an inventory is started
+	// outside of its maintenance windows.
+	ErrOutsideWindow = errors.New("outside of maintenance window")
+
+	// ErrInvalidMaintenanceWindow is returned for maintenance windows with an
+	// invalid start, duration or location.
+	ErrInvalidMaintenanceWindow = errors.New("invalid maintenance window")
+)
+
+// ErrInventoryNotFound is returned if an inventory is neither an existing
+// file or directory nor a comma separated host list.
+type ErrInventoryNotFound struct {
+	Path string
+}
+
+func (e *ErrInventoryNotFound) Error() string {
+	return fmt.Sprintf("failed to find inventory %s", e.Path)
+}
+
+// CommandError is returned if a command exits with a non-zero exit code.
+type CommandError struct {
+	Command  string
+	ExitCode int
+	Err      error
+}
+
+func (e *CommandError) Error() string {
+	return fmt.Sprintf("%s failed: %s", e.Command, e.Err)
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// commandError wraps the exit error of a command into a CommandError.
+func commandError(cmd *exec.Cmd, err error) error {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return err
+	}
+
+	return &CommandError{
+		Command:  filepath.Base(cmd.Args[0]),
+		ExitCode: exitErr.ExitCode(),
+		Err:      err,
+	}
+}
+
+// checkInventories returns an error for the first inventory which does not
+// exist. Comma separated host lists are passed as they are.
+func (p *AnsiblePlaybook) checkInventories() error {
+	for _, inventory := range p.Config.Inventories {
+		if strings.Contains(inventory, ",") {
+			continue
+		}
+
+		if _, err := os.Stat(inventory); err != nil {
+			return &ErrInventoryNotFound{Path: inventory}
+		}
+	}
+
+	return nil
+}
+
+// checkModulePaths returns an error for the first module or module utils path
+// which is not a directory.
+func (p *AnsiblePlaybook) checkModulePaths() error {
+	for _, paths := range [][]string{p.Config.ModulePath, p.Config.ModuleUtilsPath} {
+		for _, path := range paths {
+			if info, err := os.Stat(path); err != nil || !info.IsDir() {
+				return fmt.Errorf("%w: %s", ErrModulePathNotFound, path)
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkGalaxyFile returns ErrGalaxyFileNotFound if the galaxy file does not
+// exist.
+func (p *AnsiblePlaybook) checkGalaxyFile() error {
+	if _, err := os.Stat(p.Config.GalaxyFile); err != nil {
+		return fmt.Errorf("%w: %s", ErrGalaxyFileNotFound, p.Config.GalaxyFile)
+	}
+
+	return nil
+}
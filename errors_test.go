@@ -0,0 +1,76 @@
+package ansible
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// TestErrors tests the exported errors can be matched with errors.Is and
+// errors.As.
+func TestErrors(t *testing.T) {
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			Inventories:      []string{"tests/inventories/production"},
+			Playbooks:        []string{"missing.yml"},
+			SkipVersionCheck: true,
+		},
+	}
+
+	if err := playbook.Exec(); !errors.Is(err, ErrNoPlaybooks) {
+		t.Errorf("Expected ErrNoPlaybooks, got %v", err)
+	}
+
+	playbook.Config.Playbooks = []string{"tests/test.yml"}
+	playbook.Config.Inventories = []string{"localhost,", "tests/inventories/missing"}
+
+	var notFound *ErrInventoryNotFound
+	if err := playbook.Exec(); !errors.As(err, &notFound) || notFound.Path != "tests/inventories/missing" {
+		t.Errorf("Expected ErrInventoryNotFound, got %v", err)
+	}
+
+	playbook.Config.Inventories = []string{"tests/inventories/production"}
+	playbook.Config.ModulePath = []string{"tests/test.yml"}
+
+	if err := playbook.Exec(); !errors.Is(err, ErrModulePathNotFound) {
+		t.Errorf("Expected ErrModulePathNotFound, got %v", err)
+	}
+
+	playbook.Config.ModulePath = nil
+	playbook.Config.GalaxyFile = "missing.yml"
+
+	if err := playbook.Exec(); !errors.Is(err, ErrGalaxyFileNotFound) {
+		t.Errorf("Expected ErrGalaxyFileNotFound, got %v", err)
+	}
+}
+
+// TestCommandError tests the exit code of a failed command is exposed.
+func TestCommandError(t *testing.T) {
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "ansible-playbook"), []byte("#!/bin/sh\nexit 4\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir:    bin,
+			Inventories:      []string{"tests/inventories/production"},
+			Playbooks:        []string{"tests/test.yml"},
+			SkipVersionCheck: true,
+		},
+	}
+
+	err := playbook.Exec()
+
+	var commandErr *CommandError
+	if !errors.As(err, &commandErr) || commandErr.ExitCode != 4 || commandErr.Command != "ansible-playbook" {
+		t.Fatalf("Expected a CommandError with exit code 4, got %v", err)
+	}
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Errorf("Expected the CommandError to wrap the exit error, got %v", err)
+	}
+}
@@ -0,0 +1,152 @@
+package ansible
+
+import (
+	"bufio"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// EventSocketVar is the environment variable with the path of the unix socket
+// the event callback plugin sends the task events to.
+const EventSocketVar = "ARILLSO_EVENTS_SOCKET"
+
+const eventCallback = "arillso_events"
+
+//go:embed plugins/callback/arillso_events.py
+var eventCallbackSource []byte
+
+// Task event types sent by the event callback plugin.
+const (
+	EventPlaybookStart = "playbook_start"
+	EventPlayStart     = "play_start"
+	EventTaskStart     = "task_start"
+	EventHandlerStart  = "handler_start"
+	EventTaskResult    = "task_result"
+	EventStats         = "stats"
+)
+
+// TaskEvent is a structured event of a run sent by the event callback plugin.
+type TaskEvent struct {
+	Event    string               `json:"event"`
+	Time     time.Time            `json:"time"`
+	Playbook string               `json:"playbook,omitempty"`
+	Play     string               `json:"play,omitempty"`
+	Task     string               `json:"task,omitempty"`
+	Host     string               `json:"host,omitempty"`
+	Status   string               `json:"status,omitempty"`
+	Changed  bool                 `json:"changed,omitempty"`
+	Ignored  bool                 `json:"ignored,omitempty"`
+	Message  string               `json:"msg,omitempty"`
+	Stats    map[string]HostStats `json:"stats,omitempty"`
+}
+
+// EventHandler receives the task events of a run. The events of a run are
+// handled one at a time.
+type EventHandler interface {
+	HandleEvent(event TaskEvent)
+}
+
+// EventHandlerFunc adapts a function to an EventHandler.
+type EventHandlerFunc func(event TaskEvent)
+
+// HandleEvent calls f(event).
+func (f EventHandlerFunc) HandleEvent(event TaskEvent) {
+	f(event)
+}
+
+// streamEvents writes the event callback plugin to the run directory and
+// listens on a unix socket for its events. The returned function stops
+// listening once all connected commands finished.
+func (p *AnsiblePlaybook) streamEvents() (func(), error) {
+	dir, err := p.runDir()
+	if err != nil {
+		return nil, err
+	}
+
+	plugins := filepath.Join(dir, "callback_plugins")
+	if err := p.mkdirAll(plugins); err != nil {
+		return nil, fmt.Errorf("failed to create callback plugin directory: %w", err)
+	}
+
+	if err := p.writeFile(filepath.Join(plugins, eventCallback+".py"), eventCallbackSource); err != nil {
+		return nil, fmt.Errorf("failed to write event callback plugin: %w", err)
+	}
+
+	socket := filepath.Join(dir, "events.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for events: %w", err)
+	}
+
+	p.eventPlugins = plugins
+	p.setEnv(EnvSourceEvents, EventSocketVar, socket)
+
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer conn.Close()
+
+				p.readEvents(conn, &mu)
+			}()
+		}
+	}()
+
+	return func() {
+		listener.Close()
+		wg.Wait()
+
+		p.eventPlugins = ""
+	}, nil
+}
+
+// readEvents passes the events of a connection to the event handler and the
+// results of the hosts finishing a play to the host result function.
+// Malformed lines are skipped.
+func (p *AnsiblePlaybook) readEvents(r io.Reader, mu *sync.Mutex) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	hosts := &hostResults{}
+
+	for scanner.Scan() {
+		var event TaskEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			continue
+		}
+
+		event.Message = string(p.redact([]byte(event.Message)))
+
+		mu.Lock()
+		if p.Config.Events != nil {
+			p.Config.Events.HandleEvent(event)
+		}
+
+		if p.Config.HostResults != nil {
+			for _, result := range hosts.event(event) {
+				p.Config.HostResults(result)
+			}
+		}
+		mu.Unlock()
+	}
+}
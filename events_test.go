@@ -0,0 +1,70 @@
+package ansible
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// TestEvents tests the events sent to the event socket are passed to the
+// event handler and the callback plugin is enabled for the run.
+func TestEvents(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 is not installed")
+	}
+
+	bin := t.TempDir()
+	script := `#!/bin/sh
+test -f "$(echo "$ANSIBLE_CALLBACK_PLUGINS" | cut -d: -f1)/arillso_events.py" || exit 3
+test "$ANSIBLE_CALLBACKS_ENABLED" = "arillso_events" || exit 4
+python3 - <<'EOF'
+import os, socket
+s = socket.socket(socket.AF_UNIX, socket.SOCK_STREAM)
+s.connect(os.environ["ARILLSO_EVENTS_SOCKET"])
+s.sendall(b'{"event":"task_start","play":"site","task":"Install nginx"}\n')
+s.sendall(b'not json\n')
+s.sendall(b'{"event":"task_result","task":"Install nginx","host":"web1","status":"failed","msg":"token s3cr3t rejected"}\n')
+s.sendall(b'{"event":"stats","stats":{"web1":{"ok":1,"failed":1}}}\n')
+s.close()
+EOF
+`
+	if err := os.WriteFile(filepath.Join(bin, "ansible-playbook"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	var events []TaskEvent
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir:    bin,
+			Events:           EventHandlerFunc(func(event TaskEvent) { events = append(events, event) }),
+			ExtraVars:        []string{"api_token=s3cr3t"},
+			Inventories:      []string{"tests/inventories/production"},
+			NoLogSensitive:   true,
+			Playbooks:        []string{"tests/test.yml"},
+			SkipVersionCheck: true,
+		},
+		Output: &bytes.Buffer{},
+	}
+
+	if err := playbook.Exec(); err != nil {
+		t.Fatalf("Exec should execute without error, but received: %v", err)
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events, got %+v", events)
+	}
+
+	if events[0].Event != EventTaskStart || events[0].Task != "Install nginx" {
+		t.Errorf("Expected a task start event, got %+v", events[0])
+	}
+
+	if events[1].Status != StatusFailed || events[1].Message != "token "+redacted+" rejected" {
+		t.Errorf("Expected a redacted failed task result, got %+v", events[1])
+	}
+
+	if events[2].Stats["web1"].Failed != 1 {
+		t.Errorf("Expected the stats event, got %+v", events[2])
+	}
+}
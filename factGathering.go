@@ -0,0 +1,82 @@
+package ansible
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GatheringPolicy controls when facts are gathered.
+type GatheringPolicy string
+
+// Gathering policies. GatheringDefault keeps the policy of the ansible
+// configuration.
+const (
+	GatheringDefault  GatheringPolicy = ""
+	GatheringImplicit GatheringPolicy = "implicit"
+	GatheringExplicit GatheringPolicy = "explicit"
+	GatheringSmart    GatheringPolicy = "smart"
+)
+
+// gatherSubsets are the subsets and fact collectors accepted by the setup
+// module.
+var gatherSubsets = map[string]bool{
+	"all":          true,
+	"min":          true,
+	"hardware":     true,
+	"network":      true,
+	"virtual":      true,
+	"ohai":         true,
+	"facter":       true,
+	"apparmor":     true,
+	"caps":         true,
+	"cmdline":      true,
+	"date_time":    true,
+	"distribution": true,
+	"dns":          true,
+	"env":          true,
+	"fips":         true,
+	"local":        true,
+	"lsb":          true,
+	"pkg_mgr":      true,
+	"platform":     true,
+	"python":       true,
+	"selinux":      true,
+	"service_mgr":  true,
+	"ssh_pub_keys": true,
+	"user":         true,
+}
+
+// checkFactGathering returns ErrInvalidFactGathering for unknown gather
+// subsets and gathering policies, which ansible only reports once facts are
+// gathered.
+func (p *AnsiblePlaybook) checkFactGathering() error {
+	switch p.Config.Gathering {
+	case GatheringDefault, GatheringImplicit, GatheringExplicit, GatheringSmart:
+	default:
+		return fmt.Errorf("%w: unknown gathering policy %q", ErrInvalidFactGathering, p.Config.Gathering)
+	}
+
+	for _, subset := range p.Config.GatherSubset {
+		if !gatherSubsets[strings.TrimPrefix(subset, "!")] {
+			return fmt.Errorf("%w: unknown gather subset %q", ErrInvalidFactGathering, subset)
+		}
+	}
+
+	return nil
+}
+
+// factGatheringEnv returns the gathering policy and the default gather
+// subset of the plays.
+func (p *AnsiblePlaybook) factGatheringEnv() []string {
+	var env []string
+
+	if p.Config.Gathering != GatheringDefault {
+		env = append(env, "ANSIBLE_GATHERING="+string(p.Config.Gathering))
+	}
+
+	if len(p.Config.GatherSubset) > 0 {
+		env = append(env, "ANSIBLE_GATHER_SUBSET="+strings.Join(p.Config.GatherSubset, ","))
+	}
+
+	return env
+}
@@ -0,0 +1,49 @@
+package ansible
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestCheckFactGathering tests unknown gather subsets and gathering policies
+// are rejected before the run.
+func TestCheckFactGathering(t *testing.T) {
+	tests := []struct {
+		config Config
+		valid  bool
+	}{
+		{Config{}, true},
+		{Config{Gathering: GatheringSmart, GatherSubset: []string{"!all", "min", "network"}}, true},
+		{Config{GatherSubset: []string{"!hardware", "distribution"}}, true},
+		{Config{GatherSubset: []string{"netwrok"}}, false},
+		{Config{Gathering: "lazy"}, false},
+	}
+
+	for _, test := range tests {
+		playbook := &AnsiblePlaybook{Config: test.config}
+
+		err := playbook.checkFactGathering()
+		if test.valid != (err == nil) || (err != nil && !errors.Is(err, ErrInvalidFactGathering)) {
+			t.Errorf("Expected %+v to be valid: %t, got %v", test.config, test.valid, err)
+		}
+	}
+}
+
+// TestFactGatheringEnv tests the gathering policy and the gather subset are
+// passed in the environment.
+func TestFactGatheringEnv(t *testing.T) {
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			GatherSubset: []string{"!all", "network"},
+			Gathering:    GatheringExplicit,
+		},
+	}
+
+	env := "\n" + strings.Join(playbook.environ(), "\n") + "\n"
+	for _, expected := range []string{"ANSIBLE_GATHERING=explicit", "ANSIBLE_GATHER_SUBSET=!all,network"} {
+		if !strings.Contains(env, "\n"+expected+"\n") {
+			t.Errorf("Expected %s in the environment", expected)
+		}
+	}
+}
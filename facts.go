@@ -0,0 +1,64 @@
+package ansible
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// factCache points the jsonfile fact cache to the run directory, so the
+// gathered facts of every host can be exported after the run.
+func (p *AnsiblePlaybook) factCache() error {
+	if p.Config.ArtifactDir == "" {
+		return errors.New("exporting facts requires an artifact directory")
+	}
+
+	dir, err := p.runDir()
+	if err != nil {
+		return err
+	}
+
+	p.setEnv(EnvSourceFacts, "ANSIBLE_CACHE_PLUGIN", "ansible.builtin.jsonfile")
+	p.setEnv(EnvSourceFacts, "ANSIBLE_CACHE_PLUGIN_CONNECTION", filepath.Join(dir, "facts"))
+	p.setEnv(EnvSourceFacts, "ANSIBLE_CACHE_PLUGIN_PREFIX", "")
+
+	return nil
+}
+
+// exportFacts copies the cached facts to facts/<host>.json in the artifact
+// directory.
+func (p *AnsiblePlaybook) exportFacts() error {
+	cache := filepath.Join(p.tmpdir, "facts")
+
+	entries, err := os.ReadDir(cache)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to read fact cache: %w", err)
+	}
+
+	dest := filepath.Join(p.Config.ArtifactDir, "facts")
+	if err := p.mkdirAll(dest); err != nil {
+		return fmt.Errorf("failed to create facts directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		content, err := os.ReadFile(filepath.Join(cache, entry.Name()))
+		if err != nil {
+			return fmt.Errorf("failed to read facts of %s: %w", entry.Name(), err)
+		}
+
+		if err := p.writeFile(filepath.Join(dest, entry.Name()+".json"), content); err != nil {
+			return fmt.Errorf("failed to write facts of %s: %w", entry.Name(), err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package ansible
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestExportFacts tests that cached facts are exported per host.
+func TestExportFacts(t *testing.T) {
+	artifacts := t.TempDir()
+
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			ArtifactDir: artifacts,
+			ExportFacts: true,
+		},
+	}
+	defer playbook.cleanup()
+
+	if err := playbook.factCache(); err != nil {
+		t.Fatalf("factCache() failed: %s", err)
+	}
+
+	// Simulate the jsonfile cache plugin writing the facts of a host.
+	cache := filepath.Join(playbook.tmpdir, "facts")
+	if err := os.MkdirAll(cache, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(cache, "web1"), []byte(`{"ansible_hostname": "web1"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := playbook.exportFacts(); err != nil {
+		t.Fatalf("exportFacts() failed: %s", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(artifacts, "facts", "web1.json"))
+	if err != nil {
+		t.Fatalf("Expected facts file for web1: %s", err)
+	}
+
+	if string(content) != `{"ansible_hostname": "web1"}` {
+		t.Errorf("Unexpected facts: %s", content)
+	}
+}
@@ -0,0 +1,90 @@
+package ansible
+
+import "os"
+
+// Default permissions of the files and directories written by a run.
+const (
+	DefaultFileMode os.FileMode = 0o600
+	DefaultDirMode  os.FileMode = 0o700
+)
+
+// fileMode returns the permissions of the files written by the run.
+func (p *AnsiblePlaybook) fileMode() os.FileMode {
+	if p.Config.FileMode != 0 {
+		return p.Config.FileMode.Perm()
+	}
+
+	return DefaultFileMode
+}
+
+// dirMode returns the permissions of the directories created by the run.
+func (p *AnsiblePlaybook) dirMode() os.FileMode {
+	if p.Config.DirMode != 0 {
+		return p.Config.DirMode.Perm()
+	}
+
+	return DefaultDirMode
+}
+
+// writeFile writes a file with the file mode of the run. The mode is set
+// explicitly, so it does not depend on the umask or an existing file.
+func (p *AnsiblePlaybook) writeFile(path string, content []byte) error {
+	if err := os.WriteFile(path, content, p.fileMode()); err != nil {
+		return err
+	}
+
+	return os.Chmod(path, p.fileMode())
+}
+
+// mkdirAll creates a directory with the directory mode of the run.
+func (p *AnsiblePlaybook) mkdirAll(path string) error {
+	if err := os.MkdirAll(path, p.dirMode()); err != nil {
+		return err
+	}
+
+	return os.Chmod(path, p.dirMode())
+}
+
+// tempDir creates a new directory in the run directory, or in the default
+// directory for temporary files outside of a run.
+func (p *AnsiblePlaybook) tempDir(pattern string) (string, error) {
+	dir, err := os.MkdirTemp(p.tmpdir, pattern)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.Chmod(dir, p.dirMode()); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+
+	return dir, nil
+}
+
+// tempFile writes the content to a new file in the run directory, or in the
+// default directory for temporary files outside of a run.
+func (p *AnsiblePlaybook) tempFile(pattern string, content []byte) (string, error) {
+	tmpfile, err := os.CreateTemp(p.tmpdir, pattern)
+	if err != nil {
+		return "", err
+	}
+
+	if err := tmpfile.Chmod(p.fileMode()); err != nil {
+		tmpfile.Close()
+		os.Remove(tmpfile.Name())
+		return "", err
+	}
+
+	if _, err := tmpfile.Write(content); err != nil {
+		tmpfile.Close()
+		os.Remove(tmpfile.Name())
+		return "", err
+	}
+
+	if err := tmpfile.Close(); err != nil {
+		os.Remove(tmpfile.Name())
+		return "", err
+	}
+
+	return tmpfile.Name(), nil
+}
@@ -0,0 +1,49 @@
+package ansible
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestFileModes tests the files and directories of a run get the configured
+// permissions.
+func TestFileModes(t *testing.T) {
+	for _, test := range []struct {
+		config   Config
+		fileMode os.FileMode
+		dirMode  os.FileMode
+	}{
+		{Config{}, 0o600, 0o700},
+		{Config{FileMode: 0o640, DirMode: 0o750}, 0o640, 0o750},
+	} {
+		playbook := &AnsiblePlaybook{Config: test.config}
+
+		dir, err := playbook.runDir()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		file, err := playbook.tempFile("inventory*.yml", []byte("all:\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if filepath.Dir(file) != dir {
+			t.Errorf("Expected %s to be created in the run directory %s", file, dir)
+		}
+
+		for path, expected := range map[string]os.FileMode{dir: test.dirMode, file: test.fileMode} {
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if info.Mode().Perm() != expected {
+				t.Errorf("Expected mode %#o for %s, got %#o", expected, path, info.Mode().Perm())
+			}
+		}
+
+		playbook.cleanup()
+	}
+}
@@ -0,0 +1,171 @@
+package ansible
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+const downloadManifestFile = "manifest.json"
+
+// collectionTarballPattern matches the file names of collection tarballs,
+// i.e. namespace-name-version.tar.gz.
+var collectionTarballPattern = regexp.MustCompile(`^([a-z_][a-z0-9_]*)-([a-z_][a-z0-9_]*)-(.+)\.tar\.gz$`)
+
+// DownloadManifest lists the collection tarballs of a download directory.
+type DownloadManifest struct {
+	Collections []DownloadedCollection `json:"collections"`
+}
+
+// DownloadedCollection is a downloaded collection tarball.
+type DownloadedCollection struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	File    string `json:"file"`
+	SHA256  string `json:"sha256"`
+}
+
+// Download fetches the collections of the galaxy file including their
+// dependencies as tarballs into the directory with `ansible-galaxy
+// collection download` and writes a manifest.json of the downloaded
+// tarballs. The directory can be used as galaxy mirror for offline installs.
+func (p *AnsiblePlaybook) Download(dir string) (*DownloadManifest, error) {
+	if p.Config.GalaxyFile == "" {
+		return nil, ErrGalaxyFileNotFound
+	}
+
+	if err := p.mkdirAll(dir); err != nil {
+		return nil, fmt.Errorf("failed to create download directory: %w", err)
+	}
+
+	download := &AnsiblePlaybook{Config: p.Config, Output: p.Output, options: p.options}
+	defer download.cleanup()
+
+	if len(p.Config.GalaxyServers) > 0 {
+		if err := download.galaxyServerConfig(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := download.runConcurrent(download.runGalaxy(func() *exec.Cmd {
+		return download.galaxyDownloadCommand(dir)
+	})); err != nil {
+		return nil, err
+	}
+
+	manifest, err := readDownloadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode download manifest: %w", err)
+	}
+
+	if err := p.writeFile(filepath.Join(dir, downloadManifestFile), append(content, '\n')); err != nil {
+		return nil, fmt.Errorf("failed to write download manifest: %w", err)
+	}
+
+	return manifest, nil
+}
+
+func (p *AnsiblePlaybook) galaxyDownloadCommand(dir string) *exec.Cmd {
+	args := []string{
+		"collection",
+		"download",
+		"--requirements-file",
+		p.Config.GalaxyFile,
+		"--download-path",
+		dir,
+	}
+
+	if p.Config.GalaxyAPIServerURL != "" {
+		args = append(args, "--server", p.Config.GalaxyAPIServerURL)
+	}
+
+	if p.Config.GalaxyAPIKey != "" {
+		args = append(args, "--api-key", p.Config.GalaxyAPIKey)
+	}
+
+	if p.Config.GalaxyIgnoreCerts {
+		args = append(args, "--ignore-certs")
+	}
+
+	if p.Config.GalaxyTimeout != 0 {
+		args = append(args, "--timeout", strconv.Itoa(p.Config.GalaxyTimeout))
+	}
+
+	if p.Config.GalaxyNoDeps {
+		args = append(args, "--no-deps")
+	}
+
+	if p.Config.GalaxyPre {
+		args = append(args, "--pre")
+	}
+
+	args = append(args, p.verboseArgs()...)
+
+	return exec.Command(
+		p.binary("ansible-galaxy"),
+		args...,
+	)
+}
+
+// readDownloadDir returns the collection tarballs of the directory sorted by
+// their file name.
+func readDownloadDir(dir string) (*DownloadManifest, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read download directory: %w", err)
+	}
+
+	manifest := &DownloadManifest{Collections: []DownloadedCollection{}}
+	for _, entry := range entries {
+		match := collectionTarballPattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		sum, err := fileSHA256(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		manifest.Collections = append(manifest.Collections, DownloadedCollection{
+			Name:    match[1] + "." + match[2],
+			Version: match[3],
+			File:    entry.Name(),
+			SHA256:  sum,
+		})
+	}
+
+	sort.Slice(manifest.Collections, func(i, j int) bool {
+		return manifest.Collections[i].File < manifest.Collections[j].File
+	})
+
+	return manifest, nil
+}
+
+func fileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,91 @@
+package ansible
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestDownload tests that the collections are downloaded into the directory
+// and listed in the manifest.
+func TestDownload(t *testing.T) {
+	bin := t.TempDir()
+	log := filepath.Join(bin, "calls.log")
+
+	galaxy := `#!/bin/sh
+echo "$@" >> ` + log + `
+while [ $# -gt 0 ]; do
+  case "$1" in
+    --download-path) dir="$2"; shift;;
+  esac
+  shift
+done
+echo general > "$dir/community-general-8.3.0.tar.gz"
+echo docker > "$dir/community-docker-3.4.0.tar.gz"
+echo "collections: []" > "$dir/requirements.yml"
+`
+	if err := os.WriteFile(filepath.Join(bin, "ansible-galaxy"), []byte(galaxy), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	requirements := filepath.Join(bin, "requirements.yml")
+	if err := os.WriteFile(requirements, []byte("collections:\n  - community.general\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := filepath.Join(t.TempDir(), "download")
+
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir:    bin,
+			GalaxyFile:       requirements,
+			GalaxyNoDeps:     true,
+			SkipVersionCheck: true,
+		},
+	}
+
+	manifest, err := playbook.Download(dir)
+	if err != nil {
+		t.Fatalf("Download failed: %v", err)
+	}
+
+	calls, _ := os.ReadFile(log)
+	expected := "collection download --requirements-file " + requirements + " --download-path " + dir + " --no-deps"
+	if !strings.HasPrefix(string(calls), expected) {
+		t.Errorf("Expected call %q, got %q", expected, calls)
+	}
+
+	if len(manifest.Collections) != 2 {
+		t.Fatalf("Expected 2 collections, got %+v", manifest.Collections)
+	}
+
+	docker := manifest.Collections[0]
+	if docker.Name != "community.docker" || docker.Version != "3.4.0" || docker.File != "community-docker-3.4.0.tar.gz" {
+		t.Errorf("Unexpected collection %+v", docker)
+	}
+
+	if docker.SHA256 != "00d151e7d392e68e2c756a6fc42640006ddc0a98d37dba3f90a7b73f63188bbd" {
+		t.Errorf("Unexpected checksum %q", docker.SHA256)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "manifest.json"))
+	if err != nil {
+		t.Fatalf("manifest.json should be written: %v", err)
+	}
+
+	var written DownloadManifest
+	if err := json.Unmarshal(content, &written); err != nil || len(written.Collections) != 2 {
+		t.Errorf("Unexpected manifest %s: %v", content, err)
+	}
+}
+
+// TestDownloadWithoutGalaxyFile tests that a galaxy file is required.
+func TestDownloadWithoutGalaxyFile(t *testing.T) {
+	playbook := &AnsiblePlaybook{}
+
+	if _, err := playbook.Download(t.TempDir()); err != ErrGalaxyFileNotFound {
+		t.Errorf("Expected ErrGalaxyFileNotFound, got %v", err)
+	}
+}
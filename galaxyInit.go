@@ -0,0 +1,59 @@
+package ansible
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// GalaxyInit configures the scaffolding of a role or a collection.
+type GalaxyInit struct {
+	Namespace string
+	Name      string
+	InitPath  string
+	Skeleton  string
+	Force     bool
+}
+
+// RoleInit scaffolds a role with `ansible-galaxy role init`.
+func (p *AnsiblePlaybook) RoleInit(init GalaxyInit) error {
+	if init.Name == "" {
+		return errors.New("role init requires a name")
+	}
+
+	return p.run(p.galaxyInitCommand("role", init.Name, "--role-skeleton", init))
+}
+
+// CollectionInit scaffolds a collection with `ansible-galaxy collection init`.
+func (p *AnsiblePlaybook) CollectionInit(init GalaxyInit) error {
+	if init.Namespace == "" || init.Name == "" {
+		return errors.New("collection init requires a namespace and a name")
+	}
+
+	return p.run(p.galaxyInitCommand("collection", init.Namespace+"."+init.Name, "--collection-skeleton", init))
+}
+
+func (p *AnsiblePlaybook) galaxyInitCommand(kind, name, skeletonFlag string, init GalaxyInit) *exec.Cmd {
+	args := []string{
+		kind,
+		"init",
+	}
+
+	if init.InitPath != "" {
+		args = append(args, "--init-path", init.InitPath)
+	}
+
+	if init.Skeleton != "" {
+		args = append(args, skeletonFlag, init.Skeleton)
+	}
+
+	if init.Force {
+		args = append(args, "--force")
+	}
+
+	args = append(args, name)
+
+	return exec.Command(
+		p.binary("ansible-galaxy"),
+		args...,
+	)
+}
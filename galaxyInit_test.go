@@ -0,0 +1,31 @@
+package ansible
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestGalaxyInitCommand tests the arguments of role and collection init.
+func TestGalaxyInitCommand(t *testing.T) {
+	playbook := &AnsiblePlaybook{}
+
+	init := GalaxyInit{
+		Namespace: "arillso",
+		Name:      "system",
+		InitPath:  "collections",
+		Skeleton:  "skeletons/collection",
+		Force:     true,
+	}
+
+	cmd := playbook.galaxyInitCommand("collection", "arillso.system", "--collection-skeleton", init)
+
+	expected := "ansible-galaxy collection init --init-path collections --collection-skeleton skeletons/collection --force arillso.system"
+	if strings.Join(cmd.Args, " ") != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, strings.Join(cmd.Args, " "))
+	}
+
+	// Assert that incomplete names are rejected.
+	if err := playbook.CollectionInit(GalaxyInit{Name: "system"}); err == nil {
+		t.Error("CollectionInit should require a namespace")
+	}
+}
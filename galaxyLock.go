@@ -0,0 +1,347 @@
+package ansible
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const lockfileHeader = "# Generated by go.ansible from %s, do not edit.\n"
+
+var commitPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// Lock resolves the galaxy file to the exact versions of all roles and
+// collections including their dependencies and writes them to the lockfile.
+// Requirements from git are locked to a commit. The lockfile is a galaxy
+// requirements file which can be installed with InstallLocked.
+func (p *AnsiblePlaybook) Lock(lockfile string) error {
+	if p.Config.GalaxyFile == "" {
+		return ErrGalaxyFileNotFound
+	}
+
+	reqs, err := readGalaxyRequirements(p.Config.GalaxyFile)
+	if err != nil {
+		return err
+	}
+
+	resolve := &AnsiblePlaybook{Config: p.Config, Output: p.Output, options: p.options}
+	defer resolve.cleanup()
+
+	dir, err := resolve.runDir()
+	if err != nil {
+		return err
+	}
+
+	resolve.Config.GalaxyCollectionsPath = filepath.Join(dir, "collections")
+	resolve.Config.GalaxyRolesPath = filepath.Join(dir, "roles")
+	resolve.Config.GalaxyForce = true
+
+	if len(p.Config.GalaxyServers) > 0 {
+		if err := resolve.galaxyServerConfig(); err != nil {
+			return err
+		}
+	}
+
+	if err := resolve.runConcurrent(
+		resolve.runGalaxy(resolve.galaxyRoleCommand),
+		resolve.runGalaxy(resolve.galaxyCollectionCommand),
+	); err != nil {
+		return err
+	}
+
+	locked := &galaxyRequirements{}
+
+	locked.Roles, err = resolve.lockRoles(reqs.Roles)
+	if err != nil {
+		return err
+	}
+
+	locked.Collections, err = resolve.lockCollections(reqs.Collections)
+	if err != nil {
+		return err
+	}
+
+	content, err := yaml.Marshal(locked)
+	if err != nil {
+		return fmt.Errorf("failed to encode lockfile: %w", err)
+	}
+
+	header := fmt.Sprintf(lockfileHeader, filepath.Base(p.Config.GalaxyFile))
+	if err := p.writeFile(lockfile, append([]byte(header), content...)); err != nil {
+		return fmt.Errorf("failed to write lockfile: %w", err)
+	}
+
+	return nil
+}
+
+// InstallLocked installs the roles and collections of a lockfile without
+// resolving dependencies. Every entry has to pin an exact version or commit.
+func (p *AnsiblePlaybook) InstallLocked(lockfile string) error {
+	reqs, err := readGalaxyRequirements(lockfile)
+	if err != nil {
+		return err
+	}
+
+	for _, req := range append(append([]galaxyRequirement{}, reqs.Roles...), reqs.Collections...) {
+		if versioned(req) && !exactVersion(req.Version) {
+			return fmt.Errorf("lockfile %s does not pin an exact version for %s", lockfile, req.name())
+		}
+	}
+
+	install := &AnsiblePlaybook{Config: p.Config, Output: p.Output, options: p.options}
+	defer install.cleanup()
+
+	install.Config.GalaxyFile = lockfile
+	install.Config.GalaxyNoDeps = true
+
+	if len(p.Config.GalaxyServers) > 0 {
+		if err := install.galaxyServerConfig(); err != nil {
+			return err
+		}
+	}
+
+	var tasks []func(output io.Writer) error
+	if len(reqs.Roles) > 0 {
+		tasks = append(tasks, install.runGalaxy(install.galaxyRoleCommand))
+	}
+
+	if len(reqs.Collections) > 0 {
+		tasks = append(tasks, install.runGalaxy(func() *exec.Cmd {
+			cmd := install.galaxyCollectionCommand()
+			cmd.Args = append(cmd.Args, "--no-deps")
+			return cmd
+		}))
+	}
+
+	return install.runConcurrent(tasks...)
+}
+
+// lockRoles returns the installed roles including their dependencies with
+// their installed versions. Roles from git are locked to the commit.
+func (p *AnsiblePlaybook) lockRoles(reqs []galaxyRequirement) ([]galaxyRequirement, error) {
+	entries, err := os.ReadDir(p.Config.GalaxyRolesPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read installed roles: %w", err)
+	}
+
+	var locked []galaxyRequirement
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		content, err := os.ReadFile(filepath.Join(p.Config.GalaxyRolesPath, entry.Name(), "meta", ".galaxy_install_info"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read install info of role %s: %w", entry.Name(), err)
+		}
+
+		var info struct {
+			Version string `yaml:"version"`
+		}
+
+		if err := yaml.Unmarshal(content, &info); err != nil {
+			return nil, fmt.Errorf("failed to parse install info of role %s: %w", entry.Name(), err)
+		}
+
+		role := galaxyRequirement{Name: entry.Name(), Version: info.Version}
+
+		for _, req := range reqs {
+			if roleName(req) != entry.Name() || req.Src == "" || req.Src == entry.Name() {
+				continue
+			}
+
+			role.Src = req.Src
+			role.Scm = req.Scm
+
+			if gitRequirement(req) {
+				url, ref := gitSource(req.Src, req.Version)
+				if role.Version, err = p.gitCommit(url, ref); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		locked = append(locked, role)
+	}
+
+	return locked, nil
+}
+
+// lockCollections returns the installed collections including their
+// dependencies with the versions of their manifests. Collections which are
+// not installed from a galaxy server keep their source, git sources are
+// locked to the commit.
+func (p *AnsiblePlaybook) lockCollections(reqs []galaxyRequirement) ([]galaxyRequirement, error) {
+	var (
+		locked  []galaxyRequirement
+		sources []galaxyRequirement
+	)
+
+	for _, req := range reqs {
+		if req.Type == "" || req.Type == "galaxy" {
+			continue
+		}
+
+		if gitRequirement(req) {
+			url, ref := gitSource(req.Name, req.Version)
+
+			commit, err := p.gitCommit(url, ref)
+			if err != nil {
+				return nil, err
+			}
+
+			req.Name = strings.SplitN(req.Name, ",", 2)[0]
+			req.Version = commit
+		}
+
+		sources = append(sources, req)
+	}
+
+	root := filepath.Join(p.Config.GalaxyCollectionsPath, "ansible_collections")
+
+	manifests, err := filepath.Glob(filepath.Join(root, "*", "*", "MANIFEST.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Since ansible-core 2.13 collections installed from a galaxy server have
+	// an install info directory, which identifies the collections installed
+	// from other sources.
+	infos, _ := filepath.Glob(filepath.Join(root, "*.info", "GALAXY.yml"))
+	fromGalaxy := map[string]bool{}
+	for _, info := range infos {
+		name := filepath.Base(filepath.Dir(info))
+		fromGalaxy[name[:strings.LastIndexByte(name, '-')]] = true
+	}
+
+	for _, manifest := range manifests {
+		content, err := os.ReadFile(manifest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read collection manifest: %w", err)
+		}
+
+		var info struct {
+			CollectionInfo struct {
+				Namespace string `json:"namespace"`
+				Name      string `json:"name"`
+				Version   string `json:"version"`
+			} `json:"collection_info"`
+		}
+
+		if err := json.Unmarshal(content, &info); err != nil {
+			return nil, fmt.Errorf("failed to parse collection manifest %s: %w", manifest, err)
+		}
+
+		name := info.CollectionInfo.Namespace + "." + info.CollectionInfo.Name
+		if len(infos) > 0 && !fromGalaxy[name] {
+			continue
+		}
+
+		locked = append(locked, galaxyRequirement{
+			Name:    name,
+			Version: info.CollectionInfo.Version,
+		})
+	}
+
+	sort.Slice(locked, func(i, j int) bool {
+		return locked[i].Name < locked[j].Name
+	})
+
+	return append(locked, sources...), nil
+}
+
+// versioned reports whether a requirement is installed from a galaxy server
+// or git and therefore has to be locked to a version or commit. Archives and
+// local paths are locked by their source.
+func versioned(req galaxyRequirement) bool {
+	if gitRequirement(req) {
+		return true
+	}
+
+	if req.Type != "" && req.Type != "galaxy" {
+		return false
+	}
+
+	return !strings.ContainsAny(req.Src, "/:") && !strings.ContainsAny(req.Name, "/:")
+}
+
+// roleName returns the name of the directory a role is installed to.
+func roleName(req galaxyRequirement) string {
+	if req.Name != "" {
+		return req.Name
+	}
+
+	src := strings.TrimSuffix(strings.SplitN(req.Src, ",", 2)[0], ".git")
+	if strings.Contains(src, "/") {
+		return src[strings.LastIndexByte(src, '/')+1:]
+	}
+
+	return src
+}
+
+// gitRequirement reports whether a role or collection is installed from git.
+func gitRequirement(req galaxyRequirement) bool {
+	return req.Type == "git" ||
+		req.Scm == "git" ||
+		strings.HasPrefix(req.Src, "git+") ||
+		strings.HasPrefix(req.Name, "git+")
+}
+
+// gitSource returns the repository URL and the ref of a git source, which
+// can contain the ref after a comma.
+func gitSource(source, version string) (string, string) {
+	parts := strings.SplitN(strings.TrimPrefix(source, "git+"), ",", 2)
+	if len(parts) == 2 && version == "" {
+		version = parts[1]
+	}
+
+	return parts[0], version
+}
+
+// gitCommit resolves a ref of a repository to its commit.
+func (p *AnsiblePlaybook) gitCommit(url, ref string) (string, error) {
+	if commitPattern.MatchString(ref) {
+		return ref, nil
+	}
+
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	var output bytes.Buffer
+	if err := p.runOutput(exec.Command("git", "ls-remote", url, ref), &output); err != nil {
+		return "", fmt.Errorf("failed to resolve %s of %s: %w", ref, url, err)
+	}
+
+	// Annotated tags are resolved to the commit they point to.
+	commit := ""
+	for _, line := range strings.Split(output.String(), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || !commitPattern.MatchString(fields[0]) {
+			continue
+		}
+
+		if commit == "" || strings.HasSuffix(fields[1], "^{}") {
+			commit = fields[0]
+		}
+	}
+
+	if commit == "" {
+		return "", fmt.Errorf("failed to resolve %s of %s", ref, url)
+	}
+
+	return commit, nil
+}
@@ -0,0 +1,120 @@
+package ansible
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestLock tests the lockfile pins the installed versions including
+// dependencies and git commits, and is installed without resolving
+// dependencies.
+func TestLock(t *testing.T) {
+	bin := t.TempDir()
+	log := filepath.Join(bin, "calls.log")
+
+	galaxy := `#!/bin/sh
+echo "$@" >> ` + log + `
+kind="$1"
+while [ $# -gt 0 ]; do
+  case "$1" in
+    --roles-path) roles="$2"; shift;;
+    --collections-path) collections="$2"; shift;;
+  esac
+  shift
+done
+[ -z "$roles$collections" ] && exit 0
+if [ "$kind" = "role" ]; then
+  mkdir -p "$roles/geerlingguy.docker/meta" "$roles/myrole/meta"
+  echo "version: 6.1.0" > "$roles/geerlingguy.docker/meta/.galaxy_install_info"
+  echo "version: main" > "$roles/myrole/meta/.galaxy_install_info"
+else
+  for c in general:7.5.0 docker:3.4.0; do
+    mkdir -p "$collections/ansible_collections/community/${c%%:*}"
+    echo "{\"collection_info\": {\"namespace\": \"community\", \"name\": \"${c%%:*}\", \"version\": \"${c#*:}\"}}" > "$collections/ansible_collections/community/${c%%:*}/MANIFEST.json"
+  done
+fi
+`
+	git := "#!/bin/sh\necho \"0123456789abcdef0123456789abcdef01234567\trefs/heads/$3\"\n"
+
+	for name, script := range map[string]string{"ansible-galaxy": galaxy, "git": git} {
+		if err := os.WriteFile(filepath.Join(bin, name), []byte(script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	requirements := filepath.Join(bin, "requirements.yml")
+	content := `---
+roles:
+  - name: geerlingguy.docker
+  - src: git+https://example.com/myrole.git
+    version: main
+collections:
+  - name: community.general
+    version: ">=7.0.0"
+`
+	if err := os.WriteFile(requirements, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir: bin,
+			GalaxyFile:    requirements,
+		},
+		Output: &bytes.Buffer{},
+	}
+
+	lockfile := filepath.Join(bin, "requirements.lock.yml")
+	if err := playbook.Lock(lockfile); err != nil {
+		t.Fatalf("Lock should execute without error, but received: %v", err)
+	}
+
+	locked, err := readGalaxyRequirements(lockfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var versions []string
+	for _, req := range append(locked.Roles, locked.Collections...) {
+		versions = append(versions, req.name()+"@"+req.Version)
+	}
+
+	expected := "geerlingguy.docker@6.1.0 myrole@0123456789abcdef0123456789abcdef01234567 community.docker@3.4.0 community.general@7.5.0"
+	if strings.Join(versions, " ") != expected {
+		t.Errorf("Expected locked versions '%s', got '%s'", expected, strings.Join(versions, " "))
+	}
+
+	if locked.Roles[1].Src != "git+https://example.com/myrole.git" {
+		t.Errorf("Expected the git source to be kept, got %+v", locked.Roles[1])
+	}
+
+	os.Remove(log)
+
+	if err := playbook.InstallLocked(lockfile); err != nil {
+		t.Fatalf("InstallLocked should execute without error, but received: %v", err)
+	}
+
+	calls, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, call := range strings.Split(strings.TrimSpace(string(calls)), "\n") {
+		if !strings.Contains(call, lockfile) || !strings.Contains(call, "--no-deps") {
+			t.Errorf("Expected the lockfile to be installed without dependencies, got '%s'", call)
+		}
+	}
+
+	if err := os.WriteFile(lockfile, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := playbook.InstallLocked(lockfile); err == nil {
+		t.Error("Expected an error for a lockfile with version ranges")
+	}
+}
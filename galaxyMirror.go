@@ -0,0 +1,352 @@
+package ansible
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type galaxyRequirement struct {
+	Name    string `yaml:"name,omitempty"`
+	Src     string `yaml:"src,omitempty"`
+	Version string `yaml:"version,omitempty"`
+	Type    string `yaml:"type,omitempty"`
+	Scm     string `yaml:"scm,omitempty"`
+}
+
+// UnmarshalYAML accepts both the short string form and the mapping form of
+// a requirement entry.
+func (r *galaxyRequirement) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		r.Name = value.Value
+		return nil
+	}
+
+	type plain galaxyRequirement
+	return value.Decode((*plain)(r))
+}
+
+type galaxyRequirements struct {
+	Roles       []galaxyRequirement `yaml:"roles,omitempty"`
+	Collections []galaxyRequirement `yaml:"collections,omitempty"`
+}
+
+func readGalaxyRequirements(path string) (*galaxyRequirements, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read galaxy file: %w", err)
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(content, &node); err != nil {
+		return nil, fmt.Errorf("failed to parse galaxy file: %w", err)
+	}
+
+	reqs := &galaxyRequirements{}
+	if len(node.Content) == 0 {
+		return reqs, nil
+	}
+
+	// The legacy format is a plain list of roles.
+	if node.Content[0].Kind == yaml.SequenceNode {
+		if err := node.Content[0].Decode(&reqs.Roles); err != nil {
+			return nil, fmt.Errorf("failed to parse galaxy file: %w", err)
+		}
+
+		return reqs, nil
+	}
+
+	if err := node.Content[0].Decode(reqs); err != nil {
+		return nil, fmt.Errorf("failed to parse galaxy file: %w", err)
+	}
+
+	return reqs, nil
+}
+
+func (r galaxyRequirement) name() string {
+	if r.Name != "" {
+		return r.Name
+	}
+
+	return r.Src
+}
+
+func (r galaxyRequirement) String() string {
+	if r.Version == "" || r.Version == "*" {
+		return r.name()
+	}
+
+	return fmt.Sprintf("%s (%s)", r.name(), r.Version)
+}
+
+// exactVersion reports whether the version pins a single release instead of
+// a range.
+func exactVersion(version string) bool {
+	if version == "" || version == "*" {
+		return false
+	}
+
+	return !strings.ContainsAny(version, "<>=!,*")
+}
+
+func (p *AnsiblePlaybook) galaxyMirror() (string, error) {
+	reqs, err := readGalaxyRequirements(p.Config.GalaxyFile)
+	if err != nil {
+		return "", err
+	}
+
+	tmpdir, err := p.tempDir("galaxyMirror")
+	if err != nil {
+		return "", fmt.Errorf("failed to create galaxy mirror directory: %w", err)
+	}
+
+	mirror := p.Config.GalaxyMirror
+	if info, err := os.Stat(mirror); err != nil {
+		os.RemoveAll(tmpdir)
+		return "", fmt.Errorf("failed to open galaxy mirror: %w", err)
+	} else if !info.IsDir() {
+		mirror = filepath.Join(tmpdir, "mirror")
+		if err := p.extractTarball(p.Config.GalaxyMirror, mirror); err != nil {
+			os.RemoveAll(tmpdir)
+			return "", err
+		}
+	}
+
+	resolved := galaxyRequirements{}
+	missing := []string{}
+
+	for _, role := range reqs.Roles {
+		path, ok := findMirrorRole(mirror, role)
+		if !ok {
+			missing = append(missing, "role "+role.String())
+			continue
+		}
+
+		resolved.Roles = append(resolved.Roles, galaxyRequirement{
+			Name: role.name(),
+			Src:  path,
+		})
+	}
+
+	for _, collection := range reqs.Collections {
+		path, kind, ok := findMirrorCollection(mirror, collection)
+		if !ok {
+			missing = append(missing, "collection "+collection.String())
+			continue
+		}
+
+		resolved.Collections = append(resolved.Collections, galaxyRequirement{
+			Name: path,
+			Type: kind,
+		})
+	}
+
+	if len(missing) > 0 {
+		os.RemoveAll(tmpdir)
+		return "", fmt.Errorf(
+			"failed to satisfy galaxy requirements from mirror %s: %s",
+			p.Config.GalaxyMirror,
+			strings.Join(missing, ", "),
+		)
+	}
+
+	content, err := yaml.Marshal(resolved)
+	if err != nil {
+		os.RemoveAll(tmpdir)
+		return "", fmt.Errorf("failed to encode galaxy mirror requirements: %w", err)
+	}
+
+	file := filepath.Join(tmpdir, "requirements.yml")
+	if err := p.writeFile(file, content); err != nil {
+		os.RemoveAll(tmpdir)
+		return "", fmt.Errorf("failed to write galaxy mirror requirements: %w", err)
+	}
+
+	p.Config.GalaxyFile = file
+	return tmpdir, nil
+}
+
+func findMirrorRole(mirror string, role galaxyRequirement) (string, bool) {
+	name := role.name()
+
+	constraint, err := mirrorConstraint(role.Version)
+	if err != nil && !exactVersion(role.Version) {
+		return "", false
+	}
+
+	// Archives without a version only satisfy exact versions or roles
+	// without a version, not ranges.
+	candidates := []string{}
+	if exactVersion(role.Version) {
+		candidates = append(candidates, fmt.Sprintf("%s-%s.tar.gz", name, role.Version), name+".tar.gz")
+	} else if constraint == nil {
+		candidates = append(candidates, name+".tar.gz")
+	}
+
+	for _, candidate := range candidates {
+		path := filepath.Join(mirror, candidate)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, true
+		}
+	}
+
+	if exactVersion(role.Version) {
+		return "", false
+	}
+
+	return latestMatch(mirror, name+"-", constraint)
+}
+
+func findMirrorCollection(mirror string, collection galaxyRequirement) (string, string, bool) {
+	name := collection.name()
+
+	parts := strings.SplitN(name, ".", 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+
+	if exactVersion(collection.Version) {
+		path := filepath.Join(mirror, fmt.Sprintf("%s-%s-%s.tar.gz", parts[0], parts[1], collection.Version))
+		if _, err := os.Stat(path); err == nil {
+			return path, "file", true
+		}
+
+		return "", "", false
+	}
+
+	constraint, err := mirrorConstraint(collection.Version)
+	if err != nil {
+		return "", "", false
+	}
+
+	if path, ok := latestMatch(mirror, fmt.Sprintf("%s-%s-", parts[0], parts[1]), constraint); ok {
+		return path, "file", true
+	}
+
+	dir := filepath.Join(mirror, parts[0], parts[1])
+	content, err := os.ReadFile(filepath.Join(dir, "galaxy.yml"))
+	if err != nil {
+		return "", "", false
+	}
+
+	if constraint != nil {
+		var info struct {
+			Version string `yaml:"version"`
+		}
+
+		if err := yaml.Unmarshal(content, &info); err != nil {
+			return "", "", false
+		}
+
+		v, err := parseVersion(info.Version)
+		if err != nil || !constraint.match(v) {
+			return "", "", false
+		}
+	}
+
+	return dir, "dir", true
+}
+
+// mirrorConstraint parses the version range of a requirement, nil if any
+// version is accepted.
+func mirrorConstraint(version string) (versionConstraint, error) {
+	if version == "" || version == "*" {
+		return nil, nil
+	}
+
+	return parseVersionConstraint(version)
+}
+
+// latestMatch returns the archive <prefix><version>.tar.gz of the mirror with
+// the highest version matching the constraint.
+func latestMatch(mirror, prefix string, constraint versionConstraint) (string, bool) {
+	matches, err := filepath.Glob(filepath.Join(mirror, prefix+"*.tar.gz"))
+	if err != nil {
+		return "", false
+	}
+
+	var (
+		latest  string
+		highest version
+	)
+
+	for _, match := range matches {
+		v, err := parseVersion(strings.TrimSuffix(strings.TrimPrefix(filepath.Base(match), prefix), ".tar.gz"))
+		if err != nil || (constraint != nil && !constraint.match(v)) {
+			continue
+		}
+
+		if latest == "" || v.compare(highest) > 0 {
+			latest, highest = match, v
+		}
+	}
+
+	return latest, latest != ""
+}
+
+func (p *AnsiblePlaybook) extractTarball(src, dest string) error {
+	file, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open galaxy mirror: %w", err)
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if strings.HasSuffix(src, ".gz") || strings.HasSuffix(src, ".tgz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return fmt.Errorf("failed to read galaxy mirror: %w", err)
+		}
+		defer gz.Close()
+
+		reader = gz
+	}
+
+	archive := tar.NewReader(reader)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to read galaxy mirror: %w", err)
+		}
+
+		target := filepath.Join(dest, header.Name)
+		if !strings.HasPrefix(target, filepath.Clean(dest)+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid path %s in galaxy mirror", header.Name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := p.mkdirAll(target); err != nil {
+				return fmt.Errorf("failed to extract galaxy mirror: %w", err)
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), p.dirMode()); err != nil {
+				return fmt.Errorf("failed to extract galaxy mirror: %w", err)
+			}
+
+			out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, p.fileMode())
+			if err != nil {
+				return fmt.Errorf("failed to extract galaxy mirror: %w", err)
+			}
+
+			if _, err := io.Copy(out, archive); err != nil {
+				out.Close()
+				return fmt.Errorf("failed to extract galaxy mirror: %w", err)
+			}
+
+			if err := out.Close(); err != nil {
+				return fmt.Errorf("failed to extract galaxy mirror: %w", err)
+			}
+		}
+	}
+}
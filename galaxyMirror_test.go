@@ -0,0 +1,194 @@
+package ansible
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestGalaxyMirror tests that requirements are resolved from a local mirror.
+func TestGalaxyMirror(t *testing.T) {
+	dir := t.TempDir()
+
+	// Prepare a mirror containing one role and one collection archive.
+	mirror := filepath.Join(dir, "mirror")
+	if err := os.MkdirAll(mirror, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"geerlingguy.java.tar.gz", "community-general-7.5.0.tar.gz"} {
+		if err := os.WriteFile(filepath.Join(mirror, name), []byte{}, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	requirements := filepath.Join(dir, "requirements.yml")
+	content := "roles:\n  - src: geerlingguy.java\ncollections:\n  - name: community.general\n    version: 7.5.0\n"
+	if err := os.WriteFile(requirements, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			GalaxyFile:   requirements,
+			GalaxyMirror: mirror,
+		},
+	}
+
+	tmpdir, err := playbook.galaxyMirror()
+	if err != nil {
+		t.Fatalf("galaxyMirror() failed: %s", err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	// Assert that the generated requirements point to the mirror.
+	reqs, err := readGalaxyRequirements(playbook.Config.GalaxyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(reqs.Roles) != 1 || reqs.Roles[0].Src != filepath.Join(mirror, "geerlingguy.java.tar.gz") {
+		t.Errorf("Unexpected roles: %+v", reqs.Roles)
+	}
+
+	if len(reqs.Collections) != 1 || reqs.Collections[0].Type != "file" {
+		t.Errorf("Unexpected collections: %+v", reqs.Collections)
+	}
+}
+
+// TestGalaxyMirrorExec tests that the requirements of the mirror are only
+// used for the run, so the playbook can be executed again.
+func TestGalaxyMirrorExec(t *testing.T) {
+	dir := t.TempDir()
+
+	bin := filepath.Join(dir, "bin")
+	if err := os.MkdirAll(bin, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"ansible-galaxy", "ansible-playbook"} {
+		if err := os.WriteFile(filepath.Join(bin, name), []byte("#!/bin/sh\n"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "geerlingguy.java.tar.gz"), []byte{}, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	requirements := filepath.Join(dir, "requirements.yml")
+	if err := os.WriteFile(requirements, []byte("roles:\n  - src: geerlingguy.java\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir:    bin,
+			GalaxyFile:       requirements,
+			GalaxyMirror:     dir,
+			Inventories:      []string{"tests/inventories/production"},
+			Playbooks:        []string{"tests/test.yml"},
+			SkipVersionCheck: true,
+		},
+		Output: &bytes.Buffer{},
+	}
+
+	for run := 1; run <= 2; run++ {
+		if err := playbook.Exec(); err != nil {
+			t.Fatalf("Run %d should execute without error, but received: %v", run, err)
+		}
+
+		if playbook.Config.GalaxyFile != requirements {
+			t.Fatalf("Expected the galaxy file to be restored, got %s", playbook.Config.GalaxyFile)
+		}
+	}
+}
+
+// TestGalaxyMirrorMissing tests that unsatisfied requirements are reported.
+func TestGalaxyMirrorMissing(t *testing.T) {
+	dir := t.TempDir()
+
+	requirements := filepath.Join(dir, "requirements.yml")
+	content := "collections:\n  - community.general\n  - name: ansible.posix\n    version: 1.5.4\n"
+	if err := os.WriteFile(requirements, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			GalaxyFile:   requirements,
+			GalaxyMirror: dir,
+		},
+	}
+
+	_, err := playbook.galaxyMirror()
+	if err == nil {
+		t.Fatal("galaxyMirror() should fail for missing requirements")
+	}
+
+	// Assert that every missing requirement is listed in the error.
+	for _, name := range []string{"community.general", "ansible.posix (1.5.4)"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("Expected error to mention '%s', got '%s'", name, err)
+		}
+	}
+}
+
+// TestGalaxyMirrorVersions tests that the highest version of the mirror
+// matching the version range of a requirement is selected.
+func TestGalaxyMirrorVersions(t *testing.T) {
+	mirror := t.TempDir()
+
+	for _, name := range []string{
+		"community-general-1.5.0.tar.gz",
+		"community-general-2.0.0.tar.gz",
+		"community-general-9.5.0.tar.gz",
+		"community-general-10.1.0.tar.gz",
+		"geerlingguy.java-1.9.0.tar.gz",
+		"geerlingguy.java-1.10.0.tar.gz",
+		"geerlingguy.java-2.0.0.tar.gz",
+		"geerlingguy.java.tar.gz",
+	} {
+		if err := os.WriteFile(filepath.Join(mirror, name), []byte{}, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	collections := []struct {
+		version  string
+		expected string
+	}{
+		{"", "community-general-10.1.0.tar.gz"},
+		{"*", "community-general-10.1.0.tar.gz"},
+		{">=1.0,<2.0", "community-general-1.5.0.tar.gz"},
+		{">=2.0.0,<10.0.0", "community-general-9.5.0.tar.gz"},
+		{"!=10.1.0", "community-general-9.5.0.tar.gz"},
+		{">=11.0.0", ""},
+	}
+
+	for _, test := range collections {
+		path, _, ok := findMirrorCollection(mirror, galaxyRequirement{Name: "community.general", Version: test.version})
+		if filepath.Base(path) != test.expected && !(test.expected == "" && !ok) {
+			t.Errorf("Expected %q for collection version %q, got %q", test.expected, test.version, path)
+		}
+	}
+
+	roles := []struct {
+		version  string
+		expected string
+	}{
+		{"", "geerlingguy.java.tar.gz"},
+		{"1.9.0", "geerlingguy.java-1.9.0.tar.gz"},
+		{">=1.0,<2.0", "geerlingguy.java-1.10.0.tar.gz"},
+		{">=3.0", ""},
+	}
+
+	for _, test := range roles {
+		path, ok := findMirrorRole(mirror, galaxyRequirement{Src: "geerlingguy.java", Version: test.version})
+		if filepath.Base(path) != test.expected && !(test.expected == "" && !ok) {
+			t.Errorf("Expected %q for role version %q, got %q", test.expected, test.version, path)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package ansible
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+// Galaxy item types.
+const (
+	GalaxyRole       = "role"
+	GalaxyCollection = "collection"
+)
+
+var (
+	collectionInstalledPattern = regexp.MustCompile(`^(\S+?):(\S+) was installed successfully$`)
+	collectionSkippedPattern   = regexp.MustCompile(`^(?:'(\S+?):(\S+)' is already installed, skipping\.?|Skipping '(\S+?):(\S+)' as it is already installed)$`)
+	roleInstalledPattern       = regexp.MustCompile(`^- (\S+) \((\S*)\) was installed successfully$`)
+	roleSkippedPattern         = regexp.MustCompile(`^(?:\[WARNING\]: )?- (\S+) \((\S*)\) is already installed`)
+)
+
+// GalaxyReport lists the roles and collections installed or skipped by the
+// galaxy commands of a run.
+type GalaxyReport struct {
+	Installed []GalaxyItem `json:"installed,omitempty"`
+	Skipped   []GalaxyItem `json:"skipped,omitempty"`
+}
+
+// GalaxyItem is a role or collection with its version.
+type GalaxyItem struct {
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+// String returns the name and the version of the item.
+func (i GalaxyItem) String() string {
+	if i.Version == "" {
+		return i.Name
+	}
+
+	return i.Name + " " + i.Version
+}
+
+// ParseGalaxyOutput builds a report from the output of ansible-galaxy role
+// install and collection install.
+func ParseGalaxyOutput(output []byte) *GalaxyReport {
+	report := &GalaxyReport{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(ansiEscapePattern.ReplaceAll(output, nil)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if match := collectionInstalledPattern.FindStringSubmatch(line); match != nil {
+			report.Installed = append(report.Installed, GalaxyItem{Type: GalaxyCollection, Name: match[1], Version: match[2]})
+		} else if match := collectionSkippedPattern.FindStringSubmatch(line); match != nil {
+			name, version := match[1]+match[3], match[2]+match[4]
+			report.Skipped = append(report.Skipped, GalaxyItem{Type: GalaxyCollection, Name: name, Version: version})
+		} else if match := roleInstalledPattern.FindStringSubmatch(line); match != nil {
+			report.Installed = append(report.Installed, GalaxyItem{Type: GalaxyRole, Name: match[1], Version: match[2]})
+		} else if match := roleSkippedPattern.FindStringSubmatch(line); match != nil {
+			report.Skipped = append(report.Skipped, GalaxyItem{Type: GalaxyRole, Name: match[1], Version: match[2]})
+		}
+	}
+
+	return report
+}
+
+// addGalaxyReport adds the items of the output of a galaxy command to the
+// report of the run.
+func (p *AnsiblePlaybook) addGalaxyReport(output []byte) {
+	report := ParseGalaxyOutput(output)
+
+	p.galaxyMu.Lock()
+	defer p.galaxyMu.Unlock()
+
+	if p.Galaxy == nil {
+		p.Galaxy = &GalaxyReport{}
+	}
+
+	p.Galaxy.Installed = append(p.Galaxy.Installed, report.Installed...)
+	p.Galaxy.Skipped = append(p.Galaxy.Skipped, report.Skipped...)
+}
@@ -0,0 +1,67 @@
+package ansible
+
+import (
+	"bytes"
+	"os/exec"
+	"reflect"
+	"testing"
+)
+
+// TestParseGalaxyOutput tests that installed and skipped roles and
+// collections are parsed from the galaxy output.
+func TestParseGalaxyOutput(t *testing.T) {
+	output := []byte(`Starting galaxy role install process
+- downloading role 'docker', owned by geerlingguy
+- extracting geerlingguy.docker to /roles/geerlingguy.docker
+- geerlingguy.docker (7.1.0) was installed successfully
+[WARNING]: - geerlingguy.pip (3.0.3) is already installed - use --force to change version to unspecified
+Starting galaxy collection install process
+Installing 'community.general:8.3.0' to '/collections/ansible_collections/community/general'
+community.general:8.3.0 was installed successfully
+'ansible.posix:1.5.4' is already installed, skipping.
+Skipping 'community.docker:3.4.0' as it is already installed
+`)
+
+	report := ParseGalaxyOutput(output)
+
+	installed := []GalaxyItem{
+		{Type: GalaxyRole, Name: "geerlingguy.docker", Version: "7.1.0"},
+		{Type: GalaxyCollection, Name: "community.general", Version: "8.3.0"},
+	}
+
+	if !reflect.DeepEqual(report.Installed, installed) {
+		t.Errorf("Expected installed %v, got %v", installed, report.Installed)
+	}
+
+	skipped := []GalaxyItem{
+		{Type: GalaxyRole, Name: "geerlingguy.pip", Version: "3.0.3"},
+		{Type: GalaxyCollection, Name: "ansible.posix", Version: "1.5.4"},
+		{Type: GalaxyCollection, Name: "community.docker", Version: "3.4.0"},
+	}
+
+	if !reflect.DeepEqual(report.Skipped, skipped) {
+		t.Errorf("Expected skipped %v, got %v", skipped, report.Skipped)
+	}
+
+	if got := report.Installed[1].String(); got != "community.general 8.3.0" {
+		t.Errorf("Expected 'community.general 8.3.0', got %q", got)
+	}
+}
+
+// TestRunGalaxyReport tests that the output of successful galaxy commands is
+// added to the report of the run.
+func TestRunGalaxyReport(t *testing.T) {
+	playbook := &AnsiblePlaybook{}
+
+	var output bytes.Buffer
+	err := playbook.runGalaxy(func() *exec.Cmd {
+		return exec.Command("sh", "-c", "echo 'community.general:8.3.0 was installed successfully'")
+	})(&output)
+	if err != nil {
+		t.Fatalf("runGalaxy failed: %v", err)
+	}
+
+	if playbook.Galaxy == nil || len(playbook.Galaxy.Installed) != 1 {
+		t.Fatalf("Expected one installed collection, got %+v", playbook.Galaxy)
+	}
+}
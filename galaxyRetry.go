@@ -0,0 +1,52 @@
+package ansible
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"time"
+)
+
+const defaultGalaxyRetryDelay = 2 * time.Second
+
+var transientGalaxyError = regexp.MustCompile(
+	`(?i)(HTTP (Error|Code:?) 5\d\d|timed out|timeout|connection (reset|refused|aborted)|temporary failure in name resolution|unknown error when attempting to call galaxy)`,
+)
+
+// runGalaxy runs a galaxy command and retries it with an exponential backoff
+// as long as it fails with a transient network error.
+func (p *AnsiblePlaybook) runGalaxy(command func() *exec.Cmd) func(output io.Writer) error {
+	return func(output io.Writer) error {
+		delay := p.Config.GalaxyRetryDelay
+		if delay <= 0 {
+			delay = defaultGalaxyRetryDelay
+		}
+
+		for attempt := 0; ; attempt++ {
+			cmd := command()
+
+			var buf bytes.Buffer
+			fmt.Fprintln(&buf, "$", p.commandLine(cmd))
+
+			err := p.runOutput(cmd, &buf)
+			output.Write(buf.Bytes())
+
+			if err == nil {
+				p.addGalaxyReport(buf.Bytes())
+			}
+
+			if err == nil || attempt >= p.Config.GalaxyRetries || !transientGalaxyError.Match(buf.Bytes()) {
+				return err
+			}
+
+			fmt.Fprintf(output, "galaxy command failed with a transient error, retrying in %s\n", delay)
+			if err := p.sleep(delay); err != nil {
+				return err
+			}
+
+			delay *= 2
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package ansible
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRunGalaxyRetry tests that transient galaxy errors are retried.
+func TestRunGalaxyRetry(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "marker")
+
+	// The command fails with a transient error until the marker file exists.
+	script := "if [ -f " + marker + " ]; then exit 0; fi; touch " + marker + "; echo 'HTTP Code: 502'; exit 1"
+
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			GalaxyRetries:    1,
+			GalaxyRetryDelay: time.Millisecond,
+		},
+	}
+
+	var output bytes.Buffer
+	err := playbook.runGalaxy(func() *exec.Cmd {
+		return exec.Command("sh", "-c", script)
+	})(&output)
+
+	if err != nil {
+		t.Errorf("runGalaxy should succeed after a retry, but received: %v\n%s", err, output.String())
+	}
+
+	// The traced command line is quoted for a shell.
+	if !strings.HasPrefix(output.String(), "$ sh -c '") {
+		t.Errorf("Expected the quoted command line, got %q", output.String())
+	}
+}
+
+// TestRunGalaxyNoRetry tests that permanent galaxy errors are not retried.
+func TestRunGalaxyNoRetry(t *testing.T) {
+	calls := 0
+
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			GalaxyRetries:    3,
+			GalaxyRetryDelay: time.Millisecond,
+		},
+	}
+
+	var output bytes.Buffer
+	err := playbook.runGalaxy(func() *exec.Cmd {
+		calls++
+		return exec.Command("sh", "-c", "echo 'role not found'; exit 1")
+	})(&output)
+
+	if err == nil {
+		t.Error("runGalaxy should return an error")
+	}
+
+	// Assert that the command was only executed once.
+	if calls != 1 {
+		t.Errorf("Expected 1 call, got %d", calls)
+	}
+}
+
+// TestRunGalaxyRetryCanceled tests the backoff stops once the run is
+// canceled.
+func TestRunGalaxyRetryCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			GalaxyRetries:    3,
+			GalaxyRetryDelay: time.Hour,
+		},
+		ctx: ctx,
+	}
+
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	var output bytes.Buffer
+	err := playbook.runGalaxy(func() *exec.Cmd {
+		return exec.Command("sh", "-c", "echo 'HTTP Code: 502'; exit 1")
+	})(&output)
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the retry to be canceled, got %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package ansible
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// GalaxyServer configures one entry of the galaxy server list, e.g.
+// Automation Hub next to the community galaxy.
+type GalaxyServer struct {
+	Name        string
+	URL         string
+	AuthURL     string
+	Token       string
+	TokenSecret SecretProvider
+	Username    string
+	Password    string
+	IgnoreCerts bool
+	Timeout     int
+}
+
+func (s GalaxyServer) token() (string, error) {
+	if s.TokenSecret == nil {
+		return s.Token, nil
+	}
+
+	token, err := s.TokenSecret.Secret()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve token of galaxy server %s: %w", s.Name, err)
+	}
+
+	return token, nil
+}
+
+// galaxyServerEnv returns the name of the environment variable configuring
+// a key of a galaxy server.
+func galaxyServerEnv(server, key string) string {
+	return "ANSIBLE_GALAXY_SERVER_" + strings.ToUpper(server) + "_" + strings.ToUpper(key)
+}
+
+// galaxyServerConfig configures the galaxy servers with environment
+// variables, which take precedence over the ansible.cfg in use while its
+// other settings are kept.
+func (p *AnsiblePlaybook) galaxyServerConfig() error {
+	names := make([]string, 0, len(p.Config.GalaxyServers))
+	for _, server := range p.Config.GalaxyServers {
+		if server.Name == "" || server.URL == "" {
+			return errors.New("galaxy servers require a name and an url")
+		}
+
+		names = append(names, server.Name)
+	}
+
+	p.setEnv(EnvSourceGalaxy, "ANSIBLE_GALAXY_SERVER_LIST", strings.Join(names, ","))
+
+	for _, server := range p.Config.GalaxyServers {
+		token, err := server.token()
+		if err != nil {
+			return err
+		}
+
+		p.registerSecret(token)
+
+		p.setEnv(EnvSourceGalaxy, galaxyServerEnv(server.Name, "url"), server.URL)
+
+		if server.AuthURL != "" {
+			p.setEnv(EnvSourceGalaxy, galaxyServerEnv(server.Name, "auth_url"), server.AuthURL)
+		}
+
+		if token != "" {
+			p.setEnv(EnvSourceGalaxy, galaxyServerEnv(server.Name, "token"), token)
+		}
+
+		if server.Username != "" {
+			p.setEnv(EnvSourceGalaxy, galaxyServerEnv(server.Name, "username"), server.Username)
+		}
+
+		if server.Password != "" {
+			p.setEnv(EnvSourceGalaxy, galaxyServerEnv(server.Name, "password"), server.Password)
+		}
+
+		if server.IgnoreCerts {
+			p.setEnv(EnvSourceGalaxy, galaxyServerEnv(server.Name, "validate_certs"), "false")
+		}
+
+		if server.Timeout != 0 {
+			p.setEnv(EnvSourceGalaxy, galaxyServerEnv(server.Name, "timeout"), strconv.Itoa(server.Timeout))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package ansible
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestGalaxyServerConfig tests the galaxy servers are configured with
+// environment variables next to the ansible.cfg in use.
+func TestGalaxyServerConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	// Prepare a base config with an existing galaxy section and a token file.
+	base := filepath.Join(dir, "ansible.cfg")
+	if err := os.WriteFile(base, []byte("[defaults]\nforks = 10\n\n[galaxy]\nserver_list = old\ncache_dir = cache\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	token := filepath.Join(dir, "token")
+	if err := os.WriteFile(token, []byte("hub-token\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleConfigFile: base,
+			GalaxyServers: []GalaxyServer{
+				{
+					Name:        "automation_hub",
+					URL:         "https://console.redhat.com/api/automation-hub/",
+					AuthURL:     "https://sso.redhat.com/auth/realms/redhat-external/protocol/openid-connect/token",
+					TokenSecret: FileSecret(token),
+				},
+				{
+					Name:        "release_galaxy",
+					URL:         "https://galaxy.ansible.com/",
+					IgnoreCerts: true,
+					Timeout:     30,
+				},
+			},
+		},
+	}
+
+	if err := playbook.galaxyServerConfig(); err != nil {
+		t.Fatalf("galaxyServerConfig() failed: %s", err)
+	}
+
+	env := strings.Join(playbook.environ(), "\n") + "\n"
+
+	// Assert that the base config is used as it is and the servers are set
+	// by the environment.
+	for _, expected := range []string{
+		"ANSIBLE_CONFIG=" + base + "\n",
+		"ANSIBLE_GALAXY_SERVER_LIST=automation_hub,release_galaxy\n",
+		"ANSIBLE_GALAXY_SERVER_AUTOMATION_HUB_URL=https://console.redhat.com/api/automation-hub/\n",
+		"ANSIBLE_GALAXY_SERVER_AUTOMATION_HUB_TOKEN=hub-token\n",
+		"ANSIBLE_GALAXY_SERVER_RELEASE_GALAXY_VALIDATE_CERTS=false\n",
+		"ANSIBLE_GALAXY_SERVER_RELEASE_GALAXY_TIMEOUT=30\n",
+	} {
+		if !strings.Contains(env, expected) {
+			t.Errorf("Expected environment to contain '%s', got:\n%s", expected, env)
+		}
+	}
+
+	if strings.Contains(strings.Join(playbook.EnvBuilder().Dump(), "\n"), "hub-token") {
+		t.Error("Expected the token to be redacted")
+	}
+}
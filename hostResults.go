@@ -0,0 +1,106 @@
+package ansible
+
+import "time"
+
+// HostPlayResult is the result of a host once it finished a play, counted
+// like the recap of ansible.
+type HostPlayResult struct {
+	Playbook string    `json:"playbook,omitempty"`
+	Play     string    `json:"play"`
+	Host     string    `json:"host"`
+	Finished time.Time `json:"finished"`
+	Stats    HostStats `json:"stats"`
+}
+
+// HostResultFunc is called whenever a host finished a play, e.g. to update
+// the state of the host in an inventory management system. The results of a
+// run are handled one at a time.
+type HostResultFunc func(result HostPlayResult)
+
+// hostResults counts the task results of the hosts in the current play of a
+// connection of the event callback plugin. A play has finished once the next
+// play or playbook starts or the stats are sent.
+type hostResults struct {
+	playbook string
+	play     string
+	hosts    []string
+	stats    map[string]*HostStats
+}
+
+// event updates the counts with the event and returns the results of the
+// hosts if it finished the play.
+func (r *hostResults) event(event TaskEvent) []HostPlayResult {
+	switch event.Event {
+	case EventPlaybookStart:
+		results := r.finish(event.Time)
+		r.playbook = event.Playbook
+		return results
+	case EventPlayStart:
+		results := r.finish(event.Time)
+		r.play = event.Play
+		return results
+	case EventStats:
+		return r.finish(event.Time)
+	case EventTaskResult:
+		r.count(event)
+	}
+
+	return nil
+}
+
+func (r *hostResults) count(event TaskEvent) {
+	if r.stats == nil {
+		r.stats = map[string]*HostStats{}
+	}
+
+	stats, ok := r.stats[event.Host]
+	if !ok {
+		stats = &HostStats{}
+		r.stats[event.Host] = stats
+		r.hosts = append(r.hosts, event.Host)
+	}
+
+	switch event.Status {
+	case StatusOk:
+		stats.Ok++
+	case StatusChanged:
+		stats.Ok++
+		stats.Changed++
+	case StatusSkipped:
+		stats.Skipped++
+	case StatusUnreachable:
+		stats.Unreachable++
+	case StatusFailed:
+		// Ignored failures count as ok, like in the recap.
+		if event.Ignored {
+			stats.Ok++
+			stats.Ignored++
+		} else {
+			stats.Failed++
+		}
+	}
+}
+
+// finish returns the results of the hosts of the play in the order they
+// reported their first task and resets the counts.
+func (r *hostResults) finish(finished time.Time) []HostPlayResult {
+	if finished.IsZero() {
+		finished = time.Now()
+	}
+
+	results := make([]HostPlayResult, 0, len(r.hosts))
+	for _, host := range r.hosts {
+		results = append(results, HostPlayResult{
+			Playbook: r.playbook,
+			Play:     r.play,
+			Host:     host,
+			Finished: finished,
+			Stats:    *r.stats[host],
+		})
+	}
+
+	r.hosts = nil
+	r.stats = nil
+
+	return results
+}
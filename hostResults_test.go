@@ -0,0 +1,71 @@
+package ansible
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestHostResults tests the results of the hosts are reported once they
+// finished a play.
+func TestHostResults(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 is not installed")
+	}
+
+	bin := t.TempDir()
+	script := `#!/bin/sh
+python3 - <<'EOF'
+import os, socket
+s = socket.socket(socket.AF_UNIX, socket.SOCK_STREAM)
+s.connect(os.environ["ARILLSO_EVENTS_SOCKET"])
+s.sendall(b'{"event":"playbook_start","playbook":"site.yml"}\n')
+s.sendall(b'{"event":"play_start","play":"web"}\n')
+s.sendall(b'{"event":"task_result","host":"web1","status":"changed"}\n')
+s.sendall(b'{"event":"task_result","host":"web2","status":"ok"}\n')
+s.sendall(b'{"event":"task_result","host":"web1","status":"failed","ignored":true}\n')
+s.sendall(b'{"event":"task_result","host":"web2","status":"unreachable"}\n')
+s.sendall(b'{"event":"play_start","play":"db","time":"2024-05-01T10:00:00Z"}\n')
+s.sendall(b'{"event":"task_result","host":"db1","status":"skipped"}\n')
+s.sendall(b'{"event":"task_result","host":"db1","status":"failed"}\n')
+s.sendall(b'{"event":"stats","stats":{},"time":"2024-05-01T10:05:00Z"}\n')
+s.close()
+EOF
+`
+	if err := os.WriteFile(filepath.Join(bin, "ansible-playbook"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	var results []HostPlayResult
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir:    bin,
+			HostResults:      func(result HostPlayResult) { results = append(results, result) },
+			Inventories:      []string{"tests/inventories/production"},
+			Playbooks:        []string{"tests/test.yml"},
+			SkipVersionCheck: true,
+		},
+		Output: &bytes.Buffer{},
+	}
+
+	if err := playbook.Exec(); err != nil {
+		t.Fatalf("Exec should execute without error, but received: %v", err)
+	}
+
+	webFinished := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	dbFinished := time.Date(2024, 5, 1, 10, 5, 0, 0, time.UTC)
+
+	expected := []HostPlayResult{
+		{Playbook: "site.yml", Play: "web", Host: "web1", Finished: webFinished, Stats: HostStats{Ok: 2, Changed: 1, Ignored: 1}},
+		{Playbook: "site.yml", Play: "web", Host: "web2", Finished: webFinished, Stats: HostStats{Ok: 1, Unreachable: 1}},
+		{Playbook: "site.yml", Play: "db", Host: "db1", Finished: dbFinished, Stats: HostStats{Skipped: 1, Failed: 1}},
+	}
+
+	if !reflect.DeepEqual(results, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, results)
+	}
+}
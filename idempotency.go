@@ -0,0 +1,49 @@
+package ansible
+
+import (
+	"fmt"
+	"strings"
+)
+
+// IdempotencyError is returned if the second pass of an idempotency check
+// reported changes.
+type IdempotencyError struct {
+	Inventory string       `json:"inventory"`
+	Changes   []TaskResult `json:"changes"`
+}
+
+func (e *IdempotencyError) Error() string {
+	tasks := make([]string, 0, len(e.Changes))
+	for _, change := range e.Changes {
+		// Changes taken from the play recap have no play and task.
+		if change.Task == "" {
+			tasks = append(tasks, fmt.Sprintf("[%s]", change.Host))
+			continue
+		}
+
+		tasks = append(tasks, fmt.Sprintf("%s / %s [%s]", change.Play, change.Task, change.Host))
+	}
+
+	return fmt.Sprintf(
+		"idempotency check failed for inventory %s, %d task(s) changed on the second run: %s",
+		e.Inventory,
+		len(e.Changes),
+		strings.Join(tasks, ", "),
+	)
+}
+
+func (p *AnsiblePlaybook) idempotencyCheck(inventory string) error {
+	result, err := p.runPlaybook(inventory)
+	if err != nil {
+		return err
+	}
+
+	if changes := result.changes(); len(changes) > 0 {
+		return &IdempotencyError{
+			Inventory: inventory,
+			Changes:   changes,
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package ansible
+
+import (
+	"testing"
+)
+
+// TestIdempotencyChanges tests extracting changed tasks from playbook output.
+func TestIdempotencyChanges(t *testing.T) {
+	output := []byte(`
+PLAY [Configure web servers] ***************************************************
+
+TASK [Gathering Facts] *********************************************************
+ok: [web1]
+
+TASK [Install nginx] ***********************************************************
+ok: [web1]
+` + "\x1b[0;33mchanged: [web2]\x1b[0m" + `
+
+RUNNING HANDLER [Restart nginx] ************************************************
+changed: [web2] => (item=nginx)
+
+PLAY RECAP *********************************************************************
+web1                       : ok=2    changed=0    unreachable=0    failed=0
+web2                       : ok=3    changed=2    unreachable=0    failed=0
+`)
+
+	changes := ParseRunResult(output).Filter(StatusChanged)
+
+	expected := []TaskResult{
+		{Play: "Configure web servers", Task: "Install nginx", Host: "web2", Status: StatusChanged},
+		{Play: "Configure web servers", Task: "Restart nginx", Host: "web2", Status: StatusChanged},
+	}
+
+	if len(changes) != len(expected) {
+		t.Fatalf("Expected %d changes, got %+v", len(expected), changes)
+	}
+
+	for i := range expected {
+		if changes[i] != expected[i] {
+			t.Errorf("Expected change %+v, got %+v", expected[i], changes[i])
+		}
+	}
+}
+
+// TestIdempotencyRecapChanges tests the changes of the play recap are used
+// if the output does not list the changed tasks.
+func TestIdempotencyRecapChanges(t *testing.T) {
+	output := []byte(`
+PLAY RECAP *********************************************************************
+web1                       : ok=2    changed=0    unreachable=0    failed=0
+web2                       : ok=3    changed=1    unreachable=0    failed=0
+`)
+
+	err := &IdempotencyError{Inventory: "production", Changes: ParseRunResult(output).changes()}
+
+	expected := "idempotency check failed for inventory production, 1 task(s) changed on the second run: [web2]"
+	if err.Error() != expected {
+		t.Errorf("Expected error '%s', got '%s'", expected, err.Error())
+	}
+}
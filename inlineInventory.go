@@ -0,0 +1,73 @@
+package ansible
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// BuildInlineInventory returns an inventory of the hosts for
+// Config.Inventories. Without vars it is an inline host list, which ends with
+// a comma for a single host so ansible does not take it for a path. Vars map
+// hosts to their variables in the INI format, e.g. "ansible_host=10.0.0.1
+// ansible_port=2222", which can not be passed inline. The hosts are written
+// to a temporary INI inventory file instead, whose path is returned and which
+// the caller has to remove after the run.
+func BuildInlineInventory(hosts []string, vars map[string]string) (string, error) {
+	seen := map[string]bool{}
+
+	var unique []string
+	for _, host := range hosts {
+		host = strings.TrimSpace(host)
+		if host == "" || strings.ContainsAny(host, ", \t\n") {
+			return "", fmt.Errorf("invalid host %q for an inline inventory", host)
+		}
+
+		if !seen[host] {
+			seen[host] = true
+			unique = append(unique, host)
+		}
+	}
+
+	if len(unique) == 0 {
+		return "", errors.New("inline inventory requires at least one host")
+	}
+
+	for _, host := range sortedKeys(keySet(vars)) {
+		if !seen[host] {
+			return "", fmt.Errorf("vars for unknown host %s", host)
+		}
+
+		if strings.Contains(vars[host], "\n") {
+			return "", fmt.Errorf("invalid vars for host %s", host)
+		}
+	}
+
+	if len(vars) == 0 {
+		if len(unique) == 1 {
+			return unique[0] + ",", nil
+		}
+
+		return strings.Join(unique, ","), nil
+	}
+
+	var content strings.Builder
+	for _, host := range unique {
+		content.WriteString(strings.TrimSpace(host + " " + vars[host]))
+		content.WriteByte('\n')
+	}
+
+	file, err := os.CreateTemp("", "inventory*.ini")
+	if err != nil {
+		return "", fmt.Errorf("failed to write inventory file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(content.String()); err != nil {
+		os.Remove(file.Name())
+		return "", fmt.Errorf("failed to write inventory file: %w", err)
+	}
+
+	return file.Name(), nil
+}
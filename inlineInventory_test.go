@@ -0,0 +1,50 @@
+package ansible
+
+import (
+	"os"
+	"testing"
+)
+
+// TestBuildInlineInventory tests host lists are built with the trailing
+// comma of single hosts and hosts with vars are written to a file.
+func TestBuildInlineInventory(t *testing.T) {
+	tests := []struct {
+		hosts    []string
+		expected string
+	}{
+		{[]string{"web1"}, "web1,"},
+		{[]string{"web1", " web2 ", "web1"}, "web1,web2"},
+	}
+
+	for _, test := range tests {
+		inventory, err := BuildInlineInventory(test.hosts, nil)
+		if err != nil || inventory != test.expected {
+			t.Errorf("Expected inventory %q for %v, got %q: %v", test.expected, test.hosts, inventory, err)
+		}
+	}
+
+	for _, hosts := range [][]string{nil, {"web1,web2"}, {"web 1"}} {
+		if _, err := BuildInlineInventory(hosts, nil); err == nil {
+			t.Errorf("Expected hosts %q to be invalid", hosts)
+		}
+	}
+
+	if _, err := BuildInlineInventory([]string{"web1"}, map[string]string{"db1": "ansible_port=2222"}); err == nil {
+		t.Error("Expected vars of unknown hosts to be invalid")
+	}
+
+	file, err := BuildInlineInventory([]string{"web1", "web2"}, map[string]string{"web2": "ansible_port=2222"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file)
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(content) != "web1\nweb2 ansible_port=2222\n" {
+		t.Errorf("Unexpected inventory file %q", content)
+	}
+}
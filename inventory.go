@@ -0,0 +1,167 @@
+package ansible
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var invalidGroupChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// InventorySource generates an inventory at run time, e.g. from a cloud API
+// or a Terraform state.
+type InventorySource interface {
+	Inventory() (*Inventory, error)
+}
+
+// Inventory is a generated inventory of hosts with their variables and
+// group memberships.
+type Inventory struct {
+	Hosts  map[string]map[string]interface{}
+	Groups map[string][]string
+}
+
+// NewInventory returns an empty inventory.
+func NewInventory() *Inventory {
+	return &Inventory{
+		Hosts:  map[string]map[string]interface{}{},
+		Groups: map[string][]string{},
+	}
+}
+
+// AddHost adds a host with its variables to the inventory and the given
+// groups. Variables of an existing host are merged.
+func (i *Inventory) AddHost(name string, vars map[string]interface{}, groups ...string) {
+	if _, ok := i.Hosts[name]; !ok {
+		i.Hosts[name] = map[string]interface{}{}
+	}
+
+	for key, value := range vars {
+		i.Hosts[name][key] = value
+	}
+
+	for _, group := range groups {
+		i.AddToGroup(group, name)
+	}
+}
+
+// AddToGroup adds a host to a group. The group name is sanitized to be a
+// valid ansible group name.
+func (i *Inventory) AddToGroup(group, host string) {
+	group = GroupName(group)
+
+	for _, existing := range i.Groups[group] {
+		if existing == host {
+			return
+		}
+	}
+
+	i.Groups[group] = append(i.Groups[group], host)
+}
+
+// GroupName replaces all characters which are invalid in ansible group names.
+func GroupName(name string) string {
+	return invalidGroupChars.ReplaceAllString(name, "_")
+}
+
+// Marshal returns the inventory in the YAML inventory format.
+func (i *Inventory) Marshal() ([]byte, error) {
+	hosts := map[string]interface{}{}
+	for name, vars := range i.Hosts {
+		if len(vars) == 0 {
+			hosts[name] = nil
+			continue
+		}
+
+		hosts[name] = vars
+	}
+
+	children := map[string]interface{}{}
+	for group, members := range i.Groups {
+		sort.Strings(members)
+
+		groupHosts := map[string]interface{}{}
+		for _, member := range members {
+			groupHosts[member] = nil
+		}
+
+		children[group] = map[string]interface{}{"hosts": groupHosts}
+	}
+
+	all := map[string]interface{}{"hosts": hosts}
+	if len(children) > 0 {
+		all["children"] = children
+	}
+
+	content, err := yaml.Marshal(map[string]interface{}{"all": all})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode inventory: %w", err)
+	}
+
+	return content, nil
+}
+
+// WriteFile writes the inventory to a YAML file.
+func (i *Inventory) WriteFile(path string) error {
+	content, err := i.Marshal()
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		return fmt.Errorf("failed to write inventory file: %w", err)
+	}
+
+	return nil
+}
+
+// inventorySources writes the inventories of all sources to temporary files
+// and adds them to the inventories of the run. The returned files have to be
+// removed once the run finished.
+func (p *AnsiblePlaybook) inventorySources() ([]string, error) {
+	var files []string
+
+	for _, source := range p.Config.InventorySources {
+		inventory, err := source.Inventory()
+		if err != nil {
+			removeFiles(files)
+			return nil, fmt.Errorf("failed to generate inventory: %w", err)
+		}
+
+		content, err := inventory.Marshal()
+		if err != nil {
+			removeFiles(files)
+			return nil, err
+		}
+
+		file, err := p.tempFile("inventory*.yml", content)
+		if err != nil {
+			removeFiles(files)
+			return nil, fmt.Errorf("failed to write inventory file: %w", err)
+		}
+
+		files = append(files, file)
+	}
+
+	p.Config.Inventories = append(append([]string{}, p.Config.Inventories...), files...)
+	return files, nil
+}
+
+func removeFiles(files []string) {
+	for _, file := range files {
+		os.Remove(file)
+	}
+}
+
+func tagGroup(prefix, key, value string) string {
+	parts := []string{prefix, key}
+	if value != "" {
+		parts = append(parts, value)
+	}
+
+	return GroupName(strings.Join(parts, "_"))
+}
@@ -0,0 +1,92 @@
+package ansible
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// CachedInventory caches the inventory of an expensive source in a file, so
+// runs within the TTL reuse the resolved hosts. Force refreshes the cache.
+type CachedInventory struct {
+	Source InventorySource
+	Path   string
+	TTL    time.Duration
+	Force  bool
+}
+
+func (c *CachedInventory) Inventory() (*Inventory, error) {
+	if !c.Force {
+		if inventory, ok := c.load(); ok {
+			return inventory, nil
+		}
+	}
+
+	inventory, err := c.Source.Inventory()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.store(inventory); err != nil {
+		return nil, err
+	}
+
+	return inventory, nil
+}
+
+func (c *CachedInventory) load() (*Inventory, bool) {
+	info, err := os.Stat(c.Path)
+	if err != nil || time.Since(info.ModTime()) > c.TTL {
+		return nil, false
+	}
+
+	content, err := os.ReadFile(c.Path)
+	if err != nil {
+		return nil, false
+	}
+
+	inventory := NewInventory()
+	if err := json.Unmarshal(content, inventory); err != nil {
+		return nil, false
+	}
+
+	return inventory, true
+}
+
+func (c *CachedInventory) store(inventory *Inventory) error {
+	content, err := json.Marshal(inventory)
+	if err != nil {
+		return fmt.Errorf("failed to encode inventory cache: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.Path), 0o700); err != nil {
+		return fmt.Errorf("failed to create inventory cache directory: %w", err)
+	}
+
+	// Write to a temporary file first, so concurrent runs never read a
+	// partially written cache.
+	tmpfile, err := os.CreateTemp(filepath.Dir(c.Path), filepath.Base(c.Path)+"*")
+	if err != nil {
+		return fmt.Errorf("failed to create inventory cache: %w", err)
+	}
+
+	if _, err := tmpfile.Write(content); err != nil {
+		tmpfile.Close()
+		os.Remove(tmpfile.Name())
+		return fmt.Errorf("failed to write inventory cache: %w", err)
+	}
+
+	if err := tmpfile.Close(); err != nil {
+		os.Remove(tmpfile.Name())
+		return fmt.Errorf("failed to close inventory cache: %w", err)
+	}
+
+	if err := os.Rename(tmpfile.Name(), c.Path); err != nil {
+		os.Remove(tmpfile.Name())
+		return fmt.Errorf("failed to write inventory cache: %w", err)
+	}
+
+	return nil
+}
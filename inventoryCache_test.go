@@ -0,0 +1,57 @@
+package ansible
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type countingSource struct {
+	calls int
+}
+
+func (s *countingSource) Inventory() (*Inventory, error) {
+	s.calls++
+
+	inventory := NewInventory()
+	inventory.AddHost("web1", map[string]interface{}{"ansible_host": "10.0.0.1"}, "web")
+
+	return inventory, nil
+}
+
+// TestCachedInventory tests that the source is only queried when the cache expired.
+func TestCachedInventory(t *testing.T) {
+	source := &countingSource{}
+
+	cache := &CachedInventory{
+		Source: source,
+		Path:   filepath.Join(t.TempDir(), "cache", "inventory.json"),
+		TTL:    time.Hour,
+	}
+
+	for i := 0; i < 2; i++ {
+		inventory, err := cache.Inventory()
+		if err != nil {
+			t.Fatalf("Inventory() failed: %s", err)
+		}
+
+		if inventory.Hosts["web1"]["ansible_host"] != "10.0.0.1" || len(inventory.Groups["web"]) != 1 {
+			t.Errorf("Unexpected inventory: %+v", inventory)
+		}
+	}
+
+	// Assert that the second call was served from the cache.
+	if source.calls != 1 {
+		t.Errorf("Expected 1 call, got %d", source.calls)
+	}
+
+	// Assert that Force refreshes the cache.
+	cache.Force = true
+	if _, err := cache.Inventory(); err != nil {
+		t.Fatal(err)
+	}
+
+	if source.calls != 2 {
+		t.Errorf("Expected 2 calls, got %d", source.calls)
+	}
+}
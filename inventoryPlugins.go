@@ -0,0 +1,256 @@
+package ansible
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// InventoryPlugin renders the configuration file of a dynamic inventory
+// plugin.
+type InventoryPlugin interface {
+	// Suffix is the file name suffix the plugin requires, e.g. aws_ec2.yml.
+	Suffix() string
+	// PluginConfig returns the content of the configuration file.
+	PluginConfig() map[string]interface{}
+	// Env returns the credentials passed to the plugin as environment.
+	Env() (map[string]string, error)
+}
+
+// KeyedGroup creates groups from a host variable, e.g. tags.
+type KeyedGroup struct {
+	Key         string `yaml:"key"`
+	Prefix      string `yaml:"prefix,omitempty"`
+	Separator   string `yaml:"separator,omitempty"`
+	ParentGroup string `yaml:"parent_group,omitempty"`
+}
+
+// PluginOptions are the options shared by the constructed inventory
+// plugins.
+type PluginOptions struct {
+	KeyedGroups []KeyedGroup
+	Groups      map[string]string
+	Compose     map[string]string
+	Hostnames   []string
+	Strict      bool
+}
+
+func (o PluginOptions) apply(config map[string]interface{}) {
+	if len(o.KeyedGroups) > 0 {
+		config["keyed_groups"] = o.KeyedGroups
+	}
+
+	if len(o.Groups) > 0 {
+		config["groups"] = o.Groups
+	}
+
+	if len(o.Compose) > 0 {
+		config["compose"] = o.Compose
+	}
+
+	if len(o.Hostnames) > 0 {
+		config["hostnames"] = o.Hostnames
+	}
+
+	if o.Strict {
+		config["strict"] = true
+	}
+}
+
+// AWSEC2Inventory configures the amazon.aws.aws_ec2 inventory plugin.
+type AWSEC2Inventory struct {
+	PluginOptions
+
+	Regions      []string
+	Filters      map[string]interface{}
+	Profile      string
+	AccessKey    SecretProvider
+	SecretKey    SecretProvider
+	SessionToken SecretProvider
+}
+
+func (i *AWSEC2Inventory) Suffix() string {
+	return "aws_ec2.yml"
+}
+
+func (i *AWSEC2Inventory) PluginConfig() map[string]interface{} {
+	config := map[string]interface{}{"plugin": "amazon.aws.aws_ec2"}
+
+	if len(i.Regions) > 0 {
+		config["regions"] = i.Regions
+	}
+
+	if len(i.Filters) > 0 {
+		config["filters"] = i.Filters
+	}
+
+	if i.Profile != "" {
+		config["profile"] = i.Profile
+	}
+
+	i.apply(config)
+	return config
+}
+
+func (i *AWSEC2Inventory) Env() (map[string]string, error) {
+	return secretEnv(map[string]SecretProvider{
+		"AWS_ACCESS_KEY_ID":     i.AccessKey,
+		"AWS_SECRET_ACCESS_KEY": i.SecretKey,
+		"AWS_SESSION_TOKEN":     i.SessionToken,
+	})
+}
+
+// AzureRMInventory configures the azure.azcollection.azure_rm inventory
+// plugin.
+type AzureRMInventory struct {
+	PluginOptions
+
+	IncludeVMResourceGroups []string
+	ExcludeHostFilters      []string
+	AuthSource              string
+	SubscriptionID          SecretProvider
+	ClientID                SecretProvider
+	Secret                  SecretProvider
+	Tenant                  SecretProvider
+}
+
+func (i *AzureRMInventory) Suffix() string {
+	return "azure_rm.yml"
+}
+
+func (i *AzureRMInventory) PluginConfig() map[string]interface{} {
+	config := map[string]interface{}{"plugin": "azure.azcollection.azure_rm"}
+
+	if len(i.IncludeVMResourceGroups) > 0 {
+		config["include_vm_resource_groups"] = i.IncludeVMResourceGroups
+	}
+
+	if len(i.ExcludeHostFilters) > 0 {
+		config["exclude_host_filters"] = i.ExcludeHostFilters
+	}
+
+	if i.AuthSource != "" {
+		config["auth_source"] = i.AuthSource
+	}
+
+	i.apply(config)
+	return config
+}
+
+func (i *AzureRMInventory) Env() (map[string]string, error) {
+	return secretEnv(map[string]SecretProvider{
+		"AZURE_SUBSCRIPTION_ID": i.SubscriptionID,
+		"AZURE_CLIENT_ID":       i.ClientID,
+		"AZURE_SECRET":          i.Secret,
+		"AZURE_TENANT":          i.Tenant,
+	})
+}
+
+// GCPComputeInventory configures the google.cloud.gcp_compute inventory
+// plugin.
+type GCPComputeInventory struct {
+	PluginOptions
+
+	Projects           []string
+	Zones              []string
+	Filters            []string
+	AuthKind           string
+	ServiceAccountFile string
+}
+
+func (i *GCPComputeInventory) Suffix() string {
+	return "gcp.yml"
+}
+
+func (i *GCPComputeInventory) PluginConfig() map[string]interface{} {
+	config := map[string]interface{}{"plugin": "google.cloud.gcp_compute"}
+
+	if len(i.Projects) > 0 {
+		config["projects"] = i.Projects
+	}
+
+	if len(i.Zones) > 0 {
+		config["zones"] = i.Zones
+	}
+
+	if len(i.Filters) > 0 {
+		config["filters"] = i.Filters
+	}
+
+	authKind := i.AuthKind
+	if authKind == "" && i.ServiceAccountFile != "" {
+		authKind = "serviceaccount"
+	}
+
+	if authKind != "" {
+		config["auth_kind"] = authKind
+	}
+
+	i.apply(config)
+	return config
+}
+
+func (i *GCPComputeInventory) Env() (map[string]string, error) {
+	env := map[string]string{}
+	if i.ServiceAccountFile != "" {
+		env["GCP_SERVICE_ACCOUNT_FILE"] = i.ServiceAccountFile
+	}
+
+	return env, nil
+}
+
+func secretEnv(secrets map[string]SecretProvider) (map[string]string, error) {
+	env := map[string]string{}
+
+	for name, secret := range secrets {
+		if secret == nil {
+			continue
+		}
+
+		value, err := secret.Secret()
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", name, err)
+		}
+
+		env[name] = value
+	}
+
+	return env, nil
+}
+
+// inventoryPlugins writes the plugin configurations into the run directory
+// and adds them to the inventories of the run.
+func (p *AnsiblePlaybook) inventoryPlugins() error {
+	dir, err := p.runDir()
+	if err != nil {
+		return err
+	}
+
+	inventories := append([]string{}, p.Config.Inventories...)
+	for i, plugin := range p.Config.InventoryPlugins {
+		content, err := yaml.Marshal(plugin.PluginConfig())
+		if err != nil {
+			return fmt.Errorf("failed to encode inventory plugin config: %w", err)
+		}
+
+		file := filepath.Join(dir, fmt.Sprintf("inventory%d.%s", i, plugin.Suffix()))
+		if err := p.writeFile(file, content); err != nil {
+			return fmt.Errorf("failed to write inventory plugin config: %w", err)
+		}
+
+		env, err := plugin.Env()
+		if err != nil {
+			return err
+		}
+
+		for _, name := range sortedKeys(keySet(env)) {
+			p.setEnv(EnvSourceInventory, name, env[name])
+		}
+
+		inventories = append(inventories, file)
+	}
+
+	p.Config.Inventories = inventories
+	return nil
+}
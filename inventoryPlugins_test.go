@@ -0,0 +1,54 @@
+package ansible
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestInventoryPlugins tests rendering and registering inventory plugin configs.
+func TestInventoryPlugins(t *testing.T) {
+	t.Setenv("TEST_AWS_KEY", "AKIAEXAMPLE")
+
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			Inventories: []string{"static.yml"},
+			InventoryPlugins: []InventoryPlugin{
+				&AWSEC2Inventory{
+					PluginOptions: PluginOptions{
+						KeyedGroups: []KeyedGroup{{Key: "tags.Role", Prefix: "role"}},
+					},
+					Regions:   []string{"eu-central-1"},
+					Filters:   map[string]interface{}{"instance-state-name": "running"},
+					AccessKey: EnvSecret("TEST_AWS_KEY"),
+				},
+			},
+		},
+	}
+	defer playbook.cleanup()
+
+	if err := playbook.inventoryPlugins(); err != nil {
+		t.Fatalf("inventoryPlugins() failed: %s", err)
+	}
+
+	// Assert that the plugin config is registered after the static inventory.
+	if len(playbook.Config.Inventories) != 2 || !strings.HasSuffix(playbook.Config.Inventories[1], ".aws_ec2.yml") {
+		t.Fatalf("Unexpected inventories: %v", playbook.Config.Inventories)
+	}
+
+	content, err := os.ReadFile(playbook.Config.Inventories[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{"plugin: amazon.aws.aws_ec2", "instance-state-name: running", "prefix: role"} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("Expected plugin config to contain '%s', got:\n%s", expected, content)
+		}
+	}
+
+	// Assert that the credentials are passed as environment.
+	if !strings.Contains(strings.Join(playbook.environ(), "\n"), "AWS_ACCESS_KEY_ID=AKIAEXAMPLE") {
+		t.Error("Expected AWS_ACCESS_KEY_ID in the environment")
+	}
+}
@@ -0,0 +1,128 @@
+package ansible
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// InventoryView is the inventory as ansible sees it after merging all
+// inventories of the configuration.
+type InventoryView struct {
+	Groups map[string]InventoryGroup `json:"groups"`
+
+	// HostVars are the effective variables of every host after applying
+	// the precedence of the inventories and their group and host vars.
+	HostVars map[string]map[string]interface{} `json:"hostvars"`
+}
+
+// InventoryGroup is a group of the merged inventory with its direct hosts
+// and child groups.
+type InventoryGroup struct {
+	Hosts    []string `json:"hosts,omitempty"`
+	Children []string `json:"children,omitempty"`
+}
+
+// PreviewInventory merges all inventories with ansible-inventory like a run
+// with MergeInventories, so the layering of static and dynamic inventories
+// can be confirmed before applying it.
+func (p *AnsiblePlaybook) PreviewInventory() (*InventoryView, error) {
+	if len(p.Config.Inventories) == 0 {
+		return nil, errors.New("previewing the inventory requires an inventory")
+	}
+
+	var args []string
+	for _, inventory := range p.Config.Inventories {
+		args = flagArg(args, "--inventory", inventory)
+	}
+
+	return p.listInventory(args)
+}
+
+// listInventory lists the inventories of the arguments restricted by the
+// limit with ansible-inventory.
+func (p *AnsiblePlaybook) listInventory(args []string) (*InventoryView, error) {
+	args = append(args, "--list")
+	if p.Config.Limit != "" {
+		args = append(args, "--limit", p.Config.Limit)
+	}
+
+	cmd := exec.Command(p.binary("ansible-inventory"), args...)
+	cmd.Env = p.environ()
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list inventory: %s: %w", strings.TrimSpace(stderr.String()), err)
+	}
+
+	return parseInventoryList(output)
+}
+
+// parseInventoryList parses the output of ansible-inventory --list.
+func parseInventoryList(output []byte) (*InventoryView, error) {
+	var list map[string]json.RawMessage
+	if err := json.Unmarshal(output, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse inventory: %w", err)
+	}
+
+	view := &InventoryView{
+		Groups:   map[string]InventoryGroup{},
+		HostVars: map[string]map[string]interface{}{},
+	}
+
+	for name, content := range list {
+		if name == "_meta" {
+			var meta struct {
+				HostVars map[string]map[string]interface{} `json:"hostvars"`
+			}
+
+			if err := json.Unmarshal(content, &meta); err != nil {
+				return nil, fmt.Errorf("failed to parse inventory: %w", err)
+			}
+
+			for host, vars := range meta.HostVars {
+				view.HostVars[host] = vars
+			}
+
+			continue
+		}
+
+		var group InventoryGroup
+		if err := json.Unmarshal(content, &group); err != nil {
+			return nil, fmt.Errorf("failed to parse group %s: %w", name, err)
+		}
+
+		sort.Strings(group.Hosts)
+		sort.Strings(group.Children)
+		view.Groups[name] = group
+	}
+
+	// Hosts without variables are missing in the hostvars.
+	for _, group := range view.Groups {
+		for _, host := range group.Hosts {
+			if _, ok := view.HostVars[host]; !ok {
+				view.HostVars[host] = map[string]interface{}{}
+			}
+		}
+	}
+
+	return view, nil
+}
+
+// Hosts returns the names of all hosts of the inventory.
+func (v *InventoryView) Hosts() []string {
+	hosts := make([]string, 0, len(v.HostVars))
+	for host := range v.HostVars {
+		hosts = append(hosts, host)
+	}
+
+	sort.Strings(hosts)
+	return hosts
+}
@@ -0,0 +1,60 @@
+package ansible
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestPreviewInventory tests all inventories are merged by ansible-inventory
+// and its groups and effective host vars are returned.
+func TestPreviewInventory(t *testing.T) {
+	bin := t.TempDir()
+	log := filepath.Join(bin, "calls.log")
+
+	script := `#!/bin/sh
+echo "$@" >> ` + log + `
+cat <<'JSON'
+{
+  "_meta": {"hostvars": {"web1": {"http_port": 8080, "region": "eu"}}},
+  "all": {"children": ["ungrouped", "web"]},
+  "web": {"hosts": ["web2", "web1"]}
+}
+JSON
+`
+	if err := os.WriteFile(filepath.Join(bin, "ansible-inventory"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir: bin,
+			Inventories:   []string{"static", "aws_ec2.yml"},
+			Limit:         "web",
+		},
+	}
+
+	view, err := playbook.PreviewInventory()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calls, _ := os.ReadFile(log)
+	if strings.TrimSpace(string(calls)) != "--inventory static --inventory aws_ec2.yml --list --limit web" {
+		t.Errorf("Unexpected call %q", calls)
+	}
+
+	if !reflect.DeepEqual(view.Groups["web"].Hosts, []string{"web1", "web2"}) {
+		t.Errorf("Unexpected web group %+v", view.Groups["web"])
+	}
+
+	if !reflect.DeepEqual(view.Hosts(), []string{"web1", "web2"}) {
+		t.Errorf("Unexpected hosts %v", view.Hosts())
+	}
+
+	if view.HostVars["web1"]["http_port"] != float64(8080) {
+		t.Errorf("Unexpected host vars %v", view.HostVars["web1"])
+	}
+}
@@ -0,0 +1,121 @@
+package ansible
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// InventoryScriptError is returned if a dynamic inventory script is not
+// executable or fails to list its inventory.
+type InventoryScriptError struct {
+	Path   string
+	Reason string
+	Stderr string
+	Err    error
+}
+
+func (e *InventoryScriptError) Error() string {
+	message := fmt.Sprintf("inventory script %s %s", e.Path, e.Reason)
+	if e.Err != nil {
+		message += ": " + e.Err.Error()
+	}
+
+	if e.Stderr != "" {
+		message += "\n" + e.Stderr
+	}
+
+	return message
+}
+
+func (e *InventoryScriptError) Unwrap() error {
+	return e.Err
+}
+
+// inventoryScript reports whether the inventory is a script, i.e. a file
+// starting with a shebang. Ansible parses scripts without the executable bit
+// as static inventories, which fails with confusing errors.
+func inventoryScript(path string) (bool, os.FileInfo) {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return false, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return false, nil
+	}
+	defer file.Close()
+
+	shebang := make([]byte, 2)
+	if _, err := file.Read(shebang); err != nil {
+		return false, nil
+	}
+
+	return string(shebang) == "#!", info
+}
+
+// checkInventoryScripts fails if an inventory script is not executable. With
+// PreflightInventoryScripts every script is run once with --list and has to
+// print a JSON inventory.
+func (p *AnsiblePlaybook) checkInventoryScripts() error {
+	for _, inventory := range p.Config.Inventories {
+		script, info := inventoryScript(inventory)
+		if !script {
+			continue
+		}
+
+		if info.Mode()&0o111 == 0 {
+			return &InventoryScriptError{Path: inventory, Reason: "is not executable"}
+		}
+
+		if p.Config.PreflightInventoryScripts {
+			if err := p.preflightInventoryScript(inventory); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// preflightInventoryScript runs the script with --list and surfaces its
+// stderr if it fails.
+func (p *AnsiblePlaybook) preflightInventoryScript(inventory string) error {
+	path, err := filepath.Abs(inventory)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(p.context(), path, "--list")
+	cmd.Env = p.environ()
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return &InventoryScriptError{
+			Path:   inventory,
+			Reason: "failed",
+			Stderr: strings.TrimSpace(string(p.redact(stderr.Bytes()))),
+			Err:    err,
+		}
+	}
+
+	var groups map[string]interface{}
+	if err := json.Unmarshal(output, &groups); err != nil {
+		return &InventoryScriptError{
+			Path:   inventory,
+			Reason: "printed no JSON inventory",
+			Stderr: strings.TrimSpace(string(p.redact(stderr.Bytes()))),
+			Err:    err,
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package ansible
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestCheckInventoryScripts tests inventory scripts have to be executable
+// and list a JSON inventory in the preflight.
+func TestCheckInventoryScripts(t *testing.T) {
+	dir := t.TempDir()
+
+	scripts := []struct {
+		name   string
+		script string
+		mode   os.FileMode
+		reason string
+	}{
+		{"valid.py", "#!/bin/sh\necho '{\"all\": {\"hosts\": [\"web1\"]}}'\n", 0o755, ""},
+		{"static.py", "#!/bin/sh\necho '{}'\n", 0o644, "is not executable"},
+		{"broken.py", "#!/bin/sh\necho 'missing credentials' >&2\nexit 1\n", 0o755, "failed"},
+		{"text.py", "#!/bin/sh\necho 'web1'\n", 0o755, "printed no JSON inventory"},
+	}
+
+	for _, script := range scripts {
+		path := filepath.Join(dir, script.name)
+		if err := os.WriteFile(path, []byte(script.script), script.mode); err != nil {
+			t.Fatal(err)
+		}
+
+		playbook := &AnsiblePlaybook{
+			Config: Config{
+				Inventories:               []string{"tests/inventories/production", path},
+				PreflightInventoryScripts: true,
+			},
+		}
+
+		err := playbook.checkInventoryScripts()
+		if script.reason == "" {
+			if err != nil {
+				t.Errorf("Expected %s to be valid, got %v", script.name, err)
+			}
+
+			continue
+		}
+
+		var scriptErr *InventoryScriptError
+		if !errors.As(err, &scriptErr) || scriptErr.Reason != script.reason {
+			t.Errorf("Expected %s to fail with %q, got %v", script.name, script.reason, err)
+		}
+	}
+
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			Inventories:               []string{filepath.Join(dir, "broken.py")},
+			PreflightInventoryScripts: true,
+		},
+	}
+
+	if err := playbook.checkInventoryScripts(); err == nil || !strings.Contains(err.Error(), "missing credentials") {
+		t.Errorf("Expected the stderr of the script in the error, got %v", err)
+	}
+}
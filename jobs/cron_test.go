@@ -0,0 +1,70 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+// TestCronNext tests the next activation of cron expressions.
+func TestCronNext(t *testing.T) {
+	start := time.Date(2024, 1, 31, 10, 17, 30, 0, time.UTC) // Wednesday
+
+	tests := []struct {
+		expression string
+		expected   time.Time
+	}{
+		{"* * * * *", time.Date(2024, 1, 31, 10, 18, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 1, 31, 10, 30, 0, 0, time.UTC)},
+		{"0 3 * * *", time.Date(2024, 2, 1, 3, 0, 0, 0, time.UTC)},
+		{"30 2 * * 1-5", time.Date(2024, 2, 1, 2, 30, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 2, 4, 0, 0, 0, 0, time.UTC)},
+		{"0 12 29 2 *", time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC)},
+		{"0 0 13 * 5", time.Date(2024, 2, 2, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"5,45 10 * * *", time.Date(2024, 1, 31, 10, 45, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		cron, err := parseCron(test.expression)
+		if err != nil {
+			t.Errorf("Failed to parse '%s': %v", test.expression, err)
+			continue
+		}
+
+		if next := cron.next(start); !next.Equal(test.expected) {
+			t.Errorf("Expected '%s' to activate at %s, got %s", test.expression, test.expected, next)
+		}
+	}
+}
+
+// TestCronNextTimeZone tests the next activation in time zones which are not
+// offset by whole hours.
+func TestCronNextTimeZone(t *testing.T) {
+	for _, name := range []string{"Asia/Kolkata", "Asia/Kathmandu", "Australia/Adelaide"} {
+		location, err := time.LoadLocation(name)
+		if err != nil {
+			t.Skipf("time zone %s is not available: %v", name, err)
+		}
+
+		cron, err := parseCron("0 11 * * *")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		start := time.Date(2026, 10, 16, 10, 15, 0, 0, location)
+		expected := time.Date(2026, 10, 16, 11, 0, 0, 0, location)
+
+		if next := cron.next(start); !next.Equal(expected) {
+			t.Errorf("Expected the activation at %s in %s, got %s", expected, name, next)
+		}
+	}
+}
+
+// TestCronInvalid tests invalid expressions are rejected.
+func TestCronInvalid(t *testing.T) {
+	for _, expression := range []string{"", "* * * *", "60 * * * *", "* 5-2 * * *", "*/0 * * * *", "a * * * *"} {
+		if _, err := parseCron(expression); err == nil {
+			t.Errorf("Expected '%s' to be invalid", expression)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	ansible "github.com/arillso/go.ansible"
+)
+
+// Job is a submitted playbook run.
+type Job struct {
+	ID      string
+	Spec    RunSpec
+	Created time.Time
+
+	mu       sync.Mutex
+	status   string
+	err      string
+	started  time.Time
+	finished time.Time
+	results  []*ansible.RunResult
+	galaxy   *ansible.GalaxyReport
+
+	log    *log
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Info is a snapshot of the state of a job.
+type Info struct {
+	ID       string                `json:"id"`
+	RunID    string                `json:"run_id"`
+	Name     string                `json:"name,omitempty"`
+	Status   string                `json:"status"`
+	Error    string                `json:"error,omitempty"`
+	Created  time.Time             `json:"created"`
+	Started  *time.Time            `json:"started,omitempty"`
+	Finished *time.Time            `json:"finished,omitempty"`
+	Results  []*ansible.RunResult  `json:"results,omitempty"`
+	Galaxy   *ansible.GalaxyReport `json:"galaxy,omitempty"`
+}
+
+// Info returns a snapshot of the state of the job.
+func (j *Job) Info() Info {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	info := Info{
+		ID:      j.ID,
+		RunID:   j.Spec.Config.RunID,
+		Name:    j.Spec.Name,
+		Status:  j.status,
+		Error:   j.err,
+		Created: j.Created,
+		Results: j.results,
+		Galaxy:  j.galaxy,
+	}
+
+	if !j.started.IsZero() {
+		started := j.started
+		info.Started = &started
+	}
+
+	if !j.finished.IsZero() {
+		finished := j.finished
+		info.Finished = &finished
+	}
+
+	return info
+}
+
+// Done is closed once the job finished.
+func (j *Job) Done() <-chan struct{} {
+	return j.done
+}
+
+// Follow writes the output of the job to w, starting at the beginning, until
+// the job finished or the context is canceled.
+func (j *Job) Follow(ctx context.Context, w io.Writer) error {
+	return j.log.follow(ctx, w)
+}
+
+// Log returns the output of the job written so far.
+func (j *Job) Log() []byte {
+	return j.log.bytes()
+}
+
+func (j *Job) finishedAt() time.Time {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.finished
+}
+
+func (j *Job) start() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.status = StatusRunning
+	j.started = time.Now()
+}
+
+func (j *Job) finish(results []*ansible.RunResult, galaxy *ansible.GalaxyReport, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.results = results
+	j.galaxy = galaxy
+	j.finished = time.Now()
+
+	switch {
+	case err == nil:
+		j.status = StatusSucceeded
+	case errors.Is(err, context.Canceled):
+		j.status = StatusCanceled
+		j.err = err.Error()
+	default:
+		j.status = StatusFailed
+		j.err = err.Error()
+	}
+}
+
+// log is the output of a job which can be followed while it is written.
+type log struct {
+	mu     sync.Mutex
+	data   []byte
+	notify chan struct{}
+	closed bool
+}
+
+func newLog() *log {
+	return &log{
+		notify: make(chan struct{}),
+	}
+}
+
+func (l *log) Write(b []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.data = append(l.data, b...)
+	if !l.closed {
+		close(l.notify)
+		l.notify = make(chan struct{})
+	}
+
+	return len(b), nil
+}
+
+func (l *log) close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.closed {
+		l.closed = true
+		close(l.notify)
+	}
+}
+
+func (l *log) bytes() []byte {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]byte{}, l.data...)
+}
+
+func (l *log) follow(ctx context.Context, w io.Writer) error {
+	offset := 0
+
+	for {
+		l.mu.Lock()
+		chunk := append([]byte{}, l.data[offset:]...)
+		notify, closed := l.notify, l.closed
+		l.mu.Unlock()
+
+		if len(chunk) > 0 {
+			if _, err := w.Write(chunk); err != nil {
+				return err
+			}
+
+			offset += len(chunk)
+			continue
+		}
+
+		if closed {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-notify:
+		}
+	}
+}
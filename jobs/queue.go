@@ -0,0 +1,278 @@
+// Package jobs runs playbooks as asynchronous jobs with a bounded number of
+// concurrent runs.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	ansible "github.com/arillso/go.ansible"
+)
+
+var (
+	// ErrJobNotFound is returned for unknown job IDs.
+	ErrJobNotFound = errors.New("job not found")
+
+	errQueueClosed = errors.New("queue is closed")
+	errQueueFull   = errors.New("queue is full")
+)
+
+// Job statuses.
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusCanceled  = "canceled"
+)
+
+// RunSpec describes a playbook run submitted as a job.
+type RunSpec struct {
+	Name   string         `json:"name,omitempty"`
+	Config ansible.Config `json:"config"`
+}
+
+// Queue runs submitted jobs with at most Workers concurrent runs.
+type Queue struct {
+	mu      sync.Mutex
+	jobs    map[string]*Job
+	pending chan *Job
+	wg      sync.WaitGroup
+	closed  bool
+
+	// Prepare is called with the spec of every submitted job. It can be used
+	// to apply defaults or to reject specs with an error.
+	Prepare func(spec *RunSpec) error
+
+	// MaxJobs is the number of finished jobs which are kept, 1000 by
+	// default, and MaxAge, if set, how long they are kept. Finished jobs
+	// beyond are evicted with their logs. Zero keeps all finished jobs.
+	MaxJobs int
+	MaxAge  time.Duration
+}
+
+const defaultMaxJobs = 1000
+
+// NewQueue starts a queue with the given number of workers.
+func NewQueue(workers int) *Queue {
+	if workers < 1 {
+		workers = 1
+	}
+
+	q := &Queue{
+		jobs:    map[string]*Job{},
+		pending: make(chan *Job, 1024),
+		MaxJobs: defaultMaxJobs,
+	}
+
+	for i := 0; i < workers; i++ {
+		q.wg.Add(1)
+		go q.work()
+	}
+
+	return q
+}
+
+// Submit queues a job for the spec.
+func (q *Queue) Submit(spec RunSpec) (*Job, error) {
+	job, err := q.submitHeld(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := q.release(job); err != nil {
+		q.mu.Lock()
+		delete(q.jobs, job.ID)
+		q.mu.Unlock()
+
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// submitHeld adds a job for the spec which is not run until it is released.
+func (q *Queue) submitHeld(spec RunSpec) (*Job, error) {
+	if q.Prepare != nil {
+		if err := q.Prepare(&spec); err != nil {
+			return nil, err
+		}
+	}
+
+	// The job ID is the run ID unless the spec has its own.
+	id := ansible.NewRunID()
+	if spec.Config.RunID == "" {
+		spec.Config.RunID = id
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	job := &Job{
+		ID:      id,
+		Spec:    spec,
+		status:  StatusQueued,
+		Created: time.Now(),
+		log:     newLog(),
+		ctx:     ctx,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		cancel()
+		return nil, errQueueClosed
+	}
+
+	q.evict()
+	q.jobs[job.ID] = job
+
+	return job, nil
+}
+
+// release queues a held job. If that fails the job is finished with the
+// error.
+func (q *Queue) release(job *Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	err := errQueueClosed
+	if !q.closed {
+		select {
+		case q.pending <- job:
+			return nil
+		default:
+			err = errQueueFull
+		}
+	}
+
+	job.finish(nil, nil, err)
+	job.log.close()
+	job.cancel()
+	close(job.done)
+
+	return err
+}
+
+// Get returns the job with the ID.
+func (q *Queue) Get(id string) (*Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job, ok := q.jobs[id]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+
+	return job, nil
+}
+
+// List returns all jobs ordered by creation time.
+func (q *Queue) List() []*Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	jobs := make([]*Job, 0, len(q.jobs))
+	for _, job := range q.jobs {
+		jobs = append(jobs, job)
+	}
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].Created.Before(jobs[j].Created)
+	})
+
+	return jobs
+}
+
+// Cancel cancels a queued or running job.
+func (q *Queue) Cancel(id string) error {
+	job, err := q.Get(id)
+	if err != nil {
+		return err
+	}
+
+	job.cancel()
+	return nil
+}
+
+// Close cancels all jobs and waits for the workers to stop.
+func (q *Queue) Close() {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.pending)
+
+		for _, job := range q.jobs {
+			job.cancel()
+		}
+	}
+	q.mu.Unlock()
+
+	q.wg.Wait()
+}
+
+func (q *Queue) work() {
+	defer q.wg.Done()
+
+	for job := range q.pending {
+		q.run(job)
+	}
+}
+
+func (q *Queue) run(job *Job) {
+	defer close(job.done)
+	defer job.log.close()
+	defer job.cancel()
+
+	if job.ctx.Err() != nil {
+		job.finish(nil, nil, context.Canceled)
+		return
+	}
+
+	job.start()
+
+	playbook := &ansible.AnsiblePlaybook{
+		Config: job.Spec.Config,
+		Output: job.log,
+	}
+
+	err := playbook.ExecContext(job.ctx)
+	job.finish(playbook.Results, playbook.Galaxy, err)
+
+	q.mu.Lock()
+	q.evict()
+	q.mu.Unlock()
+}
+
+// evict removes the finished jobs exceeding MaxJobs or MaxAge, the oldest
+// first. The lock of the queue has to be held.
+func (q *Queue) evict() {
+	if q.MaxJobs <= 0 && q.MaxAge <= 0 {
+		return
+	}
+
+	var finished []*Job
+	for _, job := range q.jobs {
+		if !job.finishedAt().IsZero() {
+			finished = append(finished, job)
+		}
+	}
+
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[i].finishedAt().Before(finished[j].finishedAt())
+	})
+
+	for i, job := range finished {
+		expired := q.MaxAge > 0 && time.Since(job.finishedAt()) > q.MaxAge
+		exceeded := q.MaxJobs > 0 && len(finished)-i > q.MaxJobs
+
+		if expired || exceeded {
+			delete(q.jobs, job.ID)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package jobs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	ansible "github.com/arillso/go.ansible"
+	"github.com/arillso/go.ansible/ansibletest"
+)
+
+func spec(fake *ansibletest.Fake) RunSpec {
+	return RunSpec{
+		Name: "site",
+		Config: ansible.Config{
+			AnsibleBinDir:    fake.Dir,
+			Inventories:      []string{"../tests/inventories/production"},
+			Playbooks:        []string{"../tests/test.yml"},
+			SkipVersionCheck: true,
+		},
+	}
+}
+
+func wait(t *testing.T, job *Job) Info {
+	t.Helper()
+
+	select {
+	case <-job.Done():
+	case <-time.After(10 * time.Second):
+		t.Fatal("job did not finish")
+	}
+
+	return job.Info()
+}
+
+// TestQueue tests a submitted job is run and its output is recorded.
+func TestQueue(t *testing.T) {
+	fake := ansibletest.NewFake(t)
+	fake.SetOutput("ansible-playbook", ansibletest.Recap(map[string]ansible.HostStats{"web1": {Ok: 1}}))
+
+	queue := NewQueue(2)
+	defer queue.Close()
+
+	job, err := queue.Submit(spec(fake))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info := wait(t, job)
+	if info.Status != StatusSucceeded || len(info.Results) != 1 {
+		t.Fatalf("Expected the job to succeed with one result, got %+v", info)
+	}
+
+	var log strings.Builder
+	if err := job.Follow(context.Background(), &log); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(log.String(), "PLAY RECAP") {
+		t.Errorf("Expected the log to contain the recap, got '%s'", log.String())
+	}
+
+	if _, err := queue.Get("missing"); err != ErrJobNotFound {
+		t.Errorf("Expected ErrJobNotFound, got %v", err)
+	}
+}
+
+// TestQueueCancel tests a running job is killed on cancellation.
+func TestQueueCancel(t *testing.T) {
+	// The playbook run blocks until it is killed.
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "ansible-playbook"), []byte("#!/bin/sh\nexec sleep 30\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	queue := NewQueue(1)
+	defer queue.Close()
+
+	s := spec(ansibletest.NewFake(t))
+	s.Config.AnsibleBinDir = bin
+
+	job, err := queue.Submit(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for job.Info().Status != StatusRunning {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := queue.Cancel(job.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if info := wait(t, job); info.Status != StatusCanceled {
+		t.Errorf("Expected the job to be canceled, got %+v", info)
+	}
+}
+
+// TestQueueRetention tests finished jobs beyond the retention are evicted.
+func TestQueueRetention(t *testing.T) {
+	fake := ansibletest.NewFake(t)
+
+	queue := NewQueue(1)
+	defer queue.Close()
+
+	queue.MaxJobs = 2
+
+	var submitted []*Job
+	for i := 0; i < 3; i++ {
+		job, err := queue.Submit(spec(fake))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		wait(t, job)
+		submitted = append(submitted, job)
+	}
+
+	if _, err := queue.Get(submitted[0].ID); err != ErrJobNotFound {
+		t.Errorf("Expected the oldest job to be evicted, got %v", err)
+	}
+
+	if list := queue.List(); len(list) != 2 || list[0] != submitted[1] || list[1] != submitted[2] {
+		t.Errorf("Expected the last 2 jobs to be kept, got %d", len(list))
+	}
+
+	queue.MaxJobs = 0
+	queue.MaxAge = time.Millisecond
+	time.Sleep(10 * time.Millisecond)
+
+	job, err := queue.Submit(spec(fake))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if list := queue.List(); len(list) != 1 || list[0] != job {
+		t.Errorf("Expected the expired jobs to be evicted, got %d", len(list))
+	}
+
+	wait(t, job)
+}
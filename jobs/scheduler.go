@@ -0,0 +1,217 @@
+package jobs
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Overlap policies deciding what happens if a schedule fires while the job
+// of its previous activation is still queued or running.
+const (
+	OverlapSkip           = "skip"
+	OverlapQueue          = "queue"
+	OverlapCancelPrevious = "cancel-previous"
+)
+
+// Schedule binds a run spec to a cron expression, e.g. "0 3 * * 1-5" or
+// "@daily".
+type Schedule struct {
+	Name    string
+	Cron    string
+	Spec    RunSpec
+	Overlap string
+}
+
+// Scheduler submits the jobs of schedules to a queue, which keeps the
+// history of all runs.
+type Scheduler struct {
+	Queue *Queue
+
+	mu      sync.Mutex
+	entries []*scheduleEntry
+	stop    chan struct{}
+	wake    chan struct{}
+	wg      sync.WaitGroup
+	now     func() time.Time
+}
+
+type scheduleEntry struct {
+	schedule Schedule
+	cron     *cronSchedule
+	next     time.Time
+	last     *Job
+}
+
+// NewScheduler returns a scheduler submitting to the queue.
+func NewScheduler(queue *Queue) *Scheduler {
+	return &Scheduler{
+		Queue: queue,
+		wake:  make(chan struct{}, 1),
+		now:   time.Now,
+	}
+}
+
+// Add adds a schedule. Schedules can be added while the scheduler runs.
+func (s *Scheduler) Add(schedule Schedule) error {
+	switch schedule.Overlap {
+	case "":
+		schedule.Overlap = OverlapSkip
+	case OverlapSkip, OverlapQueue, OverlapCancelPrevious:
+	default:
+		return fmt.Errorf("invalid overlap policy %q", schedule.Overlap)
+	}
+
+	cron, err := parseCron(schedule.Cron)
+	if err != nil {
+		return err
+	}
+
+	if schedule.Spec.Name == "" {
+		schedule.Spec.Name = schedule.Name
+	}
+
+	s.mu.Lock()
+	s.entries = append(s.entries, &scheduleEntry{
+		schedule: schedule,
+		cron:     cron,
+		next:     cron.next(s.now()),
+	})
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// Start runs the scheduler until Stop is called.
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stop != nil {
+		return
+	}
+
+	s.stop = make(chan struct{})
+	s.wg.Add(1)
+
+	go s.loop(s.stop)
+}
+
+// Stop stops the scheduler. Submitted jobs are not canceled.
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop(stop chan struct{}) {
+	defer s.wg.Done()
+
+	for {
+		timer := time.NewTimer(s.untilNext())
+
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-s.wake:
+			timer.Stop()
+		case <-timer.C:
+			s.fire()
+		}
+	}
+}
+
+// untilNext returns the duration until the next activation of any schedule.
+func (s *Scheduler) untilNext() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	next := time.Time{}
+	for _, entry := range s.entries {
+		if !entry.next.IsZero() && (next.IsZero() || entry.next.Before(next)) {
+			next = entry.next
+		}
+	}
+
+	if next.IsZero() {
+		return time.Hour
+	}
+
+	return next.Sub(s.now())
+}
+
+// fire triggers all schedules which are due.
+func (s *Scheduler) fire() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for _, entry := range s.entries {
+		if entry.next.IsZero() || entry.next.After(now) {
+			continue
+		}
+
+		entry.next = entry.cron.next(now)
+		s.trigger(entry)
+	}
+}
+
+func (s *Scheduler) trigger(entry *scheduleEntry) {
+	previous := entry.last
+	running := previous != nil && !isDone(previous)
+
+	switch {
+	case running && entry.schedule.Overlap == OverlapSkip:
+		return
+	case running && entry.schedule.Overlap == OverlapCancelPrevious:
+		previous.cancel()
+	case running && entry.schedule.Overlap == OverlapQueue:
+		// The job is submitted once the previous one finished, so the runs
+		// of a schedule never overlap.
+		job := s.submitAfter(previous, entry.schedule.Spec)
+		if job != nil {
+			entry.last = job
+		}
+
+		return
+	}
+
+	if job, err := s.Queue.Submit(entry.schedule.Spec); err == nil {
+		entry.last = job
+	}
+}
+
+// submitAfter returns a job which is queued once the previous job finished.
+func (s *Scheduler) submitAfter(previous *Job, spec RunSpec) *Job {
+	job, err := s.Queue.submitHeld(spec)
+	if err != nil {
+		return nil
+	}
+
+	go func() {
+		<-previous.Done()
+		s.Queue.release(job)
+	}()
+
+	return job
+}
+
+func isDone(job *Job) bool {
+	select {
+	case <-job.Done():
+		return true
+	default:
+		return false
+	}
+}
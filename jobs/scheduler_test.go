@@ -0,0 +1,97 @@
+package jobs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	ansible "github.com/arillso/go.ansible"
+)
+
+// blockingSpec returns a spec whose run blocks until it is canceled.
+func blockingSpec(t *testing.T) RunSpec {
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "ansible-playbook"), []byte("#!/bin/sh\nexec sleep 30\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	return RunSpec{
+		Config: ansible.Config{
+			AnsibleBinDir:    bin,
+			Inventories:      []string{"../tests/inventories/production"},
+			Playbooks:        []string{"../tests/test.yml"},
+			SkipVersionCheck: true,
+		},
+	}
+}
+
+// TestSchedulerOverlap tests the overlap policies if the previous job is
+// still running.
+func TestSchedulerOverlap(t *testing.T) {
+	tests := []struct {
+		overlap        string
+		jobs           int
+		previousStatus string
+	}{
+		{OverlapSkip, 1, StatusRunning},
+		{OverlapCancelPrevious, 2, StatusCanceled},
+		{OverlapQueue, 2, StatusRunning},
+	}
+
+	for _, test := range tests {
+		queue := NewQueue(2)
+
+		now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		scheduler := NewScheduler(queue)
+		scheduler.now = func() time.Time { return now }
+
+		if err := scheduler.Add(Schedule{Name: "patch", Cron: "* * * * *", Spec: blockingSpec(t), Overlap: test.overlap}); err != nil {
+			t.Fatal(err)
+		}
+
+		now = now.Add(time.Minute)
+		scheduler.fire()
+
+		first := queue.List()[0]
+		for first.Info().Status != StatusRunning {
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		now = now.Add(time.Minute)
+		scheduler.fire()
+
+		if jobs := queue.List(); len(jobs) != test.jobs {
+			t.Errorf("Expected %d job(s) with overlap %s, got %d", test.jobs, test.overlap, len(jobs))
+		}
+
+		if test.previousStatus == StatusCanceled {
+			<-first.Done()
+		}
+
+		if status := first.Info().Status; status != test.previousStatus {
+			t.Errorf("Expected the previous job to be %s with overlap %s, got %s", test.previousStatus, test.overlap, status)
+		}
+
+		if test.overlap == OverlapQueue {
+			if status := queue.List()[1].Info().Status; status != StatusQueued {
+				t.Errorf("Expected the next job to wait for the previous one, got %s", status)
+			}
+		}
+
+		queue.Close()
+	}
+}
+
+// TestSchedulerInvalid tests invalid schedules are rejected.
+func TestSchedulerInvalid(t *testing.T) {
+	scheduler := NewScheduler(NewQueue(1))
+
+	if err := scheduler.Add(Schedule{Cron: "* * * * *", Overlap: "wait"}); err == nil {
+		t.Error("Expected an invalid overlap policy to be rejected")
+	}
+
+	if err := scheduler.Add(Schedule{Cron: "every minute"}); err == nil {
+		t.Error("Expected an invalid cron expression to be rejected")
+	}
+}
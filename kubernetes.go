@@ -0,0 +1,167 @@
+package ansible
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+)
+
+const defaultKubernetesConnection = "community.kubernetes.kubectl"
+
+// KubernetesInventory generates an inventory of the pods, or with Nodes of
+// the nodes, of a kubeconfig context. Pods are reached with the kubectl
+// connection plugin and grouped by namespace and labels.
+type KubernetesInventory struct {
+	Kubectl       string
+	Kubeconfig    string
+	Context       string
+	Namespaces    []string
+	LabelSelector string
+	Container     string
+	Connection    string
+	Nodes         bool
+}
+
+type kubernetesList struct {
+	Items []struct {
+		Metadata struct {
+			Name      string            `json:"name"`
+			Namespace string            `json:"namespace"`
+			Labels    map[string]string `json:"labels"`
+		} `json:"metadata"`
+		Status struct {
+			Phase     string `json:"phase"`
+			Addresses []struct {
+				Type    string `json:"type"`
+				Address string `json:"address"`
+			} `json:"addresses"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+func (k *KubernetesInventory) Inventory() (*Inventory, error) {
+	if k.Nodes {
+		return k.nodeInventory()
+	}
+
+	return k.podInventory()
+}
+
+func (k *KubernetesInventory) kubectl(args ...string) (*kubernetesList, error) {
+	kubectl := k.Kubectl
+	if kubectl == "" {
+		kubectl = "kubectl"
+	}
+
+	if k.Kubeconfig != "" {
+		args = append(args, "--kubeconfig", k.Kubeconfig)
+	}
+
+	if k.Context != "" {
+		args = append(args, "--context", k.Context)
+	}
+
+	if k.LabelSelector != "" {
+		args = append(args, "--selector", k.LabelSelector)
+	}
+
+	output, err := exec.Command(kubectl, append(args, "--output", "json")...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list kubernetes resources: %w", err)
+	}
+
+	list := &kubernetesList{}
+	if err := json.Unmarshal(output, list); err != nil {
+		return nil, fmt.Errorf("failed to parse kubernetes resources: %w", err)
+	}
+
+	return list, nil
+}
+
+func (k *KubernetesInventory) podInventory() (*Inventory, error) {
+	connection := k.Connection
+	if connection == "" {
+		connection = defaultKubernetesConnection
+	}
+
+	var lists []*kubernetesList
+	if len(k.Namespaces) == 0 {
+		list, err := k.kubectl("get", "pods", "--all-namespaces")
+		if err != nil {
+			return nil, err
+		}
+
+		lists = append(lists, list)
+	}
+
+	for _, namespace := range k.Namespaces {
+		list, err := k.kubectl("get", "pods", "--namespace", namespace)
+		if err != nil {
+			return nil, err
+		}
+
+		lists = append(lists, list)
+	}
+
+	inventory := NewInventory()
+	for _, list := range lists {
+		for _, pod := range list.Items {
+			if pod.Status.Phase != "Running" {
+				continue
+			}
+
+			vars := map[string]interface{}{
+				"ansible_connection":        connection,
+				"ansible_kubectl_pod":       pod.Metadata.Name,
+				"ansible_kubectl_namespace": pod.Metadata.Namespace,
+			}
+
+			if k.Container != "" {
+				vars["ansible_kubectl_container"] = k.Container
+			}
+
+			if k.Context != "" {
+				vars["ansible_kubectl_context"] = k.Context
+			}
+
+			if k.Kubeconfig != "" {
+				vars["ansible_kubectl_kubeconfig"] = k.Kubeconfig
+			}
+
+			name := pod.Metadata.Namespace + "_" + pod.Metadata.Name
+			inventory.AddHost(name, vars, "namespace_"+pod.Metadata.Namespace)
+
+			for key, value := range pod.Metadata.Labels {
+				inventory.AddToGroup(tagGroup("label", key, value), name)
+			}
+		}
+	}
+
+	return inventory, nil
+}
+
+func (k *KubernetesInventory) nodeInventory() (*Inventory, error) {
+	list, err := k.kubectl("get", "nodes")
+	if err != nil {
+		return nil, err
+	}
+
+	inventory := NewInventory()
+	for _, node := range list.Items {
+		vars := map[string]interface{}{}
+		for _, address := range node.Status.Addresses {
+			if address.Type == "InternalIP" {
+				vars["ansible_host"] = address.Address
+				break
+			}
+		}
+
+		inventory.AddHost(node.Metadata.Name, vars, "nodes")
+
+		for key, value := range node.Metadata.Labels {
+			inventory.AddToGroup(tagGroup("label", key, value), node.Metadata.Name)
+		}
+	}
+
+	return inventory, nil
+}
@@ -0,0 +1,49 @@
+package ansible
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestKubernetesInventory tests generating an inventory of running pods.
+func TestKubernetesInventory(t *testing.T) {
+	kubectl := filepath.Join(t.TempDir(), "kubectl")
+
+	// The fake kubectl returns one running and one pending pod.
+	script := `#!/bin/sh
+cat <<'JSON'
+{"items": [
+  {"metadata": {"name": "web-0", "namespace": "shop", "labels": {"app": "web"}}, "status": {"phase": "Running"}},
+  {"metadata": {"name": "web-1", "namespace": "shop", "labels": {"app": "web"}}, "status": {"phase": "Pending"}}
+]}
+JSON
+`
+	if err := os.WriteFile(kubectl, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	source := &KubernetesInventory{
+		Kubectl: kubectl,
+		Context: "staging",
+	}
+
+	inventory, err := source.Inventory()
+	if err != nil {
+		t.Fatalf("Inventory() failed: %s", err)
+	}
+
+	// Assert that only the running pod is added with the kubectl connection.
+	vars, ok := inventory.Hosts["shop_web-0"]
+	if !ok || len(inventory.Hosts) != 1 {
+		t.Fatalf("Unexpected hosts: %+v", inventory.Hosts)
+	}
+
+	if vars["ansible_connection"] != defaultKubernetesConnection || vars["ansible_kubectl_context"] != "staging" {
+		t.Errorf("Unexpected host vars: %+v", vars)
+	}
+
+	if len(inventory.Groups["namespace_shop"]) != 1 || len(inventory.Groups["label_app_web"]) != 1 {
+		t.Errorf("Unexpected groups: %+v", inventory.Groups)
+	}
+}
@@ -0,0 +1,134 @@
+package ansible
+
+import (
+	"fmt"
+	"time"
+)
+
+// MaintenanceWindow is a recurring time window runs are allowed to execute
+// in, e.g. Saturdays from 22:00 for four hours.
+type MaintenanceWindow struct {
+	// Inventories the window applies to, every inventory if empty.
+	Inventories []string `json:"inventories,omitempty"`
+
+	// Days the window opens on, every day if empty.
+	Days []time.Weekday `json:"days,omitempty"`
+
+	// Start is the time of day the window opens, as 15:04 or 15:04:05.
+	Start string `json:"start"`
+
+	// Duration is how long the window stays open.
+	Duration time.Duration `json:"duration"`
+
+	// Location is the IANA time zone of Start, UTC if empty.
+	Location string `json:"location,omitempty"`
+}
+
+// applies reports whether the window applies to the inventory.
+func (w MaintenanceWindow) applies(inventory string) bool {
+	if len(w.Inventories) == 0 {
+		return true
+	}
+
+	for _, i := range w.Inventories {
+		if i == inventory {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Next returns the window containing t, or the next window after t, as the
+// time it opens and closes.
+func (w MaintenanceWindow) Next(t time.Time) (time.Time, time.Time, error) {
+	if w.Duration <= 0 {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: duration %s is not positive", ErrInvalidMaintenanceWindow, w.Duration)
+	}
+
+	location, err := time.LoadLocation(w.Location)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: %v", ErrInvalidMaintenanceWindow, err)
+	}
+
+	start, err := time.Parse("15:04:05", w.Start)
+	if err != nil {
+		start, err = time.Parse("15:04", w.Start)
+	}
+
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: start %q is not a time of day", ErrInvalidMaintenanceWindow, w.Start)
+	}
+
+	days := map[time.Weekday]bool{}
+	for _, day := range w.Days {
+		days[day] = true
+	}
+
+	// Windows which opened on earlier days may still be open.
+	t = t.In(location)
+	for offset := -int(w.Duration/(24*time.Hour)) - 1; offset <= 7; offset++ {
+		date := t.AddDate(0, 0, offset)
+		opens := time.Date(date.Year(), date.Month(), date.Day(), start.Hour(), start.Minute(), start.Second(), 0, location)
+		closes := opens.Add(w.Duration)
+
+		if (len(days) == 0 || days[opens.Weekday()]) && t.Before(closes) {
+			return opens, closes, nil
+		}
+	}
+
+	return time.Time{}, time.Time{}, fmt.Errorf("%w: never opens", ErrInvalidMaintenanceWindow)
+}
+
+// nextWindow returns the time the next maintenance window of the inventory
+// opens, which is t if a window is open, and whether the inventory is
+// restricted to maintenance windows at all.
+func (p *AnsiblePlaybook) nextWindow(inventory string, t time.Time) (time.Time, bool, error) {
+	var (
+		next       time.Time
+		restricted bool
+	)
+
+	for _, window := range p.Config.MaintenanceWindows {
+		if !window.applies(inventory) {
+			continue
+		}
+
+		restricted = true
+
+		opens, _, err := window.Next(t)
+		if err != nil {
+			return time.Time{}, true, err
+		}
+
+		if !opens.After(t) {
+			return t, true, nil
+		}
+
+		if next.IsZero() || opens.Before(next) {
+			next = opens
+		}
+	}
+
+	return next, restricted, nil
+}
+
+// checkMaintenanceWindow fails with ErrOutsideWindow if the inventory is
+// restricted to maintenance windows and none is open. With
+// WaitForMaintenanceWindow it waits until the next window opens instead.
+func (p *AnsiblePlaybook) checkMaintenanceWindow(inventory string) error {
+	now := time.Now()
+
+	next, restricted, err := p.nextWindow(inventory, now)
+	if err != nil || !restricted || !next.After(now) {
+		return err
+	}
+
+	if !p.Config.WaitForMaintenanceWindow {
+		return fmt.Errorf("%w: inventory %s, next window opens at %s", ErrOutsideWindow, inventory, next.Format(time.RFC3339))
+	}
+
+	fmt.Fprintf(p.output(), "waiting for the maintenance window of inventory %s at %s\n", inventory, next.Format(time.RFC3339))
+
+	return p.sleep(time.Until(next))
+}
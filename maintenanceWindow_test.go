@@ -0,0 +1,136 @@
+package ansible
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMaintenanceWindowNext tests the open or next window is found,
+// including windows spanning midnight and other time zones.
+func TestMaintenanceWindowNext(t *testing.T) {
+	saturday := time.Date(2024, 5, 4, 23, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		window MaintenanceWindow
+		t      time.Time
+		opens  time.Time
+	}{
+		{
+			name:   "open",
+			window: MaintenanceWindow{Days: []time.Weekday{time.Saturday}, Start: "22:00", Duration: 4 * time.Hour},
+			t:      saturday,
+			opens:  time.Date(2024, 5, 4, 22, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "past midnight",
+			window: MaintenanceWindow{Days: []time.Weekday{time.Saturday}, Start: "22:00", Duration: 4 * time.Hour},
+			t:      saturday.Add(2 * time.Hour),
+			opens:  time.Date(2024, 5, 4, 22, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "next week",
+			window: MaintenanceWindow{Days: []time.Weekday{time.Saturday}, Start: "22:00", Duration: 4 * time.Hour},
+			t:      saturday.Add(5 * time.Hour),
+			opens:  time.Date(2024, 5, 11, 22, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "every day",
+			window: MaintenanceWindow{Start: "02:00:30", Duration: time.Hour},
+			t:      saturday,
+			opens:  time.Date(2024, 5, 5, 2, 0, 30, 0, time.UTC),
+		},
+		{
+			name:   "time zone",
+			window: MaintenanceWindow{Start: "01:00", Duration: time.Hour, Location: "Europe/Zurich"},
+			t:      saturday,
+			opens:  time.Date(2024, 5, 4, 23, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, test := range tests {
+		opens, closes, err := test.window.Next(test.t)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+
+		if !opens.Equal(test.opens) || !closes.Equal(test.opens.Add(test.window.Duration)) {
+			t.Errorf("%s: expected the window at %s, got %s until %s", test.name, test.opens, opens, closes)
+		}
+	}
+
+	for _, window := range []MaintenanceWindow{
+		{Start: "25:00", Duration: time.Hour},
+		{Start: "22:00"},
+		{Start: "22:00", Duration: time.Hour, Location: "Mars/Olympus"},
+	} {
+		if _, _, err := window.Next(saturday); !errors.Is(err, ErrInvalidMaintenanceWindow) {
+			t.Errorf("Expected ErrInvalidMaintenanceWindow for %+v, got %v", window, err)
+		}
+	}
+}
+
+// TestMaintenanceWindows tests runs against inventories outside of their
+// windows fail or wait for the window to open.
+func TestMaintenanceWindows(t *testing.T) {
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "ansible-playbook"), []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	inventory := "tests/inventories/production"
+	later := time.Now().UTC().Add(time.Hour).Format("15:04")
+
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir: bin,
+			Inventories:   []string{inventory},
+			MaintenanceWindows: []MaintenanceWindow{
+				{Inventories: []string{"tests/inventories/staging"}, Start: "00:00", Duration: time.Minute},
+			},
+			Playbooks:        []string{"tests/test.yml"},
+			SkipVersionCheck: true,
+		},
+		Output: &bytes.Buffer{},
+	}
+
+	if err := playbook.Exec(); err != nil {
+		t.Fatalf("Expected inventories without windows to run, got %v", err)
+	}
+
+	playbook.Config.MaintenanceWindows = []MaintenanceWindow{
+		{Inventories: []string{inventory}, Start: later, Duration: time.Minute},
+	}
+
+	if err := playbook.Exec(); !errors.Is(err, ErrOutsideWindow) || errorClass(err) != "outside_window" {
+		t.Errorf("Expected ErrOutsideWindow, got %v", err)
+	}
+
+	soon := time.Now().UTC().Add(2 * time.Second)
+	playbook.Config.MaintenanceWindows = []MaintenanceWindow{
+		{Start: later, Duration: time.Minute},
+		{Start: soon.Format("15:04:05"), Duration: time.Minute},
+	}
+	playbook.Config.WaitForMaintenanceWindow = true
+
+	output := &bytes.Buffer{}
+	playbook.Output = output
+
+	if err := playbook.Exec(); err != nil {
+		t.Fatalf("Expected the run to wait for the window, got %v", err)
+	}
+
+	if time.Now().Before(soon.Truncate(time.Second)) {
+		t.Error("Expected the run to start once the window opened")
+	}
+
+	if !strings.Contains(output.String(), "waiting for the maintenance window of inventory "+inventory) {
+		t.Errorf("Expected the wait to be reported, got %q", output.String())
+	}
+}
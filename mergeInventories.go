@@ -0,0 +1,34 @@
+package ansible
+
+import "strings"
+
+// mergedInventorySeparator joins the inventories of a merged run to its name
+// in the results.
+const mergedInventorySeparator = " + "
+
+// runInventories returns the inventories which are run one after another.
+// With MergeInventories all inventories are passed to a single run, e.g. to
+// layer a dynamic inventory over static group vars.
+func (p *AnsiblePlaybook) runInventories() []string {
+	if p.Config.MergeInventories && len(p.Config.Inventories) > 1 {
+		return []string{strings.Join(p.Config.Inventories, mergedInventorySeparator)}
+	}
+
+	return p.Config.Inventories
+}
+
+// inventoryArgs returns the --inventory arguments of the run against the
+// inventory, one for every inventory of a merged run.
+func (p *AnsiblePlaybook) inventoryArgs(inventory string) []string {
+	inventories := []string{inventory}
+	if p.Config.MergeInventories && len(p.Config.Inventories) > 1 {
+		inventories = p.Config.Inventories
+	}
+
+	var args []string
+	for _, inventory := range inventories {
+		args = flagArg(args, "--inventory", inventory)
+	}
+
+	return args
+}
@@ -0,0 +1,73 @@
+package ansible
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestMergeInventories tests all inventories are passed to a single run.
+func TestMergeInventories(t *testing.T) {
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			Forks:            5,
+			Inventories:      []string{"static", "aws_ec2.yml"},
+			MergeInventories: true,
+			Playbooks:        []string{"tests/test.yml"},
+			SkipVersionCheck: true,
+		},
+	}
+
+	specs, err := playbook.BuildCommands()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	args := []string{"--inventory", "static", "--inventory", "aws_ec2.yml", "tests/test.yml"}
+	if len(specs) != 1 || !reflect.DeepEqual(specs[0].Args, args) {
+		t.Errorf("Expected a single command with args %v, got %v", args, specs)
+	}
+}
+
+// TestMergeInventoriesExec tests the result of a merged run is named after
+// all inventories.
+func TestMergeInventoriesExec(t *testing.T) {
+	bin := t.TempDir()
+	log := filepath.Join(bin, "calls.log")
+
+	script := "#!/bin/sh\necho \"$@\" >> " + log + "\n"
+	if err := os.WriteFile(filepath.Join(bin, "ansible-playbook"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir:    bin,
+			Inventories:      []string{"tests/inventories/production", "localhost,"},
+			MergeInventories: true,
+			Playbooks:        []string{"tests/test.yml"},
+			SkipVersionCheck: true,
+		},
+		Output: &bytes.Buffer{},
+	}
+
+	if err := playbook.Exec(); err != nil {
+		t.Fatalf("Exec should execute without error, but received: %v", err)
+	}
+
+	content, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if lines := strings.Split(strings.TrimSpace(string(content)), "\n"); len(lines) != 1 {
+		t.Errorf("Expected a single run, got %q", lines)
+	}
+
+	if len(playbook.Results) != 1 || playbook.Results[0].Inventory != "tests/inventories/production + localhost," {
+		t.Errorf("Unexpected results %+v", playbook.Results)
+	}
+}
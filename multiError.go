@@ -0,0 +1,65 @@
+package ansible
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// InventoryError is the error of the run against a single inventory.
+type InventoryError struct {
+	Inventory string
+	Err       error
+}
+
+func (e *InventoryError) Error() string {
+	return fmt.Sprintf("inventory %s: %v", e.Inventory, e.Err)
+}
+
+func (e *InventoryError) Unwrap() error {
+	return e.Err
+}
+
+// MultiError is returned by runs with ContinueOnError if the run against at
+// least one inventory failed.
+type MultiError struct {
+	Inventories int
+	Failures    []*InventoryError
+}
+
+func (e *MultiError) Error() string {
+	failures := make([]string, 0, len(e.Failures))
+	for _, failure := range e.Failures {
+		failures = append(failures, failure.Error())
+	}
+
+	return fmt.Sprintf(
+		"%d of %d inventories failed: %s",
+		len(e.Failures),
+		e.Inventories,
+		strings.Join(failures, "; "),
+	)
+}
+
+// Is reports whether the error of any failed inventory matches the target.
+func (e *MultiError) Is(target error) bool {
+	for _, failure := range e.Failures {
+		if errors.Is(failure, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// As finds the first error of the failed inventories which matches the
+// target and sets the target to it.
+func (e *MultiError) As(target interface{}) bool {
+	for _, failure := range e.Failures {
+		if errors.As(failure, target) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,57 @@
+package ansible
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestContinueOnError tests all inventories are run and the failures are
+// aggregated.
+func TestContinueOnError(t *testing.T) {
+	bin := t.TempDir()
+	log := filepath.Join(bin, "calls.log")
+
+	// The fake ansible-playbook binary fails for the inventories of the
+	// failing region.
+	script := "#!/bin/sh\necho \"$@\" >> " + log + "\ncase \"$*\" in *eu-fail*) exit 2;; esac\n"
+	if err := os.WriteFile(filepath.Join(bin, "ansible-playbook"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir:    bin,
+			ContinueOnError:  true,
+			Inventories:      []string{"tests/inventories/eu-fail", "tests/inventories/us-east", "tests/inventories/us-west"},
+			Playbooks:        []string{"tests/test.yml"},
+			SkipVersionCheck: true,
+		},
+	}
+
+	err := playbook.Exec()
+
+	var multi *MultiError
+	if !errors.As(err, &multi) {
+		t.Fatalf("Expected a MultiError, got %v", err)
+	}
+
+	if multi.Inventories != 3 || len(multi.Failures) != 1 || multi.Failures[0].Inventory != "tests/inventories/eu-fail" {
+		t.Errorf("Expected only inventory eu-fail to fail, got %v", err)
+	}
+
+	if len(playbook.Results) != 3 {
+		t.Errorf("Expected a result for every inventory, got %d", len(playbook.Results))
+	}
+
+	// Assert that the errors of the failed inventories can be inspected.
+	var commandErr *CommandError
+	if !errors.As(err, &commandErr) || commandErr.ExitCode != 2 {
+		t.Errorf("Expected the CommandError of the failed inventory, got %v", err)
+	}
+
+	if !errors.Is(err, multi.Failures[0]) {
+		t.Errorf("Expected the failure to match, got %v", err)
+	}
+}
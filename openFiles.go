@@ -0,0 +1,49 @@
+package ansible
+
+import (
+	"fmt"
+)
+
+// Rough number of file descriptors needed by the controller per fork and
+// in total, used to warn about limits which are too low for the forks.
+const (
+	filesPerFork = 8
+	filesBase    = 64
+)
+
+// openFilesLimit determines the open files limit of the spawned commands,
+// at most the hard limit, and warns if it is too low for the forks. The
+// limit is set by the shell wrapping the commands, the current process is
+// not affected.
+func (p *AnsiblePlaybook) openFilesLimit() error {
+	hard, err := openFilesHardLimit()
+	if err != nil {
+		return err
+	}
+
+	limit := p.Config.OpenFilesLimit
+	if limit > hard {
+		limit = hard
+
+		fmt.Fprintf(
+			p.output(),
+			"[WARNING]: open files limit %d exceeds the hard limit, using %d\n",
+			p.Config.OpenFilesLimit,
+			limit,
+		)
+	}
+
+	p.openFiles = limit
+
+	if needed := uint64(p.Config.Forks)*filesPerFork + filesBase; needed > limit {
+		fmt.Fprintf(
+			p.output(),
+			"[WARNING]: %d forks need about %d open files, but the limit is %d\n",
+			p.Config.Forks,
+			needed,
+			limit,
+		)
+	}
+
+	return nil
+}
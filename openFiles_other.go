@@ -0,0 +1,13 @@
+//go:build !linux && !darwin
+
+package ansible
+
+import (
+	"errors"
+)
+
+// openFilesHardLimit fails, setting the open files limit is only supported
+// on Linux and macOS.
+func openFilesHardLimit() (uint64, error) {
+	return 0, errors.New("setting the open files limit is only supported on linux and macos")
+}
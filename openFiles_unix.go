@@ -0,0 +1,19 @@
+//go:build linux || darwin
+
+package ansible
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// openFilesHardLimit returns the hard open files limit of the current
+// process, which the limit of the spawned commands cannot exceed.
+func openFilesHardLimit() (uint64, error) {
+	var rlimit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rlimit); err != nil {
+		return 0, fmt.Errorf("failed to get open files limit: %w", err)
+	}
+
+	return uint64(rlimit.Max), nil
+}
@@ -0,0 +1,107 @@
+//go:build linux || darwin
+
+package ansible
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// TestOpenFilesLimit tests the spawned commands inherit the raised limit and
+// too many forks are warned about.
+func TestOpenFilesLimit(t *testing.T) {
+	var rlimit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rlimit); err != nil {
+		t.Fatal(err)
+	}
+
+	if rlimit.Max > 1<<20 {
+		t.Skip("hard open files limit is unlimited")
+	}
+
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "ansible-playbook"), []byte("#!/bin/sh\necho \"nofile=$(ulimit -n)\"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	var output bytes.Buffer
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir:    bin,
+			Forks:            int(rlimit.Max),
+			Inventories:      []string{"tests/inventories/production"},
+			OpenFilesLimit:   uint64(rlimit.Max) + 1,
+			Playbooks:        []string{"tests/test.yml"},
+			SkipVersionCheck: true,
+		},
+		Output: &output,
+	}
+
+	if err := playbook.Exec(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{
+		"nofile=" + strconv.FormatUint(uint64(rlimit.Max), 10),
+		"exceeds the hard limit",
+		"open files, but the limit is",
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("Expected output to contain '%s', got '%s'", expected, output.String())
+		}
+	}
+}
+
+// TestOpenFilesLimitCommandOnly tests the configured limit is set for the
+// spawned commands even if it is below the limit of the current process,
+// which is left unchanged.
+func TestOpenFilesLimitCommandOnly(t *testing.T) {
+	var before syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &before); err != nil {
+		t.Fatal(err)
+	}
+
+	if before.Cur <= 256 {
+		t.Skip("soft open files limit is too low")
+	}
+
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "ansible-playbook"), []byte("#!/bin/sh\necho \"nofile=$(ulimit -n)\"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	var output bytes.Buffer
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir:    bin,
+			Forks:            1,
+			Inventories:      []string{"tests/inventories/production"},
+			OpenFilesLimit:   256,
+			Playbooks:        []string{"tests/test.yml"},
+			SkipVersionCheck: true,
+		},
+		Output: &output,
+	}
+
+	if err := playbook.Exec(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(output.String(), "nofile=256\n") {
+		t.Errorf("Expected output to contain 'nofile=256', got '%s'", output.String())
+	}
+
+	var after syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &after); err != nil {
+		t.Fatal(err)
+	}
+
+	if after != before {
+		t.Errorf("Expected the limit of the current process to be %+v, got %+v", before, after)
+	}
+}
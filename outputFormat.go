@@ -0,0 +1,77 @@
+package ansible
+
+import (
+	"bytes"
+	"regexp"
+	"time"
+)
+
+// ColorMode controls the colored output of the ansible commands.
+type ColorMode string
+
+// Color modes. ColorAlways is the default, so colors survive the output
+// being piped into the run, unless ANSIBLE_FORCE_COLOR is set in the
+// environment.
+const (
+	ColorAlways ColorMode = ""
+	ColorNever  ColorMode = "never"
+	ColorAuto   ColorMode = "auto"
+)
+
+// bannerPattern matches the trailing stars of play, task and handler
+// banners.
+var bannerPattern = regexp.MustCompile(`^((?:\x1b\[[0-9;]*m)*(?:PLAY|TASK|RUNNING HANDLER)\b.*?) \*+((?:\x1b\[[0-9;]*m)*)\s*$`)
+
+// colorEnv adds the environment variables of the color mode.
+func (p *AnsiblePlaybook) colorEnv(b *EnvBuilder) {
+	switch p.Config.Color {
+	case ColorNever:
+		b.Set(EnvSourceOutput, "ANSIBLE_FORCE_COLOR", "0")
+		b.Set(EnvSourceOutput, "ANSIBLE_NOCOLOR", "1")
+	case ColorAuto:
+	default:
+		b.Default(EnvSourceOutput, "ANSIBLE_FORCE_COLOR", "1")
+	}
+}
+
+// formatsLines reports whether the output is written line by line.
+func (p *AnsiblePlaybook) formatsLines() bool {
+	return p.Config.NoLogSensitive || p.Config.Timestamps || p.Config.CompactBanners
+}
+
+// format redacts the output and applies the line formatting.
+func (p *AnsiblePlaybook) format(output []byte) []byte {
+	output = p.redact(output)
+	if !p.Config.Timestamps && !p.Config.CompactBanners {
+		return output
+	}
+
+	var (
+		formatted []byte
+		now       = time.Now().Format(time.RFC3339)
+	)
+
+	for len(output) > 0 {
+		line := output
+		if end := bytes.IndexByte(output, '\n'); end >= 0 {
+			line = output[:end+1]
+		}
+
+		output = output[len(line):]
+
+		if p.Config.Timestamps {
+			formatted = append(formatted, now+" "...)
+		}
+
+		if p.Config.CompactBanners {
+			content := bytes.TrimRight(line, "\r\n")
+			if match := bannerPattern.FindSubmatch(content); match != nil {
+				line = append(append(append([]byte{}, match[1]...), match[2]...), line[len(content):]...)
+			}
+		}
+
+		formatted = append(formatted, line...)
+	}
+
+	return formatted
+}
@@ -0,0 +1,58 @@
+package ansible
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// TestOutputFormat tests the output lines are prefixed with timestamps and
+// the banners are compacted.
+func TestOutputFormat(t *testing.T) {
+	bin := t.TempDir()
+
+	script := `#!/bin/sh
+echo "$ANSIBLE_FORCE_COLOR $ANSIBLE_NOCOLOR"
+printf '\nPLAY [web] ***********\n\nTASK [Install nginx] *****\nok: [web1]\n'
+printf 'PLAY RECAP ***\nweb1 : ok=1 changed=0 unreachable=0 failed=0'
+`
+	if err := os.WriteFile(filepath.Join(bin, "ansible-playbook"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	output := &bytes.Buffer{}
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir:    bin,
+			Color:            ColorNever,
+			CompactBanners:   true,
+			Inventories:      []string{"tests/inventories/production"},
+			Playbooks:        []string{"tests/test.yml"},
+			SkipVersionCheck: true,
+			Timestamps:       true,
+		},
+		Output: output,
+	}
+
+	if err := playbook.Exec(); err != nil {
+		t.Fatal(err)
+	}
+
+	timestamp := `\d{4}-\d\d-\d\dT\d\d:\d\d:\d\d\S* `
+	for _, expected := range []string{"0 1", "PLAY [web]", "TASK [Install nginx]", "PLAY RECAP", "web1 : ok=1 changed=0 unreachable=0 failed=0"} {
+		if !regexp.MustCompile(`(?m)^` + timestamp + regexp.QuoteMeta(expected) + `$`).MatchString(output.String()) {
+			t.Errorf("Expected a timestamped line '%s', got '%s'", expected, output)
+		}
+	}
+
+	if strings.Contains(output.String(), "*") {
+		t.Errorf("Expected compact banners, got '%s'", output)
+	}
+
+	if stats := playbook.Results[0].Stats["web1"]; stats.Ok != 1 {
+		t.Errorf("Expected the recap to be parsed, got %+v", playbook.Results[0])
+	}
+}
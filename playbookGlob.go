@@ -0,0 +1,93 @@
+package ansible
+
+import (
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// globRecursive returns the files matching a pattern which may contain **
+// segments matching any number of directories, e.g. playbooks/**/*.yml.
+func globRecursive(pattern string) ([]string, error) {
+	segments := strings.Split(filepath.ToSlash(filepath.Clean(pattern)), "/")
+
+	var base []string
+	for _, segment := range segments {
+		if strings.ContainsAny(segment, "*?[") {
+			break
+		}
+
+		base = append(base, segment)
+	}
+
+	root := filepath.FromSlash(strings.Join(base, "/"))
+	switch {
+	case len(base) == 1 && base[0] == "":
+		root = string(filepath.Separator)
+	case len(base) == 0:
+		root = "."
+	}
+
+	var files []string
+	err := filepath.WalkDir(root, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+
+		if !entry.IsDir() && matchPath(pattern, file) {
+			files = append(files, file)
+		}
+
+		return nil
+	})
+
+	return files, err
+}
+
+// matchPath reports whether the path matches the pattern, where a **
+// segment matches any number of directories.
+func matchPath(pattern, file string) bool {
+	return matchSegments(
+		strings.Split(filepath.ToSlash(filepath.Clean(pattern)), "/"),
+		strings.Split(filepath.ToSlash(filepath.Clean(file)), "/"),
+	)
+}
+
+func matchSegments(pattern, file []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(file); i++ {
+				if matchSegments(pattern[1:], file[i:]) {
+					return true
+				}
+			}
+
+			return false
+		}
+
+		if len(file) == 0 {
+			return false
+		}
+
+		if ok, _ := path.Match(pattern[0], file[0]); !ok {
+			return false
+		}
+
+		pattern, file = pattern[1:], file[1:]
+	}
+
+	return len(file) == 0
+}
+
+// excludedPlaybook reports whether the playbook matches an exclude pattern,
+// which is resolved like the playbook patterns.
+func (p *AnsiblePlaybook) excludedPlaybook(playbook string) bool {
+	for _, exclude := range p.Config.PlaybookExcludes {
+		if matchPath(p.playbookPath(exclude), playbook) {
+			return true
+		}
+	}
+
+	return false
+}
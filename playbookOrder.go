@@ -0,0 +1,56 @@
+package ansible
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// orderPlaybooks sorts the playbooks by their position in the playbook order
+// file, which lists one playbook per line relative to the playbook directory.
+// Empty lines and lines starting with # are ignored. Playbooks which are not
+// listed run after the listed ones in their resolved order.
+func (p *AnsiblePlaybook) orderPlaybooks(playbooks []string) ([]string, error) {
+	file, err := os.Open(p.Config.PlaybookOrderFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read playbook order file: %w", err)
+	}
+	defer file.Close()
+
+	positions := map[string]int{}
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		path := filepath.Clean(p.playbookPath(line))
+		if _, ok := positions[path]; !ok {
+			positions[path] = len(positions)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read playbook order file: %w", err)
+	}
+
+	position := func(playbook string) int {
+		if i, ok := positions[filepath.Clean(playbook)]; ok {
+			return i
+		}
+
+		return len(positions)
+	}
+
+	ordered := append([]string{}, playbooks...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return position(ordered[i]) < position(ordered[j])
+	})
+
+	return ordered, nil
+}
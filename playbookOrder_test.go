@@ -0,0 +1,94 @@
+package ansible
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestPlaybookOrder tests overlapping patterns resolve to sorted unique
+// playbooks, ordered by the playbook order file if configured.
+func TestPlaybookOrder(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, name := range []string{"c.yml", "a.yml", "b.yml"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("---\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			PlaybookDir: dir,
+			Playbooks:   []string{"b.yml", "*.yml"},
+		},
+	}
+
+	if err := playbook.playbooks(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{filepath.Join(dir, "b.yml"), filepath.Join(dir, "a.yml"), filepath.Join(dir, "c.yml")}
+	if !reflect.DeepEqual(playbook.Config.Playbooks, expected) {
+		t.Errorf("Expected playbooks %v, got %v", expected, playbook.Config.Playbooks)
+	}
+
+	order := filepath.Join(dir, "order.txt")
+	if err := os.WriteFile(order, []byte("# deploy order\nc.yml\n\na.yml\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	playbook.Config.Playbooks = []string{"*.yml"}
+	playbook.Config.PlaybookOrderFile = order
+
+	if err := playbook.playbooks(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected = []string{filepath.Join(dir, "c.yml"), filepath.Join(dir, "a.yml"), filepath.Join(dir, "b.yml")}
+	if !reflect.DeepEqual(playbook.Config.Playbooks, expected) {
+		t.Errorf("Expected playbooks %v, got %v", expected, playbook.Config.Playbooks)
+	}
+}
+
+// TestRecursivePlaybooks tests ** patterns match playbooks in all
+// directories except the excluded ones.
+func TestRecursivePlaybooks(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, name := range []string{
+		"site.yml",
+		"apps/web.yml",
+		"apps/db/main.yml",
+		"roles/nginx/tests/test.yml",
+		"molecule/default/converge.yml",
+		"apps/README.md",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte("---\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			PlaybookDir:      dir,
+			PlaybookExcludes: []string{"roles/**/tests/*.yml", "molecule/**"},
+			Playbooks:        []string{"**/*.yml"},
+		},
+	}
+
+	if err := playbook.playbooks(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{filepath.Join(dir, "apps/db/main.yml"), filepath.Join(dir, "apps/web.yml"), filepath.Join(dir, "site.yml")}
+	if !reflect.DeepEqual(playbook.Config.Playbooks, expected) {
+		t.Errorf("Expected playbooks %v, got %v", expected, playbook.Config.Playbooks)
+	}
+}
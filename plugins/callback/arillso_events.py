@@ -0,0 +1,115 @@
+# Streams the task events of a run as JSON lines to the unix socket in
+# ARILLSO_EVENTS_SOCKET, so the events do not depend on the stdout callback.
+from __future__ import absolute_import, division, print_function
+__metaclass__ = type
+
+DOCUMENTATION = '''
+    name: arillso_events
+    type: notification
+    short_description: streams task events to a unix socket
+    description:
+      - Sends every task event as a JSON line to the unix socket in the
+        ARILLSO_EVENTS_SOCKET environment variable.
+'''
+
+import datetime
+import json
+import os
+import socket
+
+from ansible.plugins.callback import CallbackBase
+
+
+class CallbackModule(CallbackBase):
+    CALLBACK_VERSION = 2.0
+    CALLBACK_TYPE = 'notification'
+    CALLBACK_NAME = 'arillso_events'
+    CALLBACK_NEEDS_ENABLED = True
+    CALLBACK_NEEDS_WHITELIST = True
+
+    def __init__(self):
+        super(CallbackModule, self).__init__()
+        self._play = ''
+        self._socket = None
+
+        path = os.environ.get('ARILLSO_EVENTS_SOCKET')
+        if not path:
+            return
+
+        try:
+            self._socket = socket.socket(socket.AF_UNIX, socket.SOCK_STREAM)
+            self._socket.connect(path)
+        except socket.error as e:
+            self._display.warning('failed to connect to the event socket: %s' % e)
+            self._socket = None
+
+    def _send(self, event, **fields):
+        if self._socket is None:
+            return
+
+        fields['event'] = event
+        fields['time'] = datetime.datetime.utcnow().isoformat() + 'Z'
+
+        try:
+            self._socket.sendall((json.dumps(fields) + '\n').encode('utf-8'))
+        except socket.error as e:
+            self._display.warning('failed to send to the event socket: %s' % e)
+            self._socket = None
+
+    def _send_result(self, status, result, **fields):
+        self._send(
+            'task_result',
+            play=self._play,
+            task=result._task.get_name().strip(),
+            host=result._host.get_name(),
+            status=status,
+            changed=bool(result._result.get('changed', False)),
+            msg=str(result._result.get('msg', '')),
+            **fields
+        )
+
+    def v2_playbook_on_start(self, playbook):
+        self._send('playbook_start', playbook=playbook._file_name)
+
+    def v2_playbook_on_play_start(self, play):
+        self._play = play.get_name().strip()
+        self._send('play_start', play=self._play)
+
+    def v2_playbook_on_task_start(self, task, is_conditional):
+        self._send('task_start', play=self._play, task=task.get_name().strip())
+
+    def v2_playbook_on_handler_task_start(self, task):
+        self._send('handler_start', play=self._play, task=task.get_name().strip())
+
+    def v2_runner_on_ok(self, result):
+        status = 'changed' if result._result.get('changed', False) else 'ok'
+        self._send_result(status, result)
+
+    def v2_runner_on_failed(self, result, ignore_errors=False):
+        self._send_result('failed', result, ignored=ignore_errors)
+
+    def v2_runner_on_skipped(self, result):
+        self._send_result('skipped', result)
+
+    def v2_runner_on_unreachable(self, result):
+        self._send_result('unreachable', result)
+
+    def v2_playbook_on_stats(self, stats):
+        summary = {}
+        for host in sorted(stats.processed.keys()):
+            s = stats.summarize(host)
+            summary[host] = {
+                'ok': s['ok'],
+                'changed': s['changed'],
+                'unreachable': s['unreachable'],
+                'failed': s['failures'],
+                'skipped': s['skipped'],
+                'rescued': s.get('rescued', 0),
+                'ignored': s.get('ignored', 0),
+            }
+
+        self._send('stats', stats=summary)
+
+        if self._socket is not None:
+            self._socket.close()
+            self._socket = None
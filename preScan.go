@@ -0,0 +1,200 @@
+package ansible
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultPreScanConcurrency = 32
+	defaultPreScanTimeout     = 3 * time.Second
+)
+
+// sshConnections are the connection plugins the pre-scan checks, other
+// connections like local or docker are skipped.
+var sshConnections = map[string]bool{
+	"":                             true,
+	"smart":                        true,
+	"ssh":                          true,
+	"paramiko":                     true,
+	"paramiko_ssh":                 true,
+	"ansible.builtin.ssh":          true,
+	"ansible.builtin.paramiko_ssh": true,
+}
+
+// HostScan is the connectivity of a host checked by the pre-scan.
+type HostScan struct {
+	Host      string `json:"host"`
+	Address   string `json:"address"`
+	Reachable bool   `json:"reachable"`
+	Banner    string `json:"banner,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// UnreachableHostsError is returned if the pre-scan found unreachable hosts
+// which are not excluded from the run.
+type UnreachableHostsError struct {
+	Inventory string
+	Hosts     []HostScan
+}
+
+func (e *UnreachableHostsError) Error() string {
+	hosts := make([]string, len(e.Hosts))
+	for i, host := range e.Hosts {
+		hosts[i] = host.Host + " (" + host.Error + ")"
+	}
+
+	return fmt.Sprintf("unreachable hosts in inventory %s: %s", e.Inventory, strings.Join(hosts, ", "))
+}
+
+// ScanHosts connects to the SSH port of every host of the inventory matching
+// the limit and waits for the SSH banner. The hosts are scanned concurrently
+// with a short timeout, so unreachable hosts are found before the run.
+// Hosts with a connection other than SSH are skipped.
+func (p *AnsiblePlaybook) ScanHosts(inventory string) ([]HostScan, error) {
+	view, err := p.listInventory(p.inventoryArgs(inventory))
+	if err != nil {
+		return nil, err
+	}
+
+	var scans []HostScan
+	for _, host := range view.Hosts() {
+		vars := view.HostVars[host]
+
+		connection := p.Config.Connection
+		if c, ok := vars["ansible_connection"].(string); ok {
+			connection = c
+		}
+
+		if !sshConnections[connection] {
+			continue
+		}
+
+		address := host
+		if a, ok := vars["ansible_host"].(string); ok && a != "" {
+			address = a
+		}
+
+		port := "22"
+		for _, name := range []string{"ansible_port", "ansible_ssh_port"} {
+			if value, ok := vars[name]; ok {
+				port = fmt.Sprint(value)
+				break
+			}
+		}
+
+		scans = append(scans, HostScan{Host: host, Address: net.JoinHostPort(address, port)})
+	}
+
+	concurrency := p.Config.PreScanConcurrency
+	if concurrency <= 0 {
+		concurrency = defaultPreScanConcurrency
+	}
+
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, concurrency)
+	)
+
+	for i := range scans {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(scan *HostScan) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			p.scanHost(scan)
+		}(&scans[i])
+	}
+
+	wg.Wait()
+
+	return scans, nil
+}
+
+// scanHost connects to the host and reads the SSH banner.
+func (p *AnsiblePlaybook) scanHost(scan *HostScan) {
+	timeout := p.Config.PreScanTimeout
+	if timeout <= 0 {
+		timeout = defaultPreScanTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(p.context(), timeout)
+	defer cancel()
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", scan.Address)
+	if err != nil {
+		scan.Error = err.Error()
+		return
+	}
+
+	defer conn.Close()
+
+	deadline, _ := ctx.Deadline()
+	conn.SetReadDeadline(deadline)
+
+	banner, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		scan.Error = "no ssh banner: " + err.Error()
+		return
+	}
+
+	banner = strings.TrimSpace(banner)
+	if !strings.HasPrefix(banner, "SSH-") {
+		scan.Error = "no ssh banner: " + strconv.Quote(banner)
+		return
+	}
+
+	scan.Reachable = true
+	scan.Banner = banner
+}
+
+// preScan scans the hosts of the inventory. Unreachable hosts fail the run
+// with an UnreachableHostsError, or with PreScanExclude are excluded from it
+// by the limit. The returned function restores the limit.
+func (p *AnsiblePlaybook) preScan(inventory string) (func(), error) {
+	scans, err := p.ScanHosts(inventory)
+	if err != nil {
+		return nil, err
+	}
+
+	var unreachable []HostScan
+	for _, scan := range scans {
+		if !scan.Reachable {
+			unreachable = append(unreachable, scan)
+		}
+	}
+
+	if len(unreachable) == 0 {
+		return func() {}, nil
+	}
+
+	if !p.Config.PreScanExclude || len(unreachable) == len(scans) {
+		return nil, &UnreachableHostsError{Inventory: inventory, Hosts: unreachable}
+	}
+
+	limit := p.Config.Limit
+	if limit == "" {
+		limit = "all"
+	}
+
+	hosts := make([]string, len(unreachable))
+	for i, scan := range unreachable {
+		hosts[i] = scan.Host
+		limit += ",!" + scan.Host
+	}
+
+	fmt.Fprintf(p.output(), "pre-scan: excluding unreachable hosts %s\n", strings.Join(hosts, ", "))
+
+	previous := p.Config.Limit
+	p.Config.Limit = limit
+
+	return func() { p.Config.Limit = previous }, nil
+}
@@ -0,0 +1,130 @@
+package ansible
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// listen accepts connections on a local port and greets them with the
+// banner.
+func listen(t *testing.T, banner string) int {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			conn.Write([]byte(banner))
+			conn.Close()
+		}
+	}()
+
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+// TestPreScan tests unreachable hosts fail the run or are excluded from it.
+func TestPreScan(t *testing.T) {
+	ssh := listen(t, "SSH-2.0-OpenSSH_9.6\r\n")
+	http := listen(t, "HTTP/1.1 400 Bad Request\r\n")
+
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	down := closed.Addr().(*net.TCPAddr).Port
+	closed.Close()
+
+	bin := t.TempDir()
+	log := filepath.Join(bin, "limit.log")
+
+	inventory := fmt.Sprintf(`{
+  "_meta": {"hostvars": {
+    "web1": {"ansible_host": "127.0.0.1", "ansible_port": %d},
+    "web2": {"ansible_host": "127.0.0.1", "ansible_port": %d},
+    "web3": {"ansible_host": "127.0.0.1", "ansible_port": %d},
+    "db1": {"ansible_connection": "local"}
+  }},
+  "all": {"children": ["ungrouped"]},
+  "ungrouped": {"hosts": ["db1", "web1", "web2", "web3"]}
+}`, ssh, http, down)
+
+	scripts := map[string]string{
+		"ansible-inventory": "#!/bin/sh\ncat <<'EOF'\n" + inventory + "\nEOF\n",
+		"ansible-playbook": `#!/bin/sh
+while [ $# -gt 0 ]; do [ "$1" = "--limit" ] && limit="$2"; shift; done
+echo "$limit" >> ` + log + `
+`,
+	}
+
+	for name, script := range scripts {
+		if err := os.WriteFile(filepath.Join(bin, name), []byte(script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir:    bin,
+			Inventories:      []string{"tests/inventories/production"},
+			Playbooks:        []string{"tests/test.yml"},
+			PreScan:          true,
+			PreScanTimeout:   time.Second,
+			SkipVersionCheck: true,
+		},
+		Output: &bytes.Buffer{},
+	}
+
+	scans, err := playbook.ScanHosts("tests/inventories/production")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(scans) != 3 || !scans[0].Reachable || scans[0].Banner != "SSH-2.0-OpenSSH_9.6" || scans[1].Reachable || scans[2].Reachable {
+		t.Errorf("Unexpected scans %+v", scans)
+	}
+
+	var unreachable *UnreachableHostsError
+	if err := playbook.Exec(); !errors.As(err, &unreachable) || len(unreachable.Hosts) != 2 || errorClass(err) != "unreachable_hosts" {
+		t.Fatalf("Expected the unreachable hosts web2 and web3, got %v", err)
+	}
+
+	if _, err := os.Stat(log); err == nil {
+		t.Error("Expected the playbooks not to run")
+	}
+
+	playbook.Config.Limit = "web"
+	playbook.Config.PreScanExclude = true
+
+	if err := playbook.Exec(); err != nil {
+		t.Fatalf("Exec should execute without error, but received: %v", err)
+	}
+
+	content, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if limit := strings.TrimSpace(string(content)); limit != "web,!web2,!web3" {
+		t.Errorf("Expected the unreachable hosts to be excluded, got %q", limit)
+	}
+
+	if playbook.Config.Limit != "web" {
+		t.Errorf("Expected the limit to be restored, got %q", playbook.Config.Limit)
+	}
+}
@@ -0,0 +1,46 @@
+package ansible
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// decryptPrivateKey writes a copy of the private key file without its
+// passphrase to the run directory and uses it for the run, since ansible
+// can not pass a passphrase to ssh. The passphrase is only passed to
+// ssh-keygen for the duration of the conversion.
+func (p *AnsiblePlaybook) decryptPrivateKey() error {
+	content, err := os.ReadFile(p.Config.PrivateKeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to read private key file: %w", err)
+	}
+
+	file, err := p.tempFile("privateKey", content)
+	if err != nil {
+		return fmt.Errorf("failed to write private key file: %w", err)
+	}
+
+	var output bytes.Buffer
+	cmd := exec.Command(
+		"ssh-keygen",
+		"-p",
+		"-q",
+		"-P",
+		p.Config.PrivateKeyPassphrase,
+		"-N",
+		"",
+		"-f",
+		file,
+	)
+
+	if err := p.runOutput(cmd, &output); err != nil {
+		os.Remove(file)
+		return fmt.Errorf("failed to decrypt private key: %s: %w", strings.TrimSpace(output.String()), err)
+	}
+
+	p.Config.PrivateKeyFile = file
+	return nil
+}
@@ -0,0 +1,64 @@
+package ansible
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestPrivateKeyPassphrase tests the playbooks run with a decrypted copy of
+// an encrypted private key, which is removed after the run.
+func TestPrivateKeyPassphrase(t *testing.T) {
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen not found")
+	}
+
+	dir := t.TempDir()
+	key := filepath.Join(dir, "id_ecdsa")
+
+	if output, err := exec.Command("ssh-keygen", "-q", "-t", "ecdsa", "-N", "secret", "-f", key).CombinedOutput(); err != nil {
+		t.Fatalf("failed to generate key: %s", output)
+	}
+
+	script := `#!/bin/sh
+while [ $# -gt 0 ]; do [ "$1" = "--private-key" ] && key="$2"; shift; done
+echo "key=$key"
+ssh-keygen -y -P "" -f "$key" > /dev/null && echo decrypted
+`
+	if err := os.WriteFile(filepath.Join(dir, "ansible-playbook"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	output := &bytes.Buffer{}
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir:        dir,
+			Inventories:          []string{"tests/inventories/production"},
+			Playbooks:            []string{"tests/test.yml"},
+			PrivateKeyFile:       key,
+			PrivateKeyPassphrase: "secret",
+			SkipVersionCheck:     true,
+		},
+		Output: output,
+	}
+
+	if err := playbook.Exec(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(output.String(), "decrypted") || strings.Contains(output.String(), "key="+key+"\n") {
+		t.Errorf("Expected a decrypted copy of the key, got '%s'", output)
+	}
+
+	if playbook.Config.PrivateKeyFile != key {
+		t.Errorf("Expected the private key file to be restored, got %s", playbook.Config.PrivateKeyFile)
+	}
+
+	playbook.Config.PrivateKeyPassphrase = "wrong"
+	if err := playbook.Exec(); err == nil || !strings.Contains(err.Error(), "failed to decrypt private key") {
+		t.Errorf("Expected a decryption error, got %v", err)
+	}
+}
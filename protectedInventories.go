@@ -0,0 +1,61 @@
+package ansible
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// ProtectedInventoryError is returned if a run against a protected inventory
+// is neither in check mode nor confirmed by the override.
+type ProtectedInventoryError struct {
+	Inventory string
+	Pattern   string
+}
+
+func (e *ProtectedInventoryError) Error() string {
+	return fmt.Sprintf(
+		"inventory %s is protected by %q, run in check mode or set the protected override to %s",
+		e.Inventory,
+		e.Pattern,
+		e.Inventory,
+	)
+}
+
+// checkProtectedInventories fails for every inventory matching a protected
+// pattern unless the run does not change hosts, i.e. runs in check mode or
+// only lists or checks the playbooks, or the override names the inventory.
+// Patterns are matched against the path and the base name of the inventory.
+func (p *AnsiblePlaybook) checkProtectedInventories() error {
+	if p.Config.Check || p.Config.SyntaxCheck || p.Config.ListHosts || p.Config.ListTasks || p.Config.ListTags {
+		return nil
+	}
+
+	for _, inventory := range p.Config.Inventories {
+		if inventory == p.Config.ProtectedOverride {
+			continue
+		}
+
+		for _, pattern := range p.Config.ProtectedInventories {
+			if !protectedMatch(pattern, inventory) {
+				continue
+			}
+
+			return &ProtectedInventoryError{
+				Inventory: inventory,
+				Pattern:   pattern,
+			}
+		}
+	}
+
+	return nil
+}
+
+func protectedMatch(pattern, inventory string) bool {
+	for _, name := range []string{inventory, filepath.Base(inventory)} {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,43 @@
+package ansible
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestProtectedInventories tests real runs against protected inventories
+// are only built in check mode or with the override.
+func TestProtectedInventories(t *testing.T) {
+	for _, test := range []struct {
+		config    Config
+		protected bool
+	}{
+		{Config{}, true},
+		{Config{Check: true}, false},
+		{Config{ListHosts: true}, false},
+		{Config{ProtectedOverride: "inventories/production"}, false},
+		{Config{ProtectedOverride: "inventories/staging"}, true},
+		{Config{ProtectedInventories: []string{"prod-*"}}, false},
+	} {
+		config := test.config
+		config.Inventories = []string{"inventories/staging", "inventories/production"}
+		config.Playbooks = []string{"tests/test.yml"}
+		config.SkipVersionCheck = true
+
+		if config.ProtectedInventories == nil {
+			config.ProtectedInventories = []string{"*prod*"}
+		}
+
+		playbook := &AnsiblePlaybook{Config: config}
+		_, err := playbook.BuildCommands()
+
+		var protected *ProtectedInventoryError
+		if errors.As(err, &protected) != test.protected {
+			t.Errorf("Expected protected %t for %+v, got %v", test.protected, test.config, err)
+		}
+
+		if test.protected && protected.Inventory != "inventories/production" {
+			t.Errorf("Expected production to be protected, got %s", protected.Inventory)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package ansible
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const redacted = "********"
+
+// sensitiveKeyPattern matches the names of extra vars whose values are
+// redacted from the output.
+var sensitiveKeyPattern = regexp.MustCompile(`(?i)pass|secret|token|credential|private_?key|api_?key`)
+
+// registerSecret adds a value which is redacted from all output of the run.
+func (p *AnsiblePlaybook) registerSecret(value string) {
+	if value == "" {
+		return
+	}
+
+	for _, secret := range p.secrets {
+		if secret == value {
+			return
+		}
+	}
+
+	p.secrets = append(p.secrets, value)
+}
+
+// registerConfigSecrets registers the secrets of the configuration, i.e. the
+// vault password, the galaxy api key and the values of sensitive extra vars.
+func (p *AnsiblePlaybook) registerConfigSecrets() {
+	p.registerSecret(p.Config.VaultPassword)
+	p.registerSecret(p.Config.GalaxyAPIKey)
+	p.registerSecret(p.Config.PrivateKeyPassphrase)
+
+	for _, server := range p.Config.GalaxyServers {
+		p.registerSecret(server.Token)
+		p.registerSecret(server.Password)
+	}
+
+	for _, extraVars := range p.Config.ExtraVars {
+		for _, value := range sensitiveExtraVars(extraVars) {
+			p.registerSecret(value)
+		}
+	}
+}
+
+// sensitiveExtraVars returns the values of the sensitive variables of an
+// extra vars argument in the key=value or the JSON format.
+func sensitiveExtraVars(extraVars string) []string {
+	var values []string
+
+	if strings.HasPrefix(strings.TrimSpace(extraVars), "{") {
+		var vars map[string]interface{}
+		if err := json.Unmarshal([]byte(extraVars), &vars); err != nil {
+			return nil
+		}
+
+		for key, value := range vars {
+			if s, ok := value.(string); ok && sensitiveKeyPattern.MatchString(key) {
+				values = append(values, s)
+			}
+		}
+
+		return values
+	}
+
+	for _, field := range strings.Fields(extraVars) {
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) == 2 && sensitiveKeyPattern.MatchString(parts[0]) {
+			values = append(values, strings.Trim(parts[1], `'"`))
+		}
+	}
+
+	return values
+}
+
+// redact replaces the registered secrets in the output.
+func (p *AnsiblePlaybook) redact(output []byte) []byte {
+	if !p.Config.NoLogSensitive || len(p.secrets) == 0 {
+		return output
+	}
+
+	for _, secret := range p.secrets {
+		output = bytes.ReplaceAll(output, []byte(secret), []byte(redacted))
+	}
+
+	return output
+}
+
+// stdout returns the writer for the output of the run. With NoLogSensitive,
+// Timestamps or CompactBanners the output is written line by line with all
+// registered secrets redacted and the lines formatted, so it has to be
+// flushed once the command finished.
+func (p *AnsiblePlaybook) stdout() *redactWriter {
+	return &redactWriter{
+		playbook: p,
+		w:        p.output(),
+	}
+}
+
+type redactWriter struct {
+	mu       sync.Mutex
+	playbook *AnsiblePlaybook
+	w        io.Writer
+	buf      []byte
+}
+
+func (r *redactWriter) Write(b []byte) (int, error) {
+	if !r.playbook.formatsLines() {
+		return r.w.Write(b)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.buf = append(r.buf, b...)
+
+	// Secrets never span lines, so only complete lines are written.
+	end := bytes.LastIndexByte(r.buf, '\n')
+	if end < 0 {
+		return len(b), nil
+	}
+
+	_, err := r.w.Write(r.playbook.format(r.buf[:end+1]))
+	r.buf = append(r.buf[:0], r.buf[end+1:]...)
+
+	return len(b), err
+}
+
+// Flush writes the remaining incomplete line.
+func (r *redactWriter) Flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.buf) == 0 {
+		return nil
+	}
+
+	_, err := r.w.Write(r.playbook.format(r.buf))
+	r.buf = nil
+
+	return err
+}
@@ -0,0 +1,69 @@
+package ansible
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// TestSensitiveExtraVars tests sensitive values are found in both extra vars
+// formats.
+func TestSensitiveExtraVars(t *testing.T) {
+	tests := []struct {
+		extraVars string
+		expected  []string
+	}{
+		{"user=admin db_password=hunter2 api_token='abc'", []string{"abc", "hunter2"}},
+		{`{"user": "admin", "client_secret": "s3cr3t", "port": 5432}`, []string{"s3cr3t"}},
+		{"@vars.yml", nil},
+	}
+
+	for _, test := range tests {
+		values := sensitiveExtraVars(test.extraVars)
+		sort.Strings(values)
+
+		if !reflect.DeepEqual(values, test.expected) {
+			t.Errorf("Expected %v for '%s', got %v", test.expected, test.extraVars, values)
+		}
+	}
+}
+
+// TestRedactWriter tests secrets split over several writes are redacted.
+func TestRedactWriter(t *testing.T) {
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			NoLogSensitive: true,
+			ExtraVars:      []string{"db_password=hunter2"},
+			VaultPassword:  "vaultpass",
+		},
+	}
+	playbook.registerConfigSecrets()
+
+	var output bytes.Buffer
+	writer := &redactWriter{playbook: playbook, w: &output}
+
+	for _, chunk := range []string{"login with hun", "ter2\nvault: vault", "pass"} {
+		if _, err := writer.Write([]byte(chunk)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "login with ********\nvault: ********"; output.String() != expected {
+		t.Errorf("Expected output '%s', got '%s'", expected, output.String())
+	}
+}
+
+// TestRedactDisabled tests the output is unchanged without NoLogSensitive.
+func TestRedactDisabled(t *testing.T) {
+	playbook := &AnsiblePlaybook{}
+	playbook.registerSecret("hunter2")
+
+	if output := string(playbook.redact([]byte("hunter2"))); output != "hunter2" {
+		t.Errorf("Expected output to be unchanged, got '%s'", output)
+	}
+}
@@ -0,0 +1,7 @@
+package ansible
+
+// hasResourceLimits reports whether resource limits are configured for the
+// spawned commands, which are applied to their processes once started.
+func (p *AnsiblePlaybook) hasResourceLimits() bool {
+	return p.Config.Nice != 0 || len(p.Config.CPUAffinity) > 0 || p.Config.MemoryLimit > 0
+}
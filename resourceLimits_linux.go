@@ -0,0 +1,167 @@
+package ansible
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+const cgroupRoot = "/sys/fs/cgroup"
+
+// applyResourceLimits applies the configured niceness, CPU affinity and
+// memory limit to a started process. The limits are inherited by the
+// processes it forks. The memory limit is applied with a cgroup v2 if the
+// cgroup of the current process is delegated with the memory controller,
+// and as RLIMIT_AS with a warning otherwise. The returned function removes
+// the cgroup once the process exited.
+func (p *AnsiblePlaybook) applyResourceLimits(pid int) (func(), error) {
+	cleanup := func() {}
+
+	if p.Config.Nice != 0 {
+		if err := syscall.Setpriority(syscall.PRIO_PROCESS, pid, p.Config.Nice); err != nil {
+			return cleanup, fmt.Errorf("failed to set niceness: %w", err)
+		}
+	}
+
+	if len(p.Config.CPUAffinity) > 0 {
+		if err := setAffinity(pid, p.Config.CPUAffinity); err != nil {
+			return cleanup, err
+		}
+	}
+
+	if p.Config.MemoryLimit > 0 {
+		dir, err := memoryCgroup(pid, p.Config.MemoryLimit)
+		if err == nil {
+			return func() { os.Remove(dir) }, nil
+		}
+
+		fmt.Fprintf(
+			p.output(),
+			"[WARNING]: memory cgroup is not available (%v), limiting the address space instead\n",
+			err,
+		)
+
+		if err := prlimit(pid, syscall.RLIMIT_AS, uint64(p.Config.MemoryLimit)); err != nil {
+			return cleanup, fmt.Errorf("failed to set memory limit: %w", err)
+		}
+	}
+
+	return cleanup, nil
+}
+
+// memoryUlimit returns no shell command, the memory limit is applied by
+// applyResourceLimits.
+func memoryUlimit(limit int64) string {
+	return ""
+}
+
+func setAffinity(pid int, cpus []int) error {
+	const bits = int(unsafe.Sizeof(uintptr(0)) * 8)
+
+	var mask [1024 / bits]uintptr
+	for _, cpu := range cpus {
+		if cpu < 0 || cpu >= len(mask)*bits {
+			return fmt.Errorf("invalid cpu %d", cpu)
+		}
+
+		mask[cpu/bits] |= 1 << uint(cpu%bits)
+	}
+
+	_, _, errno := syscall.RawSyscall(
+		syscall.SYS_SCHED_SETAFFINITY,
+		uintptr(pid),
+		unsafe.Sizeof(mask),
+		uintptr(unsafe.Pointer(&mask[0])),
+	)
+	if errno != 0 {
+		return fmt.Errorf("failed to set cpu affinity: %w", errno)
+	}
+
+	return nil
+}
+
+func prlimit(pid, resource int, limit uint64) error {
+	rlimit := syscall.Rlimit{Cur: limit, Max: limit}
+
+	_, _, errno := syscall.RawSyscall6(
+		syscall.SYS_PRLIMIT64,
+		uintptr(pid),
+		uintptr(resource),
+		uintptr(unsafe.Pointer(&rlimit)),
+		0,
+		0,
+		0,
+	)
+	if errno != 0 {
+		return errno
+	}
+
+	return nil
+}
+
+// memoryCgroup moves the process into a new cgroup below the cgroup of the
+// current process with the memory limit.
+func memoryCgroup(pid int, limit int64) (string, error) {
+	if _, err := os.Stat(filepath.Join(cgroupRoot, "cgroup.controllers")); err != nil {
+		return "", errors.New("cgroup v2 is not available")
+	}
+
+	parent, err := currentCgroup()
+	if err != nil {
+		return "", err
+	}
+
+	controllers, err := os.ReadFile(filepath.Join(cgroupRoot, parent, "cgroup.subtree_control"))
+	if err != nil {
+		return "", err
+	}
+
+	enabled := false
+	for _, controller := range strings.Fields(string(controllers)) {
+		enabled = enabled || controller == "memory"
+	}
+
+	if !enabled {
+		return "", fmt.Errorf("memory controller is not enabled below %s", parent)
+	}
+
+	dir := filepath.Join(cgroupRoot, parent, fmt.Sprintf("ansible-%d", pid))
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		return "", err
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "memory.max"), []byte(strconv.FormatInt(limit, 10)), 0o644); err != nil {
+		os.Remove(dir)
+		return "", err
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "cgroup.procs"), []byte(strconv.Itoa(pid)), 0o644); err != nil {
+		os.Remove(dir)
+		return "", err
+	}
+
+	return dir, nil
+}
+
+func currentCgroup() (string, error) {
+	file, err := os.Open("/proc/self/cgroup")
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if path := strings.TrimPrefix(scanner.Text(), "0::"); path != scanner.Text() {
+			return path, nil
+		}
+	}
+
+	return "", errors.New("cgroup v2 of the current process not found")
+}
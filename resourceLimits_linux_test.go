@@ -0,0 +1,91 @@
+package ansible
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestResourceLimits tests the limits are applied to the spawned process
+// before it is executed.
+func TestResourceLimits(t *testing.T) {
+	bin := t.TempDir()
+
+	script := `#!/bin/sh
+echo "nice=$(cut -d' ' -f19 /proc/$$/stat)"
+grep Cpus_allowed_list /proc/$$/status
+grep "Max address space" /proc/$$/limits
+cat /proc/$$/cgroup
+`
+	if err := os.WriteFile(filepath.Join(bin, "ansible-playbook"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	var output bytes.Buffer
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir:    bin,
+			CPUAffinity:      []int{0},
+			Inventories:      []string{"tests/inventories/production"},
+			MemoryLimit:      4 << 30,
+			Nice:             10,
+			Playbooks:        []string{"tests/test.yml"},
+			SkipVersionCheck: true,
+		},
+		Output: &output,
+	}
+
+	if err := playbook.Exec(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{"nice=10", "Cpus_allowed_list:\t0\n"} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("Expected output to contain '%s', got '%s'", expected, output.String())
+		}
+	}
+
+	// Without a delegated cgroup the memory limit falls back to RLIMIT_AS
+	// with a warning.
+	if strings.Contains(output.String(), "/ansible-") {
+		return
+	}
+
+	for _, expected := range []string{"4294967296", "[WARNING]: memory cgroup is not available"} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("Expected output to contain '%s', got '%s'", expected, output.String())
+		}
+	}
+}
+
+// TestResourceLimitsFailure tests the command is not executed if the limits
+// cannot be applied.
+func TestResourceLimitsFailure(t *testing.T) {
+	bin := t.TempDir()
+	marker := filepath.Join(t.TempDir(), "executed")
+
+	if err := os.WriteFile(filepath.Join(bin, "ansible-playbook"), []byte("#!/bin/sh\ntouch "+marker+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir:    bin,
+			CPUAffinity:      []int{-1},
+			Inventories:      []string{"tests/inventories/production"},
+			Playbooks:        []string{"tests/test.yml"},
+			SkipVersionCheck: true,
+		},
+		Output: &bytes.Buffer{},
+	}
+
+	if err := playbook.Exec(); err == nil || !strings.Contains(err.Error(), "invalid cpu") {
+		t.Fatalf("Expected an invalid cpu error, got %v", err)
+	}
+
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("Expected the command not to be executed")
+	}
+}
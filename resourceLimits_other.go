@@ -0,0 +1,34 @@
+//go:build !linux && !windows
+
+package ansible
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+)
+
+// applyResourceLimits applies the configured niceness to a started process.
+// The memory limit is set as RLIMIT_AS by the shell wrapping the command,
+// CPU affinity is only supported on Linux.
+func (p *AnsiblePlaybook) applyResourceLimits(pid int) (func(), error) {
+	cleanup := func() {}
+
+	if len(p.Config.CPUAffinity) > 0 {
+		return cleanup, errors.New("cpu affinity is only supported on linux")
+	}
+
+	if p.Config.Nice != 0 {
+		if err := syscall.Setpriority(syscall.PRIO_PROCESS, pid, p.Config.Nice); err != nil {
+			return cleanup, fmt.Errorf("failed to set niceness: %w", err)
+		}
+	}
+
+	return cleanup, nil
+}
+
+// memoryUlimit returns the shell command limiting the address space of the
+// wrapped command, ulimit takes the limit in KiB.
+func memoryUlimit(limit int64) string {
+	return fmt.Sprintf("ulimit -v %d || exit 125\n", limit/1024)
+}
@@ -0,0 +1,69 @@
+//go:build !windows
+
+package ansible
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// commandHold keeps a wrapped command from executing until it is released.
+type commandHold struct {
+	reader *os.File
+	writer *os.File
+}
+
+func (h *commandHold) release() error {
+	_, err := h.writer.Write([]byte("\n"))
+	return err
+}
+
+func (h *commandHold) close() {
+	h.reader.Close()
+	h.writer.Close()
+}
+
+// wrapCommand runs the command through a shell which sets the rlimits of
+// the command before executing it, so they apply to the command and every
+// process it forks, but not to the current process. If resource limits are
+// configured, the shell waits until they are applied to its process and the
+// returned hold is released.
+func (p *AnsiblePlaybook) wrapCommand(cmd *exec.Cmd) (*commandHold, error) {
+	var script string
+	if p.openFiles > 0 {
+		script += fmt.Sprintf("ulimit -S -n %d || exit 125\n", p.openFiles)
+	}
+
+	if p.Config.MemoryLimit > 0 {
+		script += memoryUlimit(p.Config.MemoryLimit)
+	}
+
+	if script == "" && !p.hasResourceLimits() {
+		return nil, nil
+	}
+
+	// Leave commands which are not found to fail on start.
+	if _, err := exec.LookPath(cmd.Path); err != nil {
+		return nil, nil
+	}
+
+	var hold *commandHold
+	if p.hasResourceLimits() {
+		reader, writer, err := os.Pipe()
+		if err != nil {
+			return nil, err
+		}
+
+		fd := 3 + len(cmd.ExtraFiles)
+		cmd.ExtraFiles = append(cmd.ExtraFiles, reader)
+
+		script = fmt.Sprintf("read _ <&%d || exit 125\nexec %d<&-\n", fd, fd) + script
+		hold = &commandHold{reader: reader, writer: writer}
+	}
+
+	cmd.Args = append([]string{cmd.Args[0], "-c", script + `exec "$0" "$@"`, cmd.Path}, cmd.Args[1:]...)
+	cmd.Path = "/bin/sh"
+
+	return hold, nil
+}
@@ -0,0 +1,30 @@
+package ansible
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// commandHold is never created, commands are not wrapped on Windows.
+type commandHold struct{}
+
+func (h *commandHold) release() error {
+	return nil
+}
+
+func (h *commandHold) close() {}
+
+// wrapCommand fails if resource limits are configured, they are not
+// supported on Windows.
+func (p *AnsiblePlaybook) wrapCommand(cmd *exec.Cmd) (*commandHold, error) {
+	if p.hasResourceLimits() || p.openFiles > 0 {
+		return nil, errors.New("resource limits are not supported on windows")
+	}
+
+	return nil, nil
+}
+
+// applyResourceLimits fails, resource limits are not supported on Windows.
+func (p *AnsiblePlaybook) applyResourceLimits(pid int) (func(), error) {
+	return func() {}, errors.New("resource limits are not supported on windows")
+}
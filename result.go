@@ -0,0 +1,274 @@
+package ansible
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Task statuses reported by the default stdout callback.
+const (
+	StatusOk          = "ok"
+	StatusChanged     = "changed"
+	StatusSkipped     = "skipped"
+	StatusFailed      = "failed"
+	StatusUnreachable = "unreachable"
+)
+
+var (
+	ansiEscapePattern  = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+	playLinePattern    = regexp.MustCompile(`^PLAY \[(.*)\] \**$`)
+	taskLinePattern    = regexp.MustCompile(`^(?:TASK|RUNNING HANDLER) \[(.*)\] \**$`)
+	statusLinePattern  = regexp.MustCompile(`^(ok|changed|skipping|failed|fatal): \[([^\]]+)\](.*)$`)
+	recapLinePattern   = regexp.MustCompile(`^(\S+)\s+:\s+(.*=\d+.*)$`)
+	profileLinePattern = regexp.MustCompile(`^(.+?) -{2,} (\d+\.\d+)s$`)
+	statusPrecedence   = map[string]int{
+		StatusSkipped:     0,
+		StatusOk:          1,
+		StatusChanged:     2,
+		StatusFailed:      3,
+		StatusUnreachable: 4,
+	}
+)
+
+// TaskResult is the status of a task on a single host.
+type TaskResult struct {
+	Play   string `json:"play"`
+	Task   string `json:"task"`
+	Host   string `json:"host"`
+	Status string `json:"status"`
+}
+
+// HostStats are the counters of the play recap of a single host.
+type HostStats struct {
+	Ok          int `json:"ok"`
+	Changed     int `json:"changed"`
+	Unreachable int `json:"unreachable"`
+	Failed      int `json:"failed"`
+	Skipped     int `json:"skipped"`
+	Rescued     int `json:"rescued"`
+	Ignored     int `json:"ignored"`
+}
+
+// TaskDuration is the duration of a task reported by the profile_tasks
+// callback.
+type TaskDuration struct {
+	Task     string        `json:"task"`
+	Duration time.Duration `json:"duration"`
+}
+
+// RunResult is the result of a playbook run against one inventory.
+type RunResult struct {
+	RunID        string               `json:"run_id,omitempty"`
+	Inventory    string               `json:"inventory"`
+	Tasks        []TaskResult         `json:"tasks"`
+	Stats        map[string]HostStats `json:"stats"`
+	Durations    []TaskDuration       `json:"durations,omitempty"`
+	Deprecations []string             `json:"deprecations,omitempty"`
+	Warnings     []Warning            `json:"warnings,omitempty"`
+}
+
+// ParseRunResult builds a run result from the output of the default or the
+// JSON stdout callback.
+func ParseRunResult(output []byte) *RunResult {
+	if result, ok := parseJSONCallback(output); ok {
+		result.Deprecations = parseMessages(output, deprecationMarker)
+		result.Warnings = parseWarnings(output)
+		return result
+	}
+
+	var (
+		play, task string
+		index      = map[[3]string]int{}
+	)
+
+	result := &RunResult{
+		Stats: map[string]HostStats{},
+	}
+
+	recap := false
+	scanner := bufio.NewScanner(bytes.NewReader(ansiEscapePattern.ReplaceAll(output, nil)))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if strings.HasPrefix(line, "PLAY RECAP") {
+			recap = true
+			continue
+		}
+
+		if match := playLinePattern.FindStringSubmatch(line); match != nil {
+			play, recap = match[1], false
+			continue
+		}
+
+		if match := taskLinePattern.FindStringSubmatch(line); match != nil {
+			task = match[1]
+			continue
+		}
+
+		if recap {
+			if match := recapLinePattern.FindStringSubmatch(line); match != nil {
+				result.Stats[match[1]] = parseHostStats(match[2])
+				continue
+			}
+
+			if match := profileLinePattern.FindStringSubmatch(line); match != nil {
+				seconds, _ := strconv.ParseFloat(match[2], 64)
+				result.Durations = append(result.Durations, TaskDuration{
+					Task:     match[1],
+					Duration: time.Duration(math.Round(seconds*1000)) * time.Millisecond,
+				})
+			}
+
+			continue
+		}
+
+		match := statusLinePattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+
+		status := match[1]
+		switch {
+		case status == "skipping":
+			status = StatusSkipped
+		case status == "fatal" && strings.Contains(match[3], "UNREACHABLE!"):
+			status = StatusUnreachable
+		case status == "fatal":
+			status = StatusFailed
+		}
+
+		host := strings.SplitN(match[2], " -> ", 2)[0]
+		key := [3]string{play, task, host}
+
+		// Loops report one line per item, keep the most severe status.
+		if i, ok := index[key]; ok {
+			if statusPrecedence[status] > statusPrecedence[result.Tasks[i].Status] {
+				result.Tasks[i].Status = status
+			}
+
+			continue
+		}
+
+		index[key] = len(result.Tasks)
+		result.Tasks = append(result.Tasks, TaskResult{
+			Play:   play,
+			Task:   task,
+			Host:   host,
+			Status: status,
+		})
+	}
+
+	result.Deprecations = parseMessages(output, deprecationMarker)
+	result.Warnings = parseWarnings(output)
+	return result
+}
+
+func parseHostStats(line string) HostStats {
+	var stats HostStats
+
+	for _, field := range strings.Fields(line) {
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		n, err := strconv.Atoi(parts[1])
+		if err != nil {
+			continue
+		}
+
+		switch parts[0] {
+		case "ok":
+			stats.Ok = n
+		case "changed":
+			stats.Changed = n
+		case "unreachable":
+			stats.Unreachable = n
+		case "failed":
+			stats.Failed = n
+		case "skipped":
+			stats.Skipped = n
+		case "rescued":
+			stats.Rescued = n
+		case "ignored":
+			stats.Ignored = n
+		}
+	}
+
+	return stats
+}
+
+// Filter returns the task results with the given status.
+func (r *RunResult) Filter(status string) []TaskResult {
+	var tasks []TaskResult
+
+	for _, task := range r.Tasks {
+		if task.Status == status {
+			tasks = append(tasks, task)
+		}
+	}
+
+	return tasks
+}
+
+// changes returns the changed tasks of the run. If no changed tasks were
+// parsed, e.g. because the stdout callback hides them, every change counted
+// by the play recap is returned without the play and task.
+func (r *RunResult) changes() []TaskResult {
+	if changes := r.Filter(StatusChanged); len(changes) > 0 {
+		return changes
+	}
+
+	hosts := map[string]bool{}
+	for host := range r.Stats {
+		hosts[host] = true
+	}
+
+	var changes []TaskResult
+	for _, host := range sortedKeys(hosts) {
+		for i := 0; i < r.Stats[host].Changed; i++ {
+			changes = append(changes, TaskResult{Host: host, Status: StatusChanged})
+		}
+	}
+
+	return changes
+}
+
+// Save writes the run result as JSON to the given path.
+func (r *RunResult) Save(path string) error {
+	content, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode run result: %w", err)
+	}
+
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		return fmt.Errorf("failed to write run result: %w", err)
+	}
+
+	return nil
+}
+
+// LoadRunResult reads a run result stored with Save.
+func LoadRunResult(path string) (*RunResult, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read run result: %w", err)
+	}
+
+	result := &RunResult{}
+	if err := json.Unmarshal(content, result); err != nil {
+		return nil, fmt.Errorf("failed to parse run result: %w", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,107 @@
+package ansible
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testOutput = `
+PLAY [Deploy] ******************************************************************
+
+TASK [Install packages] ********************************************************
+ok: [web1] => (item=curl)
+changed: [web1] => (item=nginx)
+skipping: [web2]
+fatal: [db1]: UNREACHABLE! => {"changed": false, "unreachable": true}
+
+TASK [Start service] ***********************************************************
+fatal: [web1]: FAILED! => {"changed": false, "msg": "failed"}
+
+PLAY RECAP *********************************************************************
+db1                        : ok=0    changed=0    unreachable=1    failed=0    skipped=0    rescued=0    ignored=0
+web1                       : ok=1    changed=1    unreachable=0    failed=1    skipped=0    rescued=0    ignored=0
+web2                       : ok=0    changed=0    unreachable=0    failed=0    skipped=1    rescued=0    ignored=0
+`
+
+// TestParseRunResult tests parsing task statuses and the play recap.
+func TestParseRunResult(t *testing.T) {
+	result := ParseRunResult([]byte(testOutput))
+
+	expected := []TaskResult{
+		{Play: "Deploy", Task: "Install packages", Host: "web1", Status: StatusChanged},
+		{Play: "Deploy", Task: "Install packages", Host: "web2", Status: StatusSkipped},
+		{Play: "Deploy", Task: "Install packages", Host: "db1", Status: StatusUnreachable},
+		{Play: "Deploy", Task: "Start service", Host: "web1", Status: StatusFailed},
+	}
+
+	if len(result.Tasks) != len(expected) {
+		t.Fatalf("Expected %d tasks, got %+v", len(expected), result.Tasks)
+	}
+
+	for i := range expected {
+		if result.Tasks[i] != expected[i] {
+			t.Errorf("Expected task %+v, got %+v", expected[i], result.Tasks[i])
+		}
+	}
+
+	// Assert that the recap was parsed.
+	if result.Stats["web1"].Failed != 1 || result.Stats["db1"].Unreachable != 1 {
+		t.Errorf("Unexpected stats: %+v", result.Stats)
+	}
+}
+
+// TestRunResultSave tests storing and loading a run result.
+func TestRunResultSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "result.json")
+
+	result := ParseRunResult([]byte(testOutput))
+	result.Inventory = "production"
+
+	if err := result.Save(path); err != nil {
+		t.Fatalf("Save failed: %s", err)
+	}
+
+	loaded, err := LoadRunResult(path)
+	if err != nil {
+		t.Fatalf("LoadRunResult failed: %s", err)
+	}
+
+	if loaded.Inventory != "production" || len(loaded.Tasks) != len(result.Tasks) {
+		t.Errorf("Loaded result differs: %+v", loaded)
+	}
+}
+
+// TestParseRunResultDurations tests parsing the profile_tasks summary.
+func TestParseRunResultDurations(t *testing.T) {
+	output := []byte(`
+PLAY RECAP *********************************************************************
+web1                       : ok=2    changed=1    unreachable=0    failed=0
+
+Thursday 16 October 2026  10:00:05 +0000 (0:00:04.120)       0:00:05.350 ******
+===============================================================================
+nginx : Install packages ------------------------------------------------ 4.12s
+Gathering Facts --------------------------------------------------------- 1.23s
+`)
+
+	result := ParseRunResult(output)
+
+	expected := []TaskDuration{
+		{Task: "nginx : Install packages", Duration: 4120 * time.Millisecond},
+		{Task: "Gathering Facts", Duration: 1230 * time.Millisecond},
+	}
+
+	if len(result.Durations) != len(expected) {
+		t.Fatalf("Expected %d durations, got %+v", len(expected), result.Durations)
+	}
+
+	for i := range expected {
+		if result.Durations[i] != expected[i] {
+			t.Errorf("Expected duration %+v, got %+v", expected[i], result.Durations[i])
+		}
+	}
+
+	if result.Stats["web1"].Changed != 1 {
+		t.Errorf("Unexpected stats: %+v", result.Stats)
+	}
+}
@@ -0,0 +1,37 @@
+package ansible
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// rollback runs the rollback playbooks against the inventory of a failed run.
+// The failure context is passed as extra vars rollback_inventory,
+// rollback_failed_hosts and rollback_error.
+func (p *AnsiblePlaybook) rollback(inventory string, result *RunResult, cause error) error {
+	playbooks := p.globPlaybooks(p.Config.RollbackPlaybooks)
+	if len(playbooks) == 0 {
+		return fmt.Errorf("failed to find rollback playbook files: %w", cause)
+	}
+
+	vars, err := json.Marshal(map[string]interface{}{
+		"rollback_inventory":    inventory,
+		"rollback_failed_hosts": failedHosts([]*RunResult{result}),
+		"rollback_error":        cause.Error(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode rollback vars: %w", err)
+	}
+
+	err = p.withConfig(func(config *Config) {
+		config.Playbooks = playbooks
+		config.ExtraVars = append(append([]string{}, config.ExtraVars...), string(vars))
+	}, func() error {
+		return p.run(p.ansibleCommand(inventory))
+	})
+	if err != nil {
+		return fmt.Errorf("rollback failed with %s: %w", err, cause)
+	}
+
+	return fmt.Errorf("run failed and was rolled back: %w", cause)
+}
@@ -0,0 +1,78 @@
+package ansible
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestRollback tests that rollback playbooks run after a failed run.
+func TestRollback(t *testing.T) {
+	bin := t.TempDir()
+	log := filepath.Join(bin, "args.log")
+
+	// The fake playbook run fails unless the rollback playbook is executed.
+	script := `#!/bin/sh
+echo "$@" >> ` + log + `
+case "$*" in *rollback.yml*) exit 0;; esac
+echo "PLAY RECAP ***"
+echo "web1 : ok=0 changed=0 unreachable=0 failed=1"
+exit 2
+`
+	if err := os.WriteFile(filepath.Join(bin, "ansible-playbook"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(bin, "ansible"), []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	rollbackPlaybook := filepath.Join(bin, "rollback.yml")
+	if err := os.WriteFile(rollbackPlaybook, []byte("---\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	output := &bytes.Buffer{}
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir:     bin,
+			ExtraVars:         []string{"version=1.2.3"},
+			Inventories:       []string{"tests/inventories/production"},
+			Playbooks:         []string{"tests/test.yml"},
+			RollbackPlaybooks: []string{rollbackPlaybook},
+			RunID:             "run-1",
+		},
+		Output: output,
+	}
+
+	if err := playbook.Exec(); err == nil {
+		t.Error("Exec should return the error of the failed run")
+	}
+
+	content, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected the run and the rollback, got:\n%s", content)
+	}
+
+	// Assert that the rollback received the original and the failure vars.
+	for _, expected := range []string{"version=1.2.3", `"rollback_failed_hosts":["web1"]`, `"rollback_inventory":"tests/inventories/production"`} {
+		if !strings.Contains(lines[1], expected) {
+			t.Errorf("Expected rollback args to contain '%s', got '%s'", expected, lines[1])
+		}
+	}
+	// Assert that the rollback ran as part of the run.
+	if !strings.Contains(output.String(), "rollback.yml") || !strings.Contains(output.String(), "# run run-1") {
+		t.Errorf("Expected the rollback in the output of the run, got %q", output.String())
+	}
+
+	if len(playbook.Config.Playbooks) != 1 || len(playbook.Config.ExtraVars) != 1 {
+		t.Errorf("Expected the configuration to be restored, got %+v", playbook.Config)
+	}
+}
@@ -0,0 +1,267 @@
+package ansible
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var hostCountPattern = regexp.MustCompile(`^hosts \(\d+\):$`)
+
+// Wave is one step of a rollout. Either Limit selects the hosts of the wave
+// with a host pattern, or Percent selects the share of all hosts which has
+// to be done once the wave finished.
+type Wave struct {
+	Name    string
+	Limit   string
+	Percent int
+}
+
+// RolloutError is returned if a wave exceeded the abort thresholds.
+type RolloutError struct {
+	Wave   string
+	Failed []string
+}
+
+func (e *RolloutError) Error() string {
+	return fmt.Sprintf(
+		"rollout aborted after wave %s, %d host(s) failed: %s",
+		e.Wave,
+		len(e.Failed),
+		strings.Join(e.Failed, ", "),
+	)
+}
+
+// Rollout runs a playbook in waves, e.g. canary, 10%, 50% and 100% of the
+// hosts, and stops if a wave fails.
+type Rollout struct {
+	Playbook *AnsiblePlaybook
+	Waves    []Wave
+
+	// HealthCheck is called after every wave. An error aborts the rollout.
+	HealthCheck func(wave Wave, results []*RunResult) error
+
+	// MaxFailedHosts is the number of failed hosts tolerated per wave, and
+	// MaxFailPercentage, if set, additionally limits their share.
+	MaxFailedHosts    int
+	MaxFailPercentage float64
+}
+
+func (r *Rollout) Exec() error {
+	return r.ExecContext(context.Background())
+}
+
+// ExecContext runs the rollout like Exec. If the context is canceled, the
+// running wave is killed and the context error is returned.
+func (r *Rollout) ExecContext(ctx context.Context) error {
+	if len(r.Waves) == 0 {
+		return errors.New("rollout requires at least one wave")
+	}
+
+	for _, wave := range r.Waves {
+		if wave.Limit == "" && wave.Percent <= 0 {
+			return fmt.Errorf("wave %s requires a limit or a percentage", wave.Name)
+		}
+	}
+
+	r.Playbook.ctx = ctx
+	defer func() { r.Playbook.ctx = nil }()
+
+	r.Playbook.startRun()
+
+	hosts := map[string][]string{}
+	for _, inventory := range r.Playbook.Config.Inventories {
+		list, err := r.Playbook.listHosts(inventory, r.Playbook.Config.Limit)
+		if err != nil {
+			return err
+		}
+
+		hosts[inventory] = list
+	}
+
+	done := map[string]map[string]bool{}
+	for i, wave := range r.Waves {
+		var results []*RunResult
+
+		for _, inventory := range r.Playbook.Config.Inventories {
+			selected, err := r.waveHosts(wave, inventory, hosts[inventory])
+			if err != nil {
+				return err
+			}
+
+			if done[inventory] == nil {
+				done[inventory] = map[string]bool{}
+			}
+
+			var limit []string
+			for _, host := range selected {
+				if !done[inventory][host] {
+					done[inventory][host] = true
+					limit = append(limit, host)
+				}
+			}
+
+			if len(limit) == 0 {
+				continue
+			}
+
+			playbook := r.Playbook.subRun()
+			playbook.Config.Inventories = []string{inventory}
+			playbook.Config.Limit = strings.Join(limit, ",")
+
+			// Dependencies only need to be installed once.
+			if i > 0 {
+				playbook.Config.GalaxyFile = ""
+			}
+
+			err = playbook.ExecContext(ctx)
+			results = append(results, playbook.Results...)
+			r.Playbook.Results = append(r.Playbook.Results, playbook.Results...)
+
+			if err != nil && len(failedHosts(playbook.Results)) == 0 {
+				return err
+			}
+		}
+
+		if err := r.checkThresholds(wave, results); err != nil {
+			return err
+		}
+
+		if r.HealthCheck != nil {
+			if err := r.HealthCheck(wave, results); err != nil {
+				return fmt.Errorf("health check after wave %s failed: %w", wave.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// waveHosts returns the hosts of the inventory selected by the wave.
+func (r *Rollout) waveHosts(wave Wave, inventory string, hosts []string) ([]string, error) {
+	if wave.Limit == "" {
+		end := int(math.Ceil(float64(len(hosts)) * float64(wave.Percent) / 100))
+		if end > len(hosts) {
+			end = len(hosts)
+		}
+
+		return hosts[:end], nil
+	}
+
+	matched, err := r.Playbook.listHosts(inventory, wave.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	known := map[string]bool{}
+	for _, host := range hosts {
+		known[host] = true
+	}
+
+	var selected []string
+	for _, host := range matched {
+		if known[host] {
+			selected = append(selected, host)
+		}
+	}
+
+	return selected, nil
+}
+
+func (r *Rollout) checkThresholds(wave Wave, results []*RunResult) error {
+	failed := failedHosts(results)
+	if !exceedsThresholds(failed, results, r.MaxFailedHosts, r.MaxFailPercentage) {
+		return nil
+	}
+
+	return &RolloutError{
+		Wave:   wave.Name,
+		Failed: failed,
+	}
+}
+
+// exceedsThresholds reports whether more hosts failed than tolerated, or, if
+// the percentage is set, a larger share of the hosts of the results.
+func exceedsThresholds(failed []string, results []*RunResult, maxFailed int, maxPercentage float64) bool {
+	if len(failed) == 0 {
+		return false
+	}
+
+	hosts := map[string]bool{}
+	for _, result := range results {
+		for host := range result.Stats {
+			hosts[host] = true
+		}
+	}
+
+	total := len(hosts)
+
+	return len(failed) > maxFailed ||
+		(maxPercentage > 0 && float64(len(failed))*100/float64(total) > maxPercentage)
+}
+
+// failedHosts returns the hosts which failed or were unreachable in any of
+// the results. Hosts of several results are only listed once.
+func failedHosts(results []*RunResult) []string {
+	failed := []string{}
+	seen := map[string]bool{}
+
+	for _, result := range results {
+		for host, stats := range result.Stats {
+			if (stats.Failed > 0 || stats.Unreachable > 0) && !seen[host] {
+				seen[host] = true
+				failed = append(failed, host)
+			}
+		}
+	}
+
+	sort.Strings(failed)
+	return failed
+}
+
+// listHosts returns the hosts of the inventory matching the pattern.
+func (p *AnsiblePlaybook) listHosts(inventory, pattern string) ([]string, error) {
+	if pattern == "" {
+		pattern = "all"
+	}
+
+	cmd := exec.CommandContext(
+		p.context(),
+		p.binary("ansible"),
+		pattern,
+		"--inventory",
+		inventory,
+		"--list-hosts",
+	)
+	cmd.Env = p.environ()
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list hosts of inventory %s: %w", inventory, err)
+	}
+
+	return parseHostList(output), nil
+}
+
+func parseHostList(output []byte) []string {
+	var hosts []string
+
+	scanner := bufio.NewScanner(bytes.NewReader(ansiEscapePattern.ReplaceAll(output, nil)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || hostCountPattern.MatchString(line) {
+			continue
+		}
+
+		hosts = append(hosts, line)
+	}
+
+	return hosts
+}
@@ -0,0 +1,207 @@
+package ansible
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeRolloutBin prepares fake ansible binaries for an inventory of four hosts.
+// The playbook run fails for host h3 and logs every limit to a file.
+func fakeRolloutBin(t *testing.T) (string, string) {
+	bin := t.TempDir()
+	log := filepath.Join(bin, "limits.log")
+
+	scripts := map[string]string{
+		"ansible": `#!/bin/sh
+[ "$1" = "--version" ] && exit 0
+echo "  hosts ($#):"
+if [ "$1" = "all" ]; then printf '    h1\n    h2\n    h3\n    h4\n'; else echo "    $1"; fi
+`,
+		"ansible-playbook": `#!/bin/sh
+while [ $# -gt 0 ]; do [ "$1" = "--limit" ] && limit="$2"; shift; done
+echo "$limit" >> ` + log + `
+echo "PLAY RECAP ***"
+rc=0
+for host in $(echo "$limit" | tr ',' ' '); do
+  if [ "$host" = "h3" ]; then echo "$host : ok=0 changed=0 unreachable=0 failed=1"; rc=2
+  else echo "$host : ok=1 changed=0 unreachable=0 failed=0"; fi
+done
+exit $rc
+`,
+	}
+
+	for name, script := range scripts {
+		if err := os.WriteFile(filepath.Join(bin, name), []byte(script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return bin, log
+}
+
+// TestRollout tests that waves run against disjoint host slices.
+func TestRollout(t *testing.T) {
+	bin, log := fakeRolloutBin(t)
+
+	output := &bytes.Buffer{}
+	rollout := &Rollout{
+		Playbook: &AnsiblePlaybook{
+			Config: Config{
+				AnsibleBinDir: bin,
+				Inventories:   []string{"tests/inventories/production"},
+				Playbooks:     []string{"tests/test.yml"},
+			},
+			Output: output,
+		},
+		Waves: []Wave{
+			{Name: "canary", Limit: "h1"},
+			{Name: "half", Percent: 50},
+			{Name: "all", Percent: 100},
+		},
+		MaxFailedHosts: 1,
+	}
+
+	var checked []string
+	rollout.HealthCheck = func(wave Wave, results []*RunResult) error {
+		checked = append(checked, wave.Name)
+		return nil
+	}
+
+	if err := rollout.Exec(); err != nil {
+		t.Fatalf("Exec should execute without error, but received: %v", err)
+	}
+
+	content, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Assert that every host ran exactly once in the expected wave.
+	if string(content) != "h1\nh2\nh3,h4\n" {
+		t.Errorf("Unexpected limits:\n%s", content)
+	}
+
+	if strings.Join(checked, ",") != "canary,half,all" {
+		t.Errorf("Unexpected health checks: %v", checked)
+	}
+
+	if !strings.Contains(output.String(), "--limit h1") {
+		t.Errorf("Expected the waves to write to the output of the playbook, got %q", output.String())
+	}
+
+	// Assert that all waves share the ID of the rollout.
+	runID := rollout.Playbook.RunID()
+	for _, result := range rollout.Playbook.Results {
+		if runID == "" || result.RunID != runID {
+			t.Errorf("Expected the run ID %q, got %q", runID, result.RunID)
+		}
+	}
+
+	if strings.Count(output.String(), `"arillso_run_id":"`+runID+`"`) != 3 {
+		t.Errorf("Expected every wave to pass the run ID, got %q", output.String())
+	}
+}
+
+// TestRolloutAbort tests that a wave exceeding the thresholds aborts the rollout.
+func TestRolloutAbort(t *testing.T) {
+	bin, _ := fakeRolloutBin(t)
+
+	rollout := &Rollout{
+		Playbook: &AnsiblePlaybook{
+			Config: Config{
+				AnsibleBinDir: bin,
+				Inventories:   []string{"tests/inventories/production"},
+				Playbooks:     []string{"tests/test.yml"},
+			},
+		},
+		Waves: []Wave{
+			{Name: "half", Percent: 50},
+			{Name: "all", Percent: 100},
+		},
+	}
+
+	err := rollout.Exec()
+
+	var rolloutErr *RolloutError
+	if !errors.As(err, &rolloutErr) {
+		t.Fatalf("Expected a RolloutError, got %v", err)
+	}
+
+	if rolloutErr.Wave != "all" || strings.Join(rolloutErr.Failed, ",") != "h3" {
+		t.Errorf("Unexpected rollout error: %+v", rolloutErr)
+	}
+}
+
+// TestRolloutInvalidWave tests that waves without a limit or a percentage
+// are rejected before any host runs.
+func TestRolloutInvalidWave(t *testing.T) {
+	bin, log := fakeRolloutBin(t)
+
+	rollout := &Rollout{
+		Playbook: &AnsiblePlaybook{
+			Config: Config{
+				AnsibleBinDir: bin,
+				Inventories:   []string{"tests/inventories/production"},
+				Playbooks:     []string{"tests/test.yml"},
+			},
+		},
+		Waves: []Wave{
+			{Name: "canary", Limit: "h1"},
+			{Name: "rest"},
+		},
+	}
+
+	if err := rollout.Exec(); err == nil || !strings.Contains(err.Error(), "wave rest") {
+		t.Errorf("Expected the wave to be rejected, got %v", err)
+	}
+
+	if _, err := os.Stat(log); !os.IsNotExist(err) {
+		t.Error("Expected no wave to run")
+	}
+}
+
+// TestRolloutCanceled tests that a canceled context stops the rollout.
+func TestRolloutCanceled(t *testing.T) {
+	bin, log := fakeRolloutBin(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	rollout := &Rollout{
+		Playbook: &AnsiblePlaybook{
+			Config: Config{
+				AnsibleBinDir: bin,
+				Inventories:   []string{"tests/inventories/production"},
+				Playbooks:     []string{"tests/test.yml"},
+			},
+		},
+		Waves: []Wave{{Name: "all", Percent: 100}},
+	}
+
+	if err := rollout.ExecContext(ctx); err == nil {
+		t.Error("Expected the canceled rollout to fail")
+	}
+
+	if _, err := os.Stat(log); !os.IsNotExist(err) {
+		t.Error("Expected no wave to run")
+	}
+}
+
+// TestFailedHosts tests that hosts failing in several results are listed
+// once.
+func TestFailedHosts(t *testing.T) {
+	results := []*RunResult{
+		{Stats: map[string]HostStats{"web1": {Unreachable: 1}, "web2": {Failed: 1}}},
+		{Stats: map[string]HostStats{"web1": {Failed: 1}}},
+	}
+
+	if failed := failedHosts(results); !reflect.DeepEqual(failed, []string{"web1", "web2"}) {
+		t.Errorf("Expected each failed host once, got %v", failed)
+	}
+}
@@ -0,0 +1,56 @@
+package ansible
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// RunIDVar is the extra var passing the run ID to the playbooks.
+const RunIDVar = "arillso_run_id"
+
+// NewRunID returns a random run ID.
+func NewRunID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+
+	return hex.EncodeToString(b)
+}
+
+// RunID returns the ID of the current or last run, either Config.RunID or a
+// generated one.
+func (p *AnsiblePlaybook) RunID() string {
+	return p.runID
+}
+
+// startRun sets the ID of a new run, either Config.RunID or a generated one.
+func (p *AnsiblePlaybook) startRun() {
+	p.runID = p.Config.RunID
+	if p.runID == "" {
+		p.runID = NewRunID()
+	}
+}
+
+// subRun returns a playbook for a part of the run, e.g. a wave of a rollout.
+// It shares the output, options, secrets and run ID of the run.
+func (p *AnsiblePlaybook) subRun() *AnsiblePlaybook {
+	sub := &AnsiblePlaybook{
+		Config:  p.Config,
+		Output:  p.Output,
+		options: p.options,
+		secrets: append([]string{}, p.secrets...),
+	}
+	sub.Config.RunID = p.runID
+
+	return sub
+}
+
+// runIDExtraVars returns the extra vars argument passing the run ID.
+func (p *AnsiblePlaybook) runIDExtraVars() string {
+	vars, _ := json.Marshal(map[string]string{RunIDVar: p.runID})
+	return string(vars)
+}
@@ -0,0 +1,77 @@
+package ansible
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestRunID tests the run ID is passed to the playbook and recorded in the
+// results and the trace.
+func TestRunID(t *testing.T) {
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "ansible-playbook"), []byte("#!/bin/sh\necho \"$@\"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	var output bytes.Buffer
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir:    bin,
+			Inventories:      []string{"tests/inventories/production"},
+			Playbooks:        []string{"tests/test.yml"},
+			RunID:            "deploy-42",
+			SkipVersionCheck: true,
+		},
+		Output: &output,
+	}
+
+	if err := playbook.Exec(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{`{"arillso_run_id":"deploy-42"}`, "  # run deploy-42\n"} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("Expected output to contain '%s', got '%s'", expected, output.String())
+		}
+	}
+
+	if playbook.RunID() != "deploy-42" || playbook.Results[0].RunID != "deploy-42" {
+		t.Errorf("Expected the run ID in the results, got %+v", playbook.Results[0])
+	}
+
+	playbook.Config.RunID = ""
+	if err := playbook.Exec(); err != nil {
+		t.Fatal(err)
+	}
+
+	if id := playbook.RunID(); len(id) != 16 || id == "deploy-42" {
+		t.Errorf("Expected a generated run ID, got '%s'", id)
+	}
+}
+
+// TestSubRun tests parts of a run share its run ID, output and secrets.
+func TestSubRun(t *testing.T) {
+	var output bytes.Buffer
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			NoLogSensitive: true,
+			RunID:          "run-1",
+		},
+		Output: &output,
+	}
+
+	playbook.startRun()
+	playbook.registerSecret("s3cr3t")
+
+	sub := playbook.subRun()
+	if sub.Config.RunID != "run-1" || sub.Output != playbook.Output {
+		t.Errorf("Expected the sub-run to share the run, got %+v", sub)
+	}
+
+	if redacted := string(sub.redact([]byte("token s3cr3t"))); strings.Contains(redacted, "s3cr3t") {
+		t.Errorf("Expected the secret to be redacted, got %q", redacted)
+	}
+}
@@ -0,0 +1,101 @@
+package ansible
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strings"
+)
+
+// Extra vars passing the provenance of the run to the playbooks.
+const (
+	RunUserVar      = "arillso_run_user"
+	RunGitCommitVar = "arillso_git_commit"
+	RunJobURLVar    = "arillso_ci_job_url"
+)
+
+// RunMetadata returns the metadata passed to the playbooks with
+// Config.RunMetadata: the triggering user, the git commit of the playbooks
+// and the URL of the CI job, as far as they are known, merged with
+// Config.RunMetadataVars.
+func (p *AnsiblePlaybook) RunMetadata() map[string]string {
+	metadata := map[string]string{
+		RunUserVar:      runUser(),
+		RunGitCommitVar: p.gitHead(),
+		RunJobURLVar:    ciJobURL(),
+	}
+
+	for name, value := range p.Config.RunMetadataVars {
+		metadata[name] = value
+	}
+
+	for name, value := range metadata {
+		if value == "" {
+			delete(metadata, name)
+		}
+	}
+
+	return metadata
+}
+
+// runMetadataExtraVars returns the extra vars argument passing the metadata.
+func (p *AnsiblePlaybook) runMetadataExtraVars() string {
+	vars, _ := json.Marshal(p.RunMetadata())
+	return string(vars)
+}
+
+// runUser returns the user triggering the CI job or running the process.
+func runUser() string {
+	for _, name := range []string{"GITHUB_ACTOR", "GITLAB_USER_LOGIN", "BUILD_USER_ID", "DRONE_COMMIT_AUTHOR"} {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+
+	if current, err := user.Current(); err == nil {
+		return current.Username
+	}
+
+	return ""
+}
+
+// gitHead returns the commit of the CI job or of the repository of the first
+// playbook.
+func (p *AnsiblePlaybook) gitHead() string {
+	for _, name := range []string{"GITHUB_SHA", "CI_COMMIT_SHA", "GIT_COMMIT", "DRONE_COMMIT_SHA"} {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+
+	if len(p.Config.Playbooks) == 0 {
+		return ""
+	}
+
+	cmd := exec.Command("git", "rev-parse", "HEAD")
+	cmd.Dir = filepath.Dir(p.Config.Playbooks[0])
+
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(output))
+}
+
+// ciJobURL returns the URL of the CI job running the playbooks.
+func ciJobURL() string {
+	if run := os.Getenv("GITHUB_RUN_ID"); run != "" {
+		return os.Getenv("GITHUB_SERVER_URL") + "/" + os.Getenv("GITHUB_REPOSITORY") + "/actions/runs/" + run
+	}
+
+	for _, name := range []string{"CI_JOB_URL", "BUILD_URL", "DRONE_BUILD_LINK"} {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
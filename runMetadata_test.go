@@ -0,0 +1,59 @@
+package ansible
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestRunMetadata tests the metadata of the CI job is passed as extra vars and
+// configured values take precedence.
+func TestRunMetadata(t *testing.T) {
+	t.Setenv("GITHUB_ACTOR", "octocat")
+	t.Setenv("GITHUB_SHA", "0123456789abcdef0123456789abcdef01234567")
+	t.Setenv("GITHUB_SERVER_URL", "https://github.com")
+	t.Setenv("GITHUB_REPOSITORY", "arillso/infra")
+	t.Setenv("GITHUB_RUN_ID", "42")
+
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			Inventories:     []string{"tests/inventories/production"},
+			Playbooks:       []string{"tests/test.yml"},
+			RunMetadata:     true,
+			RunMetadataVars: map[string]string{RunUserVar: "release-bot", "change_ticket": "CHG-1"},
+		},
+	}
+
+	specs, err := playbook.BuildCommands()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	args := specs[len(specs)-1].Args
+
+	var metadata map[string]string
+	for i, arg := range args {
+		if arg == "--extra-vars" && strings.Contains(args[i+1], RunGitCommitVar) {
+			if err := json.Unmarshal([]byte(args[i+1]), &metadata); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	expected := map[string]string{
+		RunUserVar:      "release-bot",
+		RunGitCommitVar: "0123456789abcdef0123456789abcdef01234567",
+		RunJobURLVar:    "https://github.com/arillso/infra/actions/runs/42",
+		"change_ticket": "CHG-1",
+	}
+
+	if len(metadata) != len(expected) {
+		t.Fatalf("Expected metadata %v, got %v", expected, metadata)
+	}
+
+	for name, value := range expected {
+		if metadata[name] != value {
+			t.Errorf("Expected %s to be '%s', got '%s'", name, value, metadata[name])
+		}
+	}
+}
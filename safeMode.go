@@ -0,0 +1,93 @@
+package ansible
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// UnsafeValueError is returned in safe mode if a configured value could be
+// interpreted as a flag or read arbitrary files.
+type UnsafeValueError struct {
+	Field  string
+	Value  string
+	Reason string
+}
+
+func (e *UnsafeValueError) Error() string {
+	return fmt.Sprintf("unsafe value %q for %s: %s", e.Value, e.Field, e.Reason)
+}
+
+// flagArg appends a flag with its value. Values starting with a dash are
+// passed as --flag=value, so they can not be interpreted as a flag.
+func flagArg(args []string, flag, value string) []string {
+	if strings.HasPrefix(value, "-") {
+		return append(args, flag+"="+value)
+	}
+
+	return append(args, flag, value)
+}
+
+// playbookArgs returns the playbook arguments. Relative paths starting with
+// a dash are prefixed with ./, so they can not be interpreted as a flag.
+func playbookArgs(playbooks []string) []string {
+	args := make([]string, 0, len(playbooks))
+	for _, playbook := range playbooks {
+		if strings.HasPrefix(playbook, "-") {
+			playbook = "." + string(filepath.Separator) + playbook
+		}
+
+		args = append(args, playbook)
+	}
+
+	return args
+}
+
+// checkSafeValues rejects values which are suspicious if they are passed by
+// untrusted callers, i.e. values starting with a dash, containing control
+// characters, or extra vars reading files.
+func (p *AnsiblePlaybook) checkSafeValues() error {
+	values := map[string][]string{
+		"limit":         {p.Config.Limit},
+		"tags":          {p.Config.Tags},
+		"skip tags":     {p.Config.SkipTags},
+		"start at task": {p.Config.StartAtTask},
+		"user":          {p.Config.User},
+		"become user":   {p.Config.BecomeUser},
+		"extra vars":    p.Config.ExtraVars,
+		"inventories":   p.Config.Inventories,
+		"playbooks":     p.Config.Playbooks,
+	}
+
+	for _, field := range sortedFields(values) {
+		for _, value := range values[field] {
+			if err := checkSafeValue(field, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func checkSafeValue(field, value string) error {
+	switch {
+	case strings.HasPrefix(value, "-"):
+		return &UnsafeValueError{Field: field, Value: value, Reason: "starts with a dash"}
+	case strings.ContainsAny(value, "\x00\r\n"):
+		return &UnsafeValueError{Field: field, Value: value, Reason: "contains control characters"}
+	case field == "extra vars" && strings.HasPrefix(value, "@"):
+		return &UnsafeValueError{Field: field, Value: value, Reason: "reads a file"}
+	}
+
+	return nil
+}
+
+func sortedFields(values map[string][]string) []string {
+	fields := make(map[string]bool, len(values))
+	for field := range values {
+		fields[field] = true
+	}
+
+	return sortedKeys(fields)
+}
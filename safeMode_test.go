@@ -0,0 +1,50 @@
+package ansible
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestFlagArg tests values starting with a dash are joined with their flag.
+func TestFlagArg(t *testing.T) {
+	args := flagArg(nil, "--limit", "web")
+	args = flagArg(args, "--tags", "--become")
+	args = append(args, playbookArgs([]string{"site.yml", "-e.yml"})...)
+
+	expected := []string{"--limit", "web", "--tags=--become", "site.yml", "./-e.yml"}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("Expected args %v, got %v", expected, args)
+	}
+}
+
+// TestSafeMode tests suspicious values are rejected in safe mode.
+func TestSafeMode(t *testing.T) {
+	tests := []struct {
+		config Config
+		field  string
+	}{
+		{Config{Limit: "--become"}, "limit"},
+		{Config{ExtraVars: []string{"version=1.0", "@/etc/shadow"}}, "extra vars"},
+		{Config{Tags: "deploy\nrm"}, "tags"},
+		{Config{Limit: "web,db", ExtraVars: []string{"version=-1"}}, ""},
+	}
+
+	for _, test := range tests {
+		playbook := &AnsiblePlaybook{Config: test.config}
+		err := playbook.checkSafeValues()
+
+		if test.field == "" {
+			if err != nil {
+				t.Errorf("Expected no error for %+v, got %v", test.config, err)
+			}
+
+			continue
+		}
+
+		var unsafe *UnsafeValueError
+		if !errors.As(err, &unsafe) || unsafe.Field != test.field {
+			t.Errorf("Expected an unsafe value error for %s, got %v", test.field, err)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package ansible
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const defaultGalaxyServer = "https://galaxy.ansible.com"
+
+var roleListPattern = regexp.MustCompile(`^- (\S+), (.+)$`)
+
+// SBOM is a CycloneDX software bill of materials of the installed roles and
+// collections.
+type SBOM struct {
+	BOMFormat    string          `json:"bomFormat"`
+	SpecVersion  string          `json:"specVersion"`
+	SerialNumber string          `json:"serialNumber"`
+	Version      int             `json:"version"`
+	Metadata     SBOMMetadata    `json:"metadata"`
+	Components   []SBOMComponent `json:"components"`
+}
+
+// SBOMMetadata describes when and for which run the SBOM was generated.
+type SBOMMetadata struct {
+	Timestamp  time.Time      `json:"timestamp"`
+	Properties []SBOMProperty `json:"properties,omitempty"`
+}
+
+// SBOMComponent is an installed role or collection.
+type SBOMComponent struct {
+	Type               string            `json:"type"`
+	BOMRef             string            `json:"bom-ref"`
+	Group              string            `json:"group,omitempty"`
+	Name               string            `json:"name"`
+	Version            string            `json:"version,omitempty"`
+	ExternalReferences []SBOMExternalRef `json:"externalReferences,omitempty"`
+	Properties         []SBOMProperty    `json:"properties,omitempty"`
+}
+
+// SBOMExternalRef is the source a component was installed from.
+type SBOMExternalRef struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// SBOMProperty is a name value pair of a component.
+type SBOMProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// BuildSBOM lists the installed collections and roles with their versions
+// and sources.
+func (p *AnsiblePlaybook) BuildSBOM() (*SBOM, error) {
+	sbom := &SBOM{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		SerialNumber: "urn:uuid:" + newUUID(),
+		Version:      1,
+		Metadata: SBOMMetadata{
+			Timestamp: time.Now().UTC().Truncate(time.Second),
+		},
+		Components: []SBOMComponent{},
+	}
+
+	if p.runID != "" {
+		sbom.Metadata.Properties = []SBOMProperty{{Name: "arillso:run_id", Value: p.runID}}
+	}
+
+	sources := p.requirementSources()
+
+	collections, err := p.installedCollections()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range sortedKeys(keySet(collections)) {
+		parts := strings.SplitN(name, ".", 2)
+		sbom.Components = append(sbom.Components, sbomComponent("collection", name, parts[0], parts[1], collections[name], sources[name]))
+	}
+
+	roles, err := p.installedRoles()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range sortedKeys(keySet(roles)) {
+		sbom.Components = append(sbom.Components, sbomComponent("role", name, "", name, roles[name], sources[name]))
+	}
+
+	return sbom, nil
+}
+
+// WriteSBOM writes the SBOM of the installed collections and roles as
+// CycloneDX JSON.
+func (p *AnsiblePlaybook) WriteSBOM(path string) error {
+	sbom, err := p.BuildSBOM()
+	if err != nil {
+		return err
+	}
+
+	content, err := json.MarshalIndent(sbom, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode sbom: %w", err)
+	}
+
+	if err := p.writeFile(path, append(content, '\n')); err != nil {
+		return fmt.Errorf("failed to write sbom: %w", err)
+	}
+
+	return nil
+}
+
+func sbomComponent(kind, ref, group, name, version, source string) SBOMComponent {
+	component := SBOMComponent{
+		Type:       "library",
+		BOMRef:     "ansible-" + kind + ":" + ref + "@" + version,
+		Group:      group,
+		Name:       name,
+		Version:    version,
+		Properties: []SBOMProperty{{Name: "ansible:type", Value: kind}},
+	}
+
+	if source != "" {
+		component.ExternalReferences = []SBOMExternalRef{{Type: "distribution", URL: source}}
+	}
+
+	return component
+}
+
+// requirementSources returns the sources of the requirements of the galaxy
+// file by name. Requirements without a source are installed from the galaxy
+// server.
+func (p *AnsiblePlaybook) requirementSources() map[string]string {
+	server := p.Config.GalaxyAPIServerURL
+	if server == "" {
+		server = defaultGalaxyServer
+	}
+
+	sources := map[string]string{}
+	if p.Config.GalaxyFile == "" {
+		return sources
+	}
+
+	reqs, err := readGalaxyRequirements(p.Config.GalaxyFile)
+	if err != nil {
+		return sources
+	}
+
+	for _, role := range reqs.Roles {
+		source := server
+		if role.Src != "" && strings.ContainsAny(role.Src, "/:") {
+			source = strings.TrimPrefix(role.Src, "git+")
+		}
+
+		sources[roleName(role)] = source
+	}
+
+	for _, collection := range reqs.Collections {
+		if versioned(collection) && !gitRequirement(collection) {
+			sources[collection.Name] = server
+		}
+	}
+
+	return sources
+}
+
+// installedCollections returns the versions of the installed collections.
+// Collections installed in several paths are reported once.
+func (p *AnsiblePlaybook) installedCollections() (map[string]string, error) {
+	args := []string{"collection", "list", "--format", "json"}
+	if p.Config.GalaxyCollectionsPath != "" {
+		args = append(args, "--collections-path", p.Config.GalaxyCollectionsPath)
+	}
+
+	var output bytes.Buffer
+	if err := p.runOutput(exec.Command(p.binary("ansible-galaxy"), args...), &output); err != nil {
+		return nil, fmt.Errorf("failed to list collections: %w", err)
+	}
+
+	collections := map[string]string{}
+
+	start := bytes.IndexByte(output.Bytes(), '{')
+	if start < 0 {
+		return collections, nil
+	}
+
+	var paths map[string]map[string]struct {
+		Version string `json:"version"`
+	}
+
+	if err := json.Unmarshal(output.Bytes()[start:], &paths); err != nil {
+		return nil, fmt.Errorf("failed to parse collection list: %w", err)
+	}
+
+	names := make([]string, 0, len(paths))
+	for path := range paths {
+		names = append(names, path)
+	}
+
+	sort.Strings(names)
+
+	for _, path := range names {
+		for name, info := range paths[path] {
+			if _, ok := collections[name]; !ok {
+				collections[name] = info.Version
+			}
+		}
+	}
+
+	return collections, nil
+}
+
+// installedRoles returns the versions of the installed roles.
+func (p *AnsiblePlaybook) installedRoles() (map[string]string, error) {
+	args := []string{"role", "list"}
+	if p.Config.GalaxyRolesPath != "" {
+		args = append(args, "--roles-path", p.Config.GalaxyRolesPath)
+	}
+
+	var output bytes.Buffer
+	if err := p.runOutput(exec.Command(p.binary("ansible-galaxy"), args...), &output); err != nil {
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+
+	roles := map[string]string{}
+	for _, line := range strings.Split(output.String(), "\n") {
+		match := roleListPattern.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+
+		version := match[2]
+		if strings.HasPrefix(version, "(") {
+			version = ""
+		}
+
+		if _, ok := roles[match[1]]; !ok {
+			roles[match[1]] = version
+		}
+	}
+
+	return roles, nil
+}
+
+func keySet(m map[string]string) map[string]bool {
+	set := make(map[string]bool, len(m))
+	for key := range m {
+		set[key] = true
+	}
+
+	return set
+}
+
+// newUUID returns a random version 4 UUID.
+func newUUID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,70 @@
+package ansible
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestWriteSBOM tests the installed collections and roles are listed with
+// their versions and sources.
+func TestWriteSBOM(t *testing.T) {
+	bin := t.TempDir()
+
+	galaxy := `#!/bin/sh
+if [ "$1" = "collection" ]; then
+  echo '{"/usr/share/ansible/collections/ansible_collections": {"community.general": {"version": "7.5.0"}}}'
+else
+  echo "# /etc/ansible/roles"
+  echo "- geerlingguy.docker, 6.1.0"
+  echo "- myrole, (unknown version)"
+fi
+`
+	if err := os.WriteFile(filepath.Join(bin, "ansible-galaxy"), []byte(galaxy), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	requirements := filepath.Join(bin, "requirements.yml")
+	content := "roles:\n  - name: geerlingguy.docker\n  - src: git+https://example.com/myrole.git\ncollections:\n  - community.general\n"
+	if err := os.WriteFile(requirements, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir: bin,
+			GalaxyFile:    requirements,
+		},
+	}
+
+	path := filepath.Join(bin, "sbom.json")
+	if err := playbook.WriteSBOM(path); err != nil {
+		t.Fatalf("WriteSBOM should execute without error, but received: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sbom SBOM
+	if err := json.Unmarshal(data, &sbom); err != nil {
+		t.Fatal(err)
+	}
+
+	if sbom.BOMFormat != "CycloneDX" || len(sbom.Components) != 3 {
+		t.Fatalf("Expected a CycloneDX SBOM with 3 components, got %+v", sbom)
+	}
+
+	for i, expected := range []struct{ ref, source string }{
+		{"ansible-collection:community.general@7.5.0", defaultGalaxyServer},
+		{"ansible-role:geerlingguy.docker@6.1.0", defaultGalaxyServer},
+		{"ansible-role:myrole@", "https://example.com/myrole.git"},
+	} {
+		component := sbom.Components[i]
+		if component.BOMRef != expected.ref || len(component.ExternalReferences) != 1 || component.ExternalReferences[0].URL != expected.source {
+			t.Errorf("Expected component %s from %s, got %+v", expected.ref, expected.source, component)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package ansible
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// SecretProvider resolves a secret value at run time.
+type SecretProvider interface {
+	Secret() (string, error)
+}
+
+// EnvSecret reads a secret from the named environment variable.
+type EnvSecret string
+
+func (s EnvSecret) Secret() (string, error) {
+	value, ok := os.LookupEnv(string(s))
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", string(s))
+	}
+
+	return value, nil
+}
+
+// FileSecret reads a secret from the named file. Surrounding whitespace is
+// removed.
+type FileSecret string
+
+func (s FileSecret) Secret() (string, error) {
+	content, err := os.ReadFile(string(s))
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file %s: %w", string(s), err)
+	}
+
+	return strings.TrimSpace(string(content)), nil
+}
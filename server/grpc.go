@@ -0,0 +1,185 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/arillso/go.ansible/jobs"
+	ansiblev1 "github.com/arillso/go.ansible/proto/arillso/ansible/v1"
+)
+
+// runnerService implements the gRPC runner service with the queue and the
+// base configuration of the server.
+type runnerService struct {
+	ansiblev1.UnimplementedRunnerServiceServer
+
+	server *Server
+}
+
+// RunnerService returns the gRPC counterpart of the REST API, to be
+// registered with ansiblev1.RegisterRunnerServiceServer. Like the REST API
+// it does not authenticate clients.
+func (s *Server) RunnerService() ansiblev1.RunnerServiceServer {
+	return &runnerService{server: s}
+}
+
+func (r *runnerService) SubmitRun(ctx context.Context, req *ansiblev1.SubmitRunRequest) (*ansiblev1.Run, error) {
+	if req.GetSpec() == nil {
+		return nil, status.Error(codes.InvalidArgument, "missing run spec")
+	}
+
+	spec, err := r.server.spec(RunRequest{
+		Name:      req.Spec.GetName(),
+		Playbooks: req.Spec.GetPlaybooks(),
+		Limit:     req.Spec.GetLimit(),
+		Tags:      req.Spec.GetTags(),
+		SkipTags:  req.Spec.GetSkipTags(),
+		ExtraVars: req.Spec.GetExtraVars(),
+	})
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	job, err := r.server.Queue.Submit(spec)
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+
+	return runMessage(job.Info()), nil
+}
+
+func (r *runnerService) GetRun(ctx context.Context, req *ansiblev1.GetRunRequest) (*ansiblev1.Run, error) {
+	job, err := r.server.Queue.Get(req.GetId())
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+
+	return runMessage(job.Info()), nil
+}
+
+func (r *runnerService) ListRuns(ctx context.Context, req *ansiblev1.ListRunsRequest) (*ansiblev1.ListRunsResponse, error) {
+	list := r.server.Queue.List()
+
+	resp := &ansiblev1.ListRunsResponse{Runs: make([]*ansiblev1.Run, 0, len(list))}
+	for _, job := range list {
+		info := job.Info()
+		info.Results = nil
+		resp.Runs = append(resp.Runs, runMessage(info))
+	}
+
+	return resp, nil
+}
+
+func (r *runnerService) CancelRun(ctx context.Context, req *ansiblev1.CancelRunRequest) (*ansiblev1.Run, error) {
+	if err := r.server.Queue.Cancel(req.GetId()); err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+
+	return r.GetRun(ctx, &ansiblev1.GetRunRequest{Id: req.GetId()})
+}
+
+func (r *runnerService) StreamEvents(req *ansiblev1.StreamEventsRequest, stream ansiblev1.RunnerService_StreamEventsServer) error {
+	job, err := r.server.Queue.Get(req.GetId())
+	if err != nil {
+		return status.Error(codes.NotFound, err.Error())
+	}
+
+	events := &logEvents{stream: stream}
+	if err := job.Follow(stream.Context(), events); err != nil {
+		return status.FromContextError(err).Err()
+	}
+
+	if err := events.flush(); err != nil {
+		return err
+	}
+
+	return stream.Send(&ansiblev1.RunEvent{
+		Event: &ansiblev1.RunEvent_Done{Done: runMessage(job.Info())},
+	})
+}
+
+// logEvents sends every complete line as log event.
+type logEvents struct {
+	stream ansiblev1.RunnerService_StreamEventsServer
+	buf    []byte
+}
+
+func (e *logEvents) Write(b []byte) (int, error) {
+	e.buf = append(e.buf, b...)
+
+	end := bytes.LastIndexByte(e.buf, '\n')
+	if end < 0 {
+		return len(b), nil
+	}
+
+	if err := e.send(e.buf[:end]); err != nil {
+		return 0, err
+	}
+
+	e.buf = append(e.buf[:0], e.buf[end+1:]...)
+	return len(b), nil
+}
+
+func (e *logEvents) flush() error {
+	if len(e.buf) == 0 {
+		return nil
+	}
+
+	err := e.send(e.buf)
+	e.buf = nil
+
+	return err
+}
+
+func (e *logEvents) send(lines []byte) error {
+	scanner := bufio.NewScanner(bytes.NewReader(lines))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		event := &ansiblev1.RunEvent{Event: &ansiblev1.RunEvent_Log{Log: scanner.Text()}}
+		if err := e.stream.Send(event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// runMessage converts the state of a job to a run message.
+func runMessage(info jobs.Info) *ansiblev1.Run {
+	run := &ansiblev1.Run{
+		Id:       info.ID,
+		Name:     info.Name,
+		Status:   info.Status,
+		Error:    info.Error,
+		Created:  timestamppb.New(info.Created),
+		Started:  timestamp(info.Started),
+		Finished: timestamp(info.Finished),
+	}
+
+	for _, result := range info.Results {
+		encoded, err := json.Marshal(result)
+		if err != nil {
+			continue
+		}
+
+		run.ResultsJson = append(run.ResultsJson, encoded)
+	}
+
+	return run
+}
+
+func timestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+
+	return timestamppb.New(*t)
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	ansible "github.com/arillso/go.ansible"
+	"github.com/arillso/go.ansible/ansibletest"
+	"github.com/arillso/go.ansible/jobs"
+	ansiblev1 "github.com/arillso/go.ansible/proto/arillso/ansible/v1"
+)
+
+func runnerClient(t *testing.T, s *Server) ansiblev1.RunnerServiceClient {
+	listener := bufconn.Listen(1 << 20)
+
+	grpcServer := grpc.NewServer()
+	ansiblev1.RegisterRunnerServiceServer(grpcServer, s.RunnerService())
+
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.Dial(
+		"bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { conn.Close() })
+
+	return ansiblev1.NewRunnerServiceClient(conn)
+}
+
+// TestRunnerService tests a run is submitted, streamed and fetched over
+// gRPC.
+func TestRunnerService(t *testing.T) {
+	fake := ansibletest.NewFake(t)
+	fake.SetOutput("ansible-playbook", ansibletest.Recap(map[string]ansible.HostStats{"web1": {Ok: 2}}))
+
+	queue := jobs.NewQueue(1)
+	defer queue.Close()
+
+	client := runnerClient(t, New(queue, base(fake)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	submitted, err := client.SubmitRun(ctx, &ansiblev1.SubmitRunRequest{
+		Spec: &ansiblev1.RunSpec{Name: "site", Limit: "web1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if submitted.Id == "" || submitted.Name != "site" {
+		t.Fatalf("Expected the run to be created, got %+v", submitted)
+	}
+
+	stream, err := client.StreamEvents(ctx, &ansiblev1.StreamEventsRequest{Id: submitted.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		recap bool
+		done  *ansiblev1.Run
+	)
+
+	for {
+		event, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		recap = recap || event.GetLog() == "PLAY RECAP *********************************************************************"
+		if event.GetDone() != nil {
+			done = event.GetDone()
+		}
+	}
+
+	if done == nil || done.Status != jobs.StatusSucceeded || done.Finished == nil {
+		t.Fatalf("Expected the final status event, got %+v", done)
+	}
+
+	run, err := client.GetRun(ctx, &ansiblev1.GetRunRequest{Id: submitted.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var result ansible.RunResult
+	if len(run.ResultsJson) != 1 || json.Unmarshal(run.ResultsJson[0], &result) != nil || result.Stats["web1"].Ok != 2 {
+		t.Errorf("Expected the run result, got %+v", run)
+	}
+
+	list, err := client.ListRuns(ctx, &ansiblev1.ListRunsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(list.Runs) != 1 || len(list.Runs[0].ResultsJson) != 0 {
+		t.Errorf("Expected the run without results, got %+v", list.Runs)
+	}
+
+	if !recap {
+		t.Error("Expected the output to be streamed")
+	}
+}
+
+// TestRunnerServiceErrors tests invalid requests are rejected with the
+// status codes of the errors.
+func TestRunnerServiceErrors(t *testing.T) {
+	queue := jobs.NewQueue(1)
+	defer queue.Close()
+
+	client := runnerClient(t, New(queue, base(ansibletest.NewFake(t))))
+	ctx := context.Background()
+
+	_, err := client.SubmitRun(ctx, &ansiblev1.SubmitRunRequest{})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for a missing spec, got %v", err)
+	}
+
+	_, err = client.SubmitRun(ctx, &ansiblev1.SubmitRunRequest{Spec: &ansiblev1.RunSpec{Playbooks: []string{"/etc/site.yml"}}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for a playbook outside of the base, got %v", err)
+	}
+
+	_, err = client.GetRun(ctx, &ansiblev1.GetRunRequest{Id: "missing"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound, got %v", err)
+	}
+
+	_, err = client.CancelRun(ctx, &ansiblev1.CancelRunRequest{Id: "missing"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound, got %v", err)
+	}
+}
@@ -0,0 +1,263 @@
+// Package server exposes a job queue as REST API.
+//
+//	POST /runs                submit a run request, returns the job
+//	GET  /runs                list the jobs
+//	GET  /runs/{id}           get a job with its results
+//	GET  /runs/{id}/log       get the output, streamed as server-sent events
+//	                          if the client accepts text/event-stream
+//	POST /runs/{id}/cancel    cancel a job
+//
+// RunnerService provides the same operations as gRPC service.
+//
+// The server does not authenticate clients. It has to be run behind a proxy
+// or middleware which authenticates and authorizes the requests.
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	ansible "github.com/arillso/go.ansible"
+	"github.com/arillso/go.ansible/jobs"
+)
+
+// Server is a http.Handler exposing the jobs of a queue.
+type Server struct {
+	Queue *jobs.Queue
+
+	// Base is the configuration of every run. Run requests can only select
+	// playbooks of it and add a limit, tags and extra vars. The runs are
+	// always executed in safe mode.
+	Base ansible.Config
+}
+
+// RunRequest is the body of POST /runs.
+type RunRequest struct {
+	Name      string   `json:"name,omitempty"`
+	Playbooks []string `json:"playbooks,omitempty"`
+	Limit     string   `json:"limit,omitempty"`
+	Tags      string   `json:"tags,omitempty"`
+	SkipTags  string   `json:"skip_tags,omitempty"`
+	ExtraVars []string `json:"extra_vars,omitempty"`
+}
+
+// New returns a server for the queue running the requests with the base
+// configuration.
+func New(queue *jobs.Queue, base ansible.Config) *Server {
+	return &Server{
+		Queue: queue,
+		Base:  base,
+	}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if parts[0] != "runs" || len(parts) > 3 {
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+		return
+	}
+
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodPost:
+		s.submit(w, r)
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		s.list(w)
+	case len(parts) == 2 && r.Method == http.MethodGet:
+		s.get(w, parts[1])
+	case len(parts) == 3 && parts[2] == "log" && r.Method == http.MethodGet:
+		s.log(w, r, parts[1])
+	case len(parts) == 3 && parts[2] == "cancel" && r.Method == http.MethodPost:
+		s.cancel(w, parts[1])
+	default:
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	}
+}
+
+func (s *Server) submit(w http.ResponseWriter, r *http.Request) {
+	var request RunRequest
+
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid run request: %v", err))
+		return
+	}
+
+	spec, err := s.spec(request)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	job, err := s.Queue.Submit(spec)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	w.Header().Set("Location", "/runs/"+job.ID)
+	writeJSON(w, http.StatusCreated, job.Info())
+}
+
+// spec merges the request onto the base configuration.
+func (s *Server) spec(request RunRequest) (jobs.RunSpec, error) {
+	config := s.Base
+	config.SafeMode = true
+
+	if len(request.Playbooks) > 0 {
+		allowed := map[string]bool{}
+		for _, playbook := range s.Base.Playbooks {
+			allowed[playbook] = true
+		}
+
+		for _, playbook := range request.Playbooks {
+			if !allowed[playbook] {
+				return jobs.RunSpec{}, fmt.Errorf("playbook %s is not allowed", playbook)
+			}
+		}
+
+		config.Playbooks = append([]string{}, request.Playbooks...)
+	}
+
+	if request.Limit != "" {
+		if s.Base.Limit != "" {
+			return jobs.RunSpec{}, errors.New("the limit of the server cannot be changed")
+		}
+
+		config.Limit = request.Limit
+	}
+
+	if request.Tags != "" {
+		config.Tags = request.Tags
+	}
+
+	if request.SkipTags != "" {
+		config.SkipTags = request.SkipTags
+	}
+
+	config.ExtraVars = append(append([]string{}, s.Base.ExtraVars...), request.ExtraVars...)
+
+	return jobs.RunSpec{Name: request.Name, Config: config}, nil
+}
+
+func (s *Server) list(w http.ResponseWriter) {
+	list := s.Queue.List()
+
+	infos := make([]jobs.Info, 0, len(list))
+	for _, job := range list {
+		info := job.Info()
+		info.Results = nil
+		infos = append(infos, info)
+	}
+
+	writeJSON(w, http.StatusOK, infos)
+}
+
+func (s *Server) get(w http.ResponseWriter, id string) {
+	job, err := s.Queue.Get(id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, job.Info())
+}
+
+func (s *Server) log(w http.ResponseWriter, r *http.Request, id string) {
+	job, err := s.Queue.Get(id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok || !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write(job.Log())
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	events := &eventWriter{w: w, flusher: flusher}
+	if err := job.Follow(r.Context(), events); err != nil {
+		return
+	}
+
+	events.flush()
+	fmt.Fprintf(w, "event: done\ndata: %s\n\n", job.Info().Status)
+	flusher.Flush()
+}
+
+func (s *Server) cancel(w http.ResponseWriter, id string) {
+	if err := s.Queue.Cancel(id); err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	job, _ := s.Queue.Get(id)
+	writeJSON(w, http.StatusAccepted, job.Info())
+}
+
+// eventWriter writes every complete line as server-sent event.
+type eventWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+	buf     []byte
+}
+
+func (e *eventWriter) Write(b []byte) (int, error) {
+	e.buf = append(e.buf, b...)
+
+	end := bytes.LastIndexByte(e.buf, '\n')
+	if end < 0 {
+		return len(b), nil
+	}
+
+	if err := e.write(e.buf[:end]); err != nil {
+		return 0, err
+	}
+
+	e.buf = append(e.buf[:0], e.buf[end+1:]...)
+	return len(b), nil
+}
+
+func (e *eventWriter) flush() {
+	if len(e.buf) > 0 {
+		e.write(e.buf)
+		e.buf = nil
+	}
+}
+
+func (e *eventWriter) write(lines []byte) error {
+	scanner := bufio.NewScanner(bytes.NewReader(lines))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		if _, err := fmt.Fprintf(e.w, "data: %s\n\n", scanner.Text()); err != nil {
+			return err
+		}
+	}
+
+	e.flusher.Flush()
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,150 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	ansible "github.com/arillso/go.ansible"
+	"github.com/arillso/go.ansible/ansibletest"
+	"github.com/arillso/go.ansible/jobs"
+)
+
+func base(fake *ansibletest.Fake) ansible.Config {
+	return ansible.Config{
+		AnsibleBinDir:    fake.Dir,
+		Inventories:      []string{"../tests/inventories/production"},
+		Playbooks:        []string{"../tests/test.yml"},
+		SkipVersionCheck: true,
+	}
+}
+
+// TestServer tests a run is submitted, streamed and fetched.
+func TestServer(t *testing.T) {
+	fake := ansibletest.NewFake(t)
+	fake.SetOutput("ansible-playbook", ansibletest.Recap(map[string]ansible.HostStats{"web1": {Ok: 2}}))
+
+	queue := jobs.NewQueue(1)
+	defer queue.Close()
+
+	server := httptest.NewServer(New(queue, base(fake)))
+	defer server.Close()
+
+	body, _ := json.Marshal(RunRequest{
+		Playbooks: []string{"../tests/test.yml"},
+		Limit:     "web1",
+		ExtraVars: []string{"version=1.2.3"},
+	})
+
+	resp, err := http.Post(server.URL+"/runs", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var submitted jobs.Info
+	json.NewDecoder(resp.Body).Decode(&submitted)
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated || submitted.ID == "" {
+		t.Fatalf("Expected the run to be created, got %d %+v", resp.StatusCode, submitted)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/runs/"+submitted.ID+"/log", nil)
+	req.Header.Set("Accept", "text/event-stream")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if !strings.Contains(string(events), "data: PLAY RECAP") || !strings.HasSuffix(string(events), "event: done\ndata: succeeded\n\n") {
+		t.Errorf("Expected the log events, got '%s'", events)
+	}
+
+	resp, err = http.Get(server.URL + "/runs/" + submitted.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var info jobs.Info
+	json.NewDecoder(resp.Body).Decode(&info)
+	resp.Body.Close()
+
+	if info.Status != jobs.StatusSucceeded || info.Results[0].Stats["web1"].Ok != 2 {
+		t.Errorf("Expected the run result, got %+v", info)
+	}
+}
+
+// TestServerErrors tests invalid requests are rejected.
+func TestServerErrors(t *testing.T) {
+	queue := jobs.NewQueue(1)
+	defer queue.Close()
+
+	handler := New(queue, base(ansibletest.NewFake(t)))
+
+	tests := []struct {
+		method, path, body string
+		status             int
+	}{
+		{http.MethodGet, "/runs/missing", "", http.StatusNotFound},
+		{http.MethodPost, "/runs/missing/cancel", "", http.StatusNotFound},
+		{http.MethodPost, "/runs", `{"config": {"AnsibleBinDir": "/tmp"}}`, http.StatusBadRequest},
+		{http.MethodPost, "/runs", `{"playbooks": ["/etc/site.yml"]}`, http.StatusUnprocessableEntity},
+		{http.MethodDelete, "/runs", "", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/jobs", "", http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(test.method, test.path, strings.NewReader(test.body)))
+
+		if rec.Code != test.status {
+			t.Errorf("Expected %d for %s %s, got %d", test.status, test.method, test.path, rec.Code)
+		}
+	}
+}
+
+// TestServerSpec tests run requests are merged onto the base configuration.
+func TestServerSpec(t *testing.T) {
+	config := base(ansibletest.NewFake(t))
+	config.ExtraVars = []string{"env=production"}
+
+	s := New(nil, config)
+
+	spec, err := s.spec(RunRequest{
+		Name:      "deploy",
+		Playbooks: []string{"../tests/test.yml"},
+		Limit:     "web1",
+		Tags:      "nginx",
+		ExtraVars: []string{"version=1.2.3"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !spec.Config.SafeMode || spec.Name != "deploy" || spec.Config.Limit != "web1" || spec.Config.Tags != "nginx" {
+		t.Errorf("Unexpected spec %+v", spec)
+	}
+
+	if strings.Join(spec.Config.ExtraVars, " ") != "env=production version=1.2.3" || len(s.Base.ExtraVars) != 1 {
+		t.Errorf("Expected the extra vars to be added to the base, got %v", spec.Config.ExtraVars)
+	}
+
+	if _, err := s.spec(RunRequest{Playbooks: []string{"site.yml"}}); err == nil {
+		t.Error("Expected playbooks outside of the base to be rejected")
+	}
+
+	s.Base.Limit = "staging"
+	if _, err := s.spec(RunRequest{Limit: "all"}); err == nil {
+		t.Error("Expected the limit of the base to be kept")
+	}
+}
@@ -0,0 +1,55 @@
+package ansible
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// sshControlDir creates the directory of the SSH control sockets of the run
+// and makes ansible reuse connections through it for SSHControlPersist.
+// Sockets of previous runs are never picked up, as every run has its own
+// directory.
+func (p *AnsiblePlaybook) sshControlDir() (string, error) {
+	dir, err := p.runDir()
+	if err != nil {
+		return "", err
+	}
+
+	// Unix socket paths are limited to about 100 bytes, so the directory
+	// name is short and the sockets are named by the hash of the connection.
+	dir = filepath.Join(dir, "cp")
+	if err := os.Mkdir(dir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create ssh control directory: %w", err)
+	}
+
+	persist := strconv.Itoa(int(p.Config.SSHControlPersist / time.Second))
+
+	p.setEnv(EnvSourceSSH, "ANSIBLE_SSH_ARGS", "-C -o ControlMaster=auto -o ControlPersist="+persist+"s")
+	p.setEnv(EnvSourceSSH, "ANSIBLE_SSH_CONTROL_PATH_DIR", dir)
+	p.setEnv(EnvSourceSSH, "ANSIBLE_SSH_CONTROL_PATH", "%(directory)s/%%C")
+
+	return dir, nil
+}
+
+// closeSSHControlSockets stops the master connections of the control
+// sockets, which would otherwise outlive the run for the persist duration.
+func (p *AnsiblePlaybook) closeSSHControlSockets(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		socket := filepath.Join(dir, entry.Name())
+
+		cmd := exec.Command("ssh", "-o", "ControlPath="+socket, "-O", "exit", "control")
+		cmd.Env = p.environ()
+		cmd.Run()
+
+		os.Remove(socket)
+	}
+}
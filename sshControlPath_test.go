@@ -0,0 +1,70 @@
+package ansible
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSSHControlPersist tests the control sockets are created in a directory
+// of the run and their master connections are stopped after the run.
+func TestSSHControlPersist(t *testing.T) {
+	bin := t.TempDir()
+	log := filepath.Join(bin, "calls.log")
+
+	scripts := map[string]string{
+		"ansible-playbook": `#!/bin/sh
+echo "$ANSIBLE_SSH_ARGS|$ANSIBLE_SSH_CONTROL_PATH" >> ` + log + `
+echo "$ANSIBLE_SSH_CONTROL_PATH_DIR" >> ` + log + `
+touch "$ANSIBLE_SSH_CONTROL_PATH_DIR/0123abcd"
+`,
+		"ssh": "#!/bin/sh\necho ssh \"$@\" >> " + log + "\n",
+	}
+
+	for name, script := range scripts {
+		if err := os.WriteFile(filepath.Join(bin, name), []byte(script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir:     bin,
+			Inventories:       []string{"tests/inventories/production"},
+			Playbooks:         []string{"tests/test.yml"},
+			SkipVersionCheck:  true,
+			SSHControlPersist: 2 * time.Minute,
+		},
+	}
+
+	if err := playbook.Exec(); err != nil {
+		t.Fatalf("Exec should execute without error, but received: %v", err)
+	}
+
+	content, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected a playbook run and an ssh call, got %q", lines)
+	}
+
+	if lines[0] != "-C -o ControlMaster=auto -o ControlPersist=120s|%(directory)s/%%C" {
+		t.Errorf("Unexpected ssh args %q", lines[0])
+	}
+
+	socket := filepath.Join(lines[1], "0123abcd")
+	if lines[2] != "ssh -o ControlPath="+socket+" -O exit control" {
+		t.Errorf("Expected the master connection to be stopped, got %q", lines[2])
+	}
+
+	if _, err := os.Stat(lines[1]); !os.IsNotExist(err) {
+		t.Errorf("Expected the control directory to be removed, got %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+package ansible
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SSHArgs are the SSH connection settings of an inventory group.
+type SSHArgs struct {
+	CommonArgs string
+	ProxyJump  string
+	User       string
+	Port       int
+}
+
+// vars returns the connection variables of the settings.
+func (a SSHArgs) vars() map[string]interface{} {
+	vars := map[string]interface{}{}
+
+	args := a.CommonArgs
+	if a.ProxyJump != "" {
+		args = strings.TrimSpace(args + " -o ProxyJump=" + a.ProxyJump)
+	}
+
+	if args != "" {
+		vars["ansible_ssh_common_args"] = args
+	}
+
+	if a.User != "" {
+		vars["ansible_user"] = a.User
+	}
+
+	if a.Port > 0 {
+		vars["ansible_port"] = a.Port
+	}
+
+	return vars
+}
+
+// sshGroupVars writes the SSH settings of every group to the group_vars of
+// an empty inventory in the run directory. The inventory is passed next to
+// every inventory of the run, so ansible loads its group_vars for the groups
+// of the actual inventory.
+func (p *AnsiblePlaybook) sshGroupVars() error {
+	dir, err := p.runDir()
+	if err != nil {
+		return err
+	}
+
+	inventory := filepath.Join(dir, "ssh_inventory")
+	if err := p.mkdirAll(filepath.Join(inventory, "group_vars")); err != nil {
+		return fmt.Errorf("failed to create group vars directory: %w", err)
+	}
+
+	if err := p.writeFile(filepath.Join(inventory, "hosts.yml"), []byte("all:\n  hosts: {}\n")); err != nil {
+		return fmt.Errorf("failed to write inventory file: %w", err)
+	}
+
+	for _, group := range sortedKeys(sshGroups(p.Config.SSHGroupArgs)) {
+		content, err := yaml.Marshal(p.Config.SSHGroupArgs[group].vars())
+		if err != nil {
+			return fmt.Errorf("failed to encode group vars of %s: %w", group, err)
+		}
+
+		if err := p.writeFile(filepath.Join(inventory, "group_vars", GroupName(group)+".yml"), content); err != nil {
+			return fmt.Errorf("failed to write group vars of %s: %w", group, err)
+		}
+	}
+
+	p.groupVars = inventory
+	return nil
+}
+
+func sshGroups(args map[string]SSHArgs) map[string]bool {
+	groups := make(map[string]bool, len(args))
+	for group := range args {
+		groups[group] = true
+	}
+
+	return groups
+}
@@ -0,0 +1,56 @@
+package ansible
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestSSHGroupArgs tests the SSH settings of the groups are rendered into the
+// group_vars of an inventory passed with every inventory.
+func TestSSHGroupArgs(t *testing.T) {
+	bin := t.TempDir()
+
+	script := `#!/bin/sh
+echo "$@"
+cat "$4/group_vars/dmz.yml"
+`
+	if err := os.WriteFile(filepath.Join(bin, "ansible-playbook"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	output := &bytes.Buffer{}
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir:    bin,
+			Inventories:      []string{"tests/inventories/production"},
+			Playbooks:        []string{"tests/test.yml"},
+			SkipVersionCheck: true,
+			SSHGroupArgs: map[string]SSHArgs{
+				"dmz":      {CommonArgs: "-o StrictHostKeyChecking=yes", ProxyJump: "bastion.example.com", User: "deploy", Port: 2222},
+				"internal": {User: "ops"},
+			},
+		},
+		Output: output,
+	}
+
+	if err := playbook.Exec(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(output.String(), "\n--inventory tests/inventories/production --inventory ") {
+		t.Errorf("Expected the group vars inventory after the inventory, got '%s'", output)
+	}
+
+	for _, expected := range []string{
+		"ansible_port: 2222",
+		"ansible_ssh_common_args: -o StrictHostKeyChecking=yes -o ProxyJump=bastion.example.com",
+		"ansible_user: deploy",
+	} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("Expected the group vars to contain '%s', got '%s'", expected, output)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package ansible
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// StrategyError is returned if the strategy plugin is not installed.
+//
+// The strategy only changes how the hosts of a batch progress through the
+// tasks. With the free strategy, failed hosts do not stop the others within
+// a batch, so max_fail_percentage and serial of the plays and the
+// MaxFailPercentage of a Rollout only take effect after a batch or wave.
+type StrategyError struct {
+	Strategy   string
+	Suggestion string
+	Reason     string
+}
+
+func (e *StrategyError) Error() string {
+	switch {
+	case e.Reason != "":
+		return fmt.Sprintf("strategy %s %s", e.Strategy, e.Reason)
+	case e.Suggestion != "":
+		return fmt.Sprintf("unknown strategy %q, did you mean %q?", e.Strategy, e.Suggestion)
+	default:
+		return fmt.Sprintf("unknown strategy %q", e.Strategy)
+	}
+}
+
+// checkStrategy fails early if the strategy plugin is not installed. The
+// mitogen strategies are not documented, so StrategyPluginPath has to
+// contain them. Strategies of collections require their collection.
+func (p *AnsiblePlaybook) checkStrategy() error {
+	strategy := p.Config.Strategy
+
+	if strings.HasPrefix(strategy, "mitogen_") {
+		for _, dir := range p.Config.StrategyPluginPath {
+			if _, err := os.Stat(filepath.Join(dir, strategy+".py")); err == nil {
+				return nil
+			}
+		}
+
+		return &StrategyError{Strategy: strategy, Reason: "requires the mitogen strategy plugins in the strategy plugin path"}
+	}
+
+	if collection, ok := playbookCollection(strategy); ok && !builtinCollections[collection] {
+		if !p.collectionInstalled(collection) {
+			return &StrategyError{Strategy: strategy, Reason: "requires the collection " + collection}
+		}
+	}
+
+	plugins, err := p.plugins("strategy")
+	if err != nil {
+		return err
+	}
+
+	if names, ok := matchPlugin(strategy, plugins); !ok {
+		return &StrategyError{Strategy: strategy, Suggestion: closest(strategy, names)}
+	}
+
+	return nil
+}
+
+// strategyEnv returns the default strategy of the plays.
+func (p *AnsiblePlaybook) strategyEnv() []string {
+	if p.Config.Strategy == "" {
+		return nil
+	}
+
+	return []string{"ANSIBLE_STRATEGY=" + p.Config.Strategy}
+}
@@ -0,0 +1,72 @@
+package ansible
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestCheckStrategy tests the strategy is validated against the installed
+// strategy plugins, collections and the mitogen plugin path.
+func TestCheckStrategy(t *testing.T) {
+	bin := t.TempDir()
+	mitogen := t.TempDir()
+
+	scripts := map[string]string{
+		"ansible-doc": `#!/bin/sh
+echo 'ansible.builtin.free        Executes tasks without waiting for all hosts'
+echo 'ansible.builtin.linear      Executes tasks in a linear fashion'
+echo 'ansible.builtin.host_pinned Executes tasks on each host without interruption'
+`,
+		"ansible-galaxy": "#!/bin/sh\nexit 0\n",
+	}
+
+	for name, script := range scripts {
+		if err := os.WriteFile(filepath.Join(bin, name), []byte(script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(mitogen, "mitogen_linear.py"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		strategy   string
+		suggestion string
+		reason     bool
+		valid      bool
+	}{
+		{"free", "", false, true},
+		{"ansible.builtin.linear", "", false, true},
+		{"mitogen_linear", "", false, true},
+		{"mitogen_free", "", true, false},
+		{"community.general.batch", "", true, false},
+		{"fre", "free", false, false},
+	}
+
+	for _, test := range tests {
+		playbook := &AnsiblePlaybook{
+			Config: Config{
+				AnsibleBinDir:      bin,
+				Strategy:           test.strategy,
+				StrategyPluginPath: []string{mitogen},
+			},
+		}
+
+		err := playbook.checkStrategy()
+		if test.valid {
+			if err != nil {
+				t.Errorf("Expected %s to be valid, got %v", test.strategy, err)
+			}
+
+			continue
+		}
+
+		var strategy *StrategyError
+		if !errors.As(err, &strategy) || strategy.Suggestion != test.suggestion || (strategy.Reason != "") != test.reason {
+			t.Errorf("Expected %s to be invalid, got %v", test.strategy, err)
+		}
+	}
+}
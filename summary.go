@@ -0,0 +1,195 @@
+package ansible
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Run statuses of the summary.
+const (
+	RunSucceeded = "succeeded"
+	RunChanged   = "changed"
+	RunFailed    = "failed"
+	RunCanceled  = "canceled"
+)
+
+// RunSummary is the machine readable summary of a run, written as a single
+// JSON line to the Summary writer.
+type RunSummary struct {
+	RunID       string    `json:"run_id,omitempty"`
+	Status      string    `json:"status"`
+	Started     time.Time `json:"started"`
+	Duration    float64   `json:"duration_seconds"`
+	Inventories int       `json:"inventories"`
+	Hosts       int       `json:"hosts"`
+	Recap       HostStats `json:"recap"`
+	ErrorClass  string    `json:"error_class,omitempty"`
+	ExitCode    int       `json:"exit_code,omitempty"`
+	Error       string    `json:"error,omitempty"`
+
+	Galaxy *GalaxyReport `json:"galaxy,omitempty"`
+}
+
+// NewRunSummary summarizes the results and the error of a run.
+func NewRunSummary(started time.Time, results []*RunResult, err error) *RunSummary {
+	summary := &RunSummary{
+		Status:      RunSucceeded,
+		Started:     started,
+		Duration:    time.Since(started).Seconds(),
+		Inventories: len(results),
+	}
+
+	// Retries report the same host again, the last result counts.
+	hosts := map[string]HostStats{}
+	for _, result := range results {
+		for host, stats := range result.Stats {
+			hosts[host] = stats
+		}
+	}
+
+	for _, stats := range hosts {
+		summary.Recap.Ok += stats.Ok
+		summary.Recap.Changed += stats.Changed
+		summary.Recap.Unreachable += stats.Unreachable
+		summary.Recap.Failed += stats.Failed
+		summary.Recap.Skipped += stats.Skipped
+		summary.Recap.Rescued += stats.Rescued
+		summary.Recap.Ignored += stats.Ignored
+	}
+
+	summary.Hosts = len(hosts)
+
+	if err != nil {
+		summary.Status = RunFailed
+		summary.Error = err.Error()
+		summary.ErrorClass = errorClass(err)
+
+		var exitErr *CommandError
+		if errors.As(err, &exitErr) {
+			summary.ExitCode = exitErr.ExitCode
+		}
+
+		switch summary.ErrorClass {
+		case "changed":
+			summary.Status = RunChanged
+		case "canceled", "timeout":
+			summary.Status = RunCanceled
+		}
+	}
+
+	return summary
+}
+
+// errorClass returns a stable name for the kind of the error.
+func errorClass(err error) string {
+	var (
+		approval    *ApprovalError
+		become      *BecomeMethodError
+		changed     *ChangedError
+		confirm     *ConfirmationError
+		connection  *ConnectionPluginError
+		crypto      *CryptoPolicyError
+		collections *MissingCollectionsError
+		deadline    *DeadlineError
+		deprecation *DeprecationError
+		idempotency *IdempotencyError
+		inventory   *ErrInventoryNotFound
+		script      *InventoryScriptError
+		multi       *MultiError
+		playbook    *MissingPlaybookError
+		protected   *ProtectedInventoryError
+		strategy    *StrategyError
+		envConflict *EnvConflictError
+		upload      *ArtifactUploadError
+		prescan     *UnreachableHostsError
+		network     *NetworkAccessError
+		unsafe      *UnsafeValueError
+		exitErr     *CommandError
+	)
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.As(err, &approval):
+		return "approval"
+	case errors.As(err, &become):
+		return "become_method"
+	case errors.As(err, &changed):
+		return "changed"
+	case errors.As(err, &confirm):
+		return "confirmation"
+	case errors.As(err, &connection):
+		return "connection_plugin"
+	case errors.As(err, &crypto):
+		return "crypto_policy"
+	case errors.As(err, &collections):
+		return "missing_collections"
+	case errors.As(err, &deadline):
+		return "deadline"
+	case errors.As(err, &deprecation):
+		return "deprecation"
+	case errors.As(err, &idempotency):
+		return "idempotency"
+	case errors.As(err, &inventory):
+		return "inventory_not_found"
+	case errors.As(err, &script):
+		return "inventory_script"
+	case errors.Is(err, ErrNoPlaybooks):
+		return "no_playbooks"
+	case errors.Is(err, ErrGalaxyFileNotFound):
+		return "galaxy_file_not_found"
+	case errors.Is(err, ErrModulePathNotFound):
+		return "module_path_not_found"
+	case errors.Is(err, ErrChrootDirNotFound):
+		return "chroot_dir_not_found"
+	case errors.Is(err, ErrInvalidTimeouts):
+		return "invalid_timeouts"
+	case errors.Is(err, ErrInvalidFactGathering):
+		return "invalid_fact_gathering"
+	case errors.Is(err, ErrOutsideWindow):
+		return "outside_window"
+	case errors.Is(err, ErrInvalidMaintenanceWindow):
+		return "invalid_maintenance_window"
+	case errors.As(err, &multi):
+		return "multiple"
+	case errors.As(err, &playbook):
+		return "missing_playbook"
+	case errors.As(err, &network):
+		return "network_access"
+	case errors.As(err, &protected):
+		return "protected_inventory"
+	case errors.As(err, &strategy):
+		return "strategy"
+	case errors.As(err, &envConflict):
+		return "env_conflict"
+	case errors.As(err, &upload):
+		return "artifact_upload"
+	case errors.As(err, &prescan):
+		return "unreachable_hosts"
+	case errors.As(err, &unsafe):
+		return "unsafe_value"
+	case errors.As(err, &exitErr):
+		return "exit"
+	default:
+		return "error"
+	}
+}
+
+// runSummary summarizes the run with its ID and galaxy report.
+func (p *AnsiblePlaybook) runSummary(started time.Time, err error) *RunSummary {
+	summary := NewRunSummary(started, p.Results, err)
+	summary.RunID = p.runID
+	summary.Galaxy = p.Galaxy
+	summary.Error = string(p.redact([]byte(summary.Error)))
+
+	return summary
+}
+
+func (p *AnsiblePlaybook) writeSummary(started time.Time, err error) {
+	line, _ := json.Marshal(p.runSummary(started, err))
+	p.Summary.Write(append(line, '\n'))
+}
@@ -0,0 +1,79 @@
+package ansible
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestRunSummary tests the summary is written as a single JSON line.
+func TestRunSummary(t *testing.T) {
+	bin := t.TempDir()
+
+	script := `#!/bin/sh
+echo "PLAY RECAP *********************************************************************"
+echo "web1                       : ok=3    changed=1    unreachable=0    failed=0"
+echo "web2                       : ok=2    changed=0    unreachable=0    failed=1"
+exit 2
+`
+	if err := os.WriteFile(filepath.Join(bin, "ansible-playbook"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	var output, summary bytes.Buffer
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir:    bin,
+			Inventories:      []string{"tests/inventories/production"},
+			Playbooks:        []string{"tests/test.yml"},
+			SkipVersionCheck: true,
+		},
+		Output:  &output,
+		Summary: &summary,
+	}
+
+	if err := playbook.Exec(); err == nil {
+		t.Fatal("Expected the run to fail")
+	}
+
+	if bytes.Count(summary.Bytes(), []byte("\n")) != 1 {
+		t.Fatalf("Expected a single line, got '%s'", summary.String())
+	}
+
+	var result RunSummary
+	if err := json.Unmarshal(summary.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Status != RunFailed || result.ErrorClass != "exit" || result.ExitCode != 2 {
+		t.Errorf("Expected a failed run with exit code 2, got %+v", result)
+	}
+
+	if result.Hosts != 2 || result.Recap.Ok != 5 || result.Recap.Changed != 1 || result.Recap.Failed != 1 {
+		t.Errorf("Expected the aggregated recap, got %+v", result)
+	}
+}
+
+// TestErrorClass tests the error classes of the summary.
+func TestErrorClass(t *testing.T) {
+	tests := map[string]error{
+		"canceled":    context.Canceled,
+		"changed":     &ChangedError{},
+		"multiple":    &MultiError{},
+		"idempotency": &IdempotencyError{},
+	}
+
+	for expected, err := range tests {
+		if class := errorClass(err); class != expected {
+			t.Errorf("Expected error class %s, got %s", expected, class)
+		}
+	}
+
+	if summary := NewRunSummary(time.Now(), nil, &ChangedError{}); summary.Status != RunChanged {
+		t.Errorf("Expected status %s, got %s", RunChanged, summary.Status)
+	}
+}
@@ -0,0 +1,96 @@
+package ansible
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var taskTagsPattern = regexp.MustCompile(`TASK TAGS: \[(.*)\]`)
+
+// TagMap maps playbooks to their tags.
+type TagMap map[string][]string
+
+// All returns the deduplicated tags of all playbooks.
+func (m TagMap) All() []string {
+	seen := map[string]bool{}
+	for _, tags := range m {
+		for _, tag := range tags {
+			seen[tag] = true
+		}
+	}
+
+	return sortedKeys(seen)
+}
+
+// ListTags runs --list-tags for every resolved playbook and returns the
+// deduplicated tags per playbook.
+func (p *AnsiblePlaybook) ListTags() (TagMap, error) {
+	playbooks := p.globPlaybooks(p.Config.Playbooks)
+	if len(playbooks) == 0 {
+		return nil, ErrNoPlaybooks
+	}
+
+	inventory := "localhost,"
+	if len(p.Config.Inventories) > 0 {
+		inventory = p.Config.Inventories[0]
+	}
+
+	tags := TagMap{}
+	for _, playbook := range playbooks {
+		cmd := exec.Command(
+			p.binary("ansible-playbook"),
+			"--inventory",
+			inventory,
+			"--list-tags",
+			playbook,
+		)
+		cmd.Env = p.environ()
+
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+
+		output, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tags of %s: %s: %w", playbook, strings.TrimSpace(stderr.String()), err)
+		}
+
+		tags[playbook] = parseTaskTags(output)
+	}
+
+	return tags, nil
+}
+
+func parseTaskTags(output []byte) []string {
+	seen := map[string]bool{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(ansiEscapePattern.ReplaceAll(output, nil)))
+	for scanner.Scan() {
+		match := taskTagsPattern.FindStringSubmatch(scanner.Text())
+		if match == nil {
+			continue
+		}
+
+		for _, tag := range strings.Split(match[1], ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				seen[tag] = true
+			}
+		}
+	}
+
+	return sortedKeys(seen)
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,29 @@
+package ansible
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestParseTaskTags tests collecting the tags of all plays.
+func TestParseTaskTags(t *testing.T) {
+	output := []byte(`
+playbook: site.yml
+
+  play #1 (web): Configure web servers	TAGS: []
+      TASK TAGS: [nginx, packages]
+
+  play #2 (db): Configure databases	TAGS: []
+      TASK TAGS: [packages, postgres]
+`)
+
+	tags := parseTaskTags(output)
+	if strings.Join(tags, ",") != "nginx,packages,postgres" {
+		t.Errorf("Unexpected tags: %v", tags)
+	}
+
+	all := TagMap{"site.yml": tags, "db.yml": {"backup", "postgres"}}.All()
+	if strings.Join(all, ",") != "backup,nginx,packages,postgres" {
+		t.Errorf("Unexpected aggregated tags: %v", all)
+	}
+}
@@ -0,0 +1,262 @@
+package ansible
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+)
+
+var defaultTerraformAddressAttributes = []string{
+	"public_ip",
+	"ipv4_address",
+	"access_ip_v4",
+	"public_ip_address",
+	"private_ip",
+	"private_ip_address",
+}
+
+// TerraformInventory generates an inventory from a Terraform state file or
+// from the JSON written by `terraform output -json`.
+//
+// For state files every managed resource with an address attribute becomes
+// a host, grouped by resource type and by its tags or labels. Hosts are
+// named by their Name tag or name attribute, or by the resource address if
+// the name is not unique. For output
+// files the output named OutputName has to map group names to host lists.
+type TerraformInventory struct {
+	StateFile  string
+	OutputFile string
+	OutputName string
+
+	// ResourceTypes limits the hosts to the given resource types.
+	ResourceTypes []string
+
+	// AddressAttributes are the attributes used as ansible_host, the first
+	// one set wins.
+	AddressAttributes []string
+
+	// GroupTags limits the tags used for grouping. All tags are used if
+	// empty.
+	GroupTags []string
+}
+
+type terraformState struct {
+	Version   int `json:"version"`
+	Resources []struct {
+		Module    string `json:"module"`
+		Mode      string `json:"mode"`
+		Type      string `json:"type"`
+		Name      string `json:"name"`
+		Instances []struct {
+			IndexKey   interface{}            `json:"index_key"`
+			Attributes map[string]interface{} `json:"attributes"`
+		} `json:"instances"`
+	} `json:"resources"`
+}
+
+func (t *TerraformInventory) Inventory() (*Inventory, error) {
+	switch {
+	case t.StateFile != "":
+		return t.stateInventory()
+	case t.OutputFile != "":
+		return t.outputInventory()
+	default:
+		return nil, errors.New("terraform inventory requires a state or output file")
+	}
+}
+
+func (t *TerraformInventory) stateInventory() (*Inventory, error) {
+	content, err := os.ReadFile(t.StateFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read terraform state: %w", err)
+	}
+
+	var state terraformState
+	if err := json.Unmarshal(content, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse terraform state: %w", err)
+	}
+
+	if state.Version != 4 {
+		return nil, fmt.Errorf("unsupported terraform state version %d", state.Version)
+	}
+
+	addresses := t.AddressAttributes
+	if len(addresses) == 0 {
+		addresses = defaultTerraformAddressAttributes
+	}
+
+	type terraformHost struct {
+		name         string
+		address      string
+		resourceType string
+		ansibleHost  string
+		groups       []string
+	}
+
+	var hosts []terraformHost
+	names := map[string]int{}
+
+	for _, resource := range state.Resources {
+		if resource.Mode != "managed" || !t.includeType(resource.Type) {
+			continue
+		}
+
+		for _, instance := range resource.Instances {
+			ansibleHost := stringAttribute(instance.Attributes, addresses...)
+			if ansibleHost == "" {
+				continue
+			}
+
+			address := resource.Type + "." + resource.Name
+			if resource.Module != "" {
+				address = resource.Module + "." + address
+			}
+
+			if instance.IndexKey != nil {
+				address = fmt.Sprintf("%s[%v]", address, instance.IndexKey)
+			}
+
+			name := address
+			if tags, ok := instance.Attributes["tags"].(map[string]interface{}); ok {
+				if tagName, ok := tags["Name"].(string); ok && tagName != "" {
+					name = tagName
+				}
+			} else if n := stringAttribute(instance.Attributes, "name"); n != "" {
+				name = n
+			}
+
+			names[name]++
+			hosts = append(hosts, terraformHost{
+				name:         name,
+				address:      address,
+				resourceType: resource.Type,
+				ansibleHost:  ansibleHost,
+				groups:       t.tagGroups(instance.Attributes),
+			})
+		}
+	}
+
+	// Instances sharing a name would be merged into one host, they are
+	// named by their resource address instead.
+	inventory := NewInventory()
+	for _, host := range hosts {
+		name := host.name
+		if names[name] > 1 {
+			name = host.address
+		}
+
+		inventory.AddHost(name, map[string]interface{}{
+			"ansible_host": host.ansibleHost,
+		}, host.resourceType)
+
+		for _, group := range host.groups {
+			inventory.AddToGroup(group, name)
+		}
+	}
+
+	return inventory, nil
+}
+
+func (t *TerraformInventory) outputInventory() (*Inventory, error) {
+	content, err := os.ReadFile(t.OutputFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read terraform output: %w", err)
+	}
+
+	var outputs map[string]struct {
+		Value map[string][]string `json:"value"`
+	}
+	if err := json.Unmarshal(content, &outputs); err != nil {
+		return nil, fmt.Errorf("failed to parse terraform output: %w", err)
+	}
+
+	name := t.OutputName
+	if name == "" {
+		name = "ansible_inventory"
+	}
+
+	output, ok := outputs[name]
+	if !ok {
+		return nil, fmt.Errorf("terraform output %s not found", name)
+	}
+
+	inventory := NewInventory()
+	for group, hosts := range output.Value {
+		for _, host := range hosts {
+			inventory.AddHost(host, nil, group)
+		}
+	}
+
+	return inventory, nil
+}
+
+func (t *TerraformInventory) includeType(resourceType string) bool {
+	if len(t.ResourceTypes) == 0 {
+		return true
+	}
+
+	for _, allowed := range t.ResourceTypes {
+		if allowed == resourceType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// tagGroups returns the groups derived from the tags (AWS, Azure), labels
+// (GCP) or tag lists (DigitalOcean, Hetzner) of a resource.
+func (t *TerraformInventory) tagGroups(attributes map[string]interface{}) []string {
+	var groups []string
+
+	for _, attribute := range []string{"tags", "labels"} {
+		switch tags := attributes[attribute].(type) {
+		case map[string]interface{}:
+			for key, value := range tags {
+				if !t.includeTag(key) {
+					continue
+				}
+
+				groups = append(groups, tagGroup("tag", key, fmt.Sprint(value)))
+			}
+		case []interface{}:
+			for _, value := range tags {
+				key := fmt.Sprint(value)
+				if !t.includeTag(key) {
+					continue
+				}
+
+				groups = append(groups, tagGroup("tag", key, ""))
+			}
+		}
+	}
+
+	sort.Strings(groups)
+	return groups
+}
+
+func (t *TerraformInventory) includeTag(key string) bool {
+	if len(t.GroupTags) == 0 {
+		return true
+	}
+
+	for _, allowed := range t.GroupTags {
+		if allowed == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+func stringAttribute(attributes map[string]interface{}, names ...string) string {
+	for _, name := range names {
+		if value, ok := attributes[name].(string); ok && value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
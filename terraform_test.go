@@ -0,0 +1,110 @@
+package ansible
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestTerraformStateInventory tests generating an inventory from a state file.
+func TestTerraformStateInventory(t *testing.T) {
+	source := &TerraformInventory{
+		StateFile: "tests/terraform/terraform.tfstate",
+		GroupTags: []string{"Role"},
+	}
+
+	inventory, err := source.Inventory()
+	if err != nil {
+		t.Fatalf("Inventory() failed: %s", err)
+	}
+
+	// Assert that hosts are named by tag or address and use the public address.
+	if inventory.Hosts["web-1"]["ansible_host"] != "203.0.113.10" {
+		t.Errorf("Unexpected host vars: %+v", inventory.Hosts)
+	}
+
+	if inventory.Hosts["aws_instance.web[1]"]["ansible_host"] != "10.0.1.11" {
+		t.Errorf("Unexpected host vars: %+v", inventory.Hosts)
+	}
+
+	// Assert that the groups are derived from the resource type and tags.
+	if len(inventory.Groups["aws_instance"]) != 2 || len(inventory.Groups["tag_Role_web"]) != 2 {
+		t.Errorf("Unexpected groups: %+v", inventory.Groups)
+	}
+
+	if _, ok := inventory.Groups["tag_Env_prod"]; ok {
+		t.Error("Expected tags outside of GroupTags to be ignored")
+	}
+
+	if len(inventory.Hosts) != 2 {
+		t.Errorf("Expected 2 hosts, got %+v", inventory.Hosts)
+	}
+}
+
+// TestTerraformOutputInventory tests generating an inventory from terraform output.
+func TestTerraformOutputInventory(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "output.json")
+	content := `{"ansible_inventory": {"sensitive": false, "type": "object", "value": {"web": ["10.0.1.10", "10.0.1.11"]}}}`
+	if err := os.WriteFile(output, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	inventory, err := (&TerraformInventory{OutputFile: output}).Inventory()
+	if err != nil {
+		t.Fatalf("Inventory() failed: %s", err)
+	}
+
+	marshaled, err := inventory.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Assert that the hosts end up in the group of the generated YAML.
+	for _, expected := range []string{"web:", "10.0.1.10:", "10.0.1.11:"} {
+		if !strings.Contains(string(marshaled), expected) {
+			t.Errorf("Expected inventory to contain '%s', got:\n%s", expected, marshaled)
+		}
+	}
+}
+
+// TestTerraformStateInventoryDuplicateNames tests instances sharing a name
+// are kept apart by their resource address.
+func TestTerraformStateInventoryDuplicateNames(t *testing.T) {
+	state := filepath.Join(t.TempDir(), "terraform.tfstate")
+	content := `{"version": 4, "resources": [
+		{"mode": "managed", "type": "aws_instance", "name": "app", "instances": [
+			{"index_key": 0, "attributes": {"public_ip": "203.0.113.20", "tags": {"Name": "app", "Role": "app"}}},
+			{"index_key": 1, "attributes": {"public_ip": "203.0.113.21", "tags": {"Name": "app", "Role": "app"}}}
+		]},
+		{"mode": "managed", "type": "aws_instance", "name": "db", "instances": [
+			{"attributes": {"public_ip": "203.0.113.30", "tags": {"Name": "db"}}}
+		]}
+	]}`
+	if err := os.WriteFile(state, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	inventory, err := (&TerraformInventory{StateFile: state}).Inventory()
+	if err != nil {
+		t.Fatalf("Inventory() failed: %s", err)
+	}
+
+	for name, expected := range map[string]string{
+		"aws_instance.app[0]": "203.0.113.20",
+		"aws_instance.app[1]": "203.0.113.21",
+		"db":                  "203.0.113.30",
+	} {
+		if inventory.Hosts[name]["ansible_host"] != expected {
+			t.Errorf("Expected host %s with ansible_host %s, got %+v", name, expected, inventory.Hosts)
+		}
+	}
+
+	if _, ok := inventory.Hosts["app"]; ok || len(inventory.Hosts) != 3 {
+		t.Errorf("Expected 3 hosts without the shared name, got %+v", inventory.Hosts)
+	}
+
+	if len(inventory.Groups["tag_Role_app"]) != 2 {
+		t.Errorf("Unexpected groups: %+v", inventory.Groups)
+	}
+}
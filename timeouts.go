@@ -0,0 +1,93 @@
+package ansible
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// The timeouts of a run are stratified:
+//
+//   - ConnectTimeout limits establishing the connection to a host and is
+//     passed as --timeout. Timeout is the same in seconds and superseded by
+//     ConnectTimeout.
+//   - TaskTimeout limits every single task and is passed as
+//     ANSIBLE_TASK_TIMEOUT. A task exceeding it fails on its host.
+//   - RunTimeout limits the whole run including galaxy installs. The running
+//     command is killed once it is exceeded.
+//   - AsyncTimeout and AsyncPoll are the budget and the poll interval of
+//     long running async tasks, passed to the playbooks as extra vars.
+//     PollInterval is the interval ansible checks async tasks started
+//     without polling and passed as ANSIBLE_POLL_INTERVAL.
+//
+// Every timeout has to be shorter than the ones it is part of.
+
+// checkTimeouts fails with ErrInvalidTimeouts if the timeouts contradict
+// each other.
+func (p *AnsiblePlaybook) checkTimeouts() error {
+	c := p.Config
+
+	if c.Timeout < 0 || c.ConnectTimeout < 0 || c.TaskTimeout < 0 || c.RunTimeout < 0 ||
+		c.AsyncTimeout < 0 || c.AsyncPoll < 0 || c.PollInterval < 0 {
+		return fmt.Errorf("%w: timeouts must not be negative", ErrInvalidTimeouts)
+	}
+
+	if c.AsyncPoll != 0 && c.AsyncTimeout == 0 {
+		return fmt.Errorf("%w: async poll requires an async timeout", ErrInvalidTimeouts)
+	}
+
+	if c.Timeout != 0 && c.ConnectTimeout != 0 && time.Duration(c.Timeout)*time.Second != c.ConnectTimeout {
+		return fmt.Errorf("%w: timeout %ds differs from connect timeout %s", ErrInvalidTimeouts, c.Timeout, c.ConnectTimeout)
+	}
+
+	connect := time.Duration(c.connectTimeout()) * time.Second
+
+	limits := []struct {
+		name    string
+		timeout time.Duration
+		within  string
+		limit   time.Duration
+	}{
+		{"connect timeout", connect, "task timeout", c.TaskTimeout},
+		{"connect timeout", connect, "run timeout", c.RunTimeout},
+		{"task timeout", c.TaskTimeout, "run timeout", c.RunTimeout},
+		{"minimum remaining time", c.MinRemainingTime, "run timeout", c.RunTimeout},
+		{"async poll", c.AsyncPoll, "async timeout", c.AsyncTimeout},
+		{"async timeout", c.AsyncTimeout, "task timeout", c.TaskTimeout},
+		{"async timeout", c.AsyncTimeout, "run timeout", c.RunTimeout},
+	}
+
+	for _, l := range limits {
+		if l.timeout != 0 && l.limit != 0 && l.timeout >= l.limit {
+			return fmt.Errorf("%w: %s %s is not shorter than %s %s", ErrInvalidTimeouts, l.name, l.timeout, l.within, l.limit)
+		}
+	}
+
+	return nil
+}
+
+// connectTimeout returns the connect timeout in seconds, 0 for the default
+// of ansible.
+func (c *Config) connectTimeout() int {
+	if c.ConnectTimeout > 0 {
+		return seconds(c.ConnectTimeout)
+	}
+
+	return c.Timeout
+}
+
+// taskTimeoutEnv returns the timeout of every task.
+func (p *AnsiblePlaybook) taskTimeoutEnv() []string {
+	if p.Config.TaskTimeout <= 0 {
+		return nil
+	}
+
+	return []string{"ANSIBLE_TASK_TIMEOUT=" + strconv.Itoa(seconds(p.Config.TaskTimeout))}
+}
+
+// seconds rounds the duration up to whole seconds, as ansible does not
+// accept fractions.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,106 @@
+package ansible
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestCheckTimeouts tests contradicting timeouts are rejected.
+func TestCheckTimeouts(t *testing.T) {
+	tests := []struct {
+		config Config
+		valid  bool
+	}{
+		{Config{}, true},
+		{Config{Timeout: 10, TaskTimeout: time.Minute, RunTimeout: time.Hour}, true},
+		{Config{Timeout: 10, ConnectTimeout: 10 * time.Second}, true},
+		{Config{Timeout: 10, ConnectTimeout: 20 * time.Second}, false},
+		{Config{ConnectTimeout: time.Minute, TaskTimeout: time.Minute}, false},
+		{Config{TaskTimeout: time.Hour, RunTimeout: time.Minute}, false},
+		{Config{Timeout: 120, RunTimeout: time.Minute}, false},
+		{Config{MinRemainingTime: time.Hour, RunTimeout: time.Hour}, false},
+		{Config{TaskTimeout: -time.Second}, false},
+	}
+
+	for _, test := range tests {
+		playbook := &AnsiblePlaybook{Config: test.config}
+
+		err := playbook.checkTimeouts()
+		if test.valid && err != nil {
+			t.Errorf("Expected %+v to be valid, got %v", test.config, err)
+		}
+
+		if !test.valid && !errors.Is(err, ErrInvalidTimeouts) {
+			t.Errorf("Expected %+v to be invalid, got %v", test.config, err)
+		}
+	}
+}
+
+// TestTimeouts tests the connect timeout is passed as argument and the task
+// timeout in the environment.
+func TestTimeouts(t *testing.T) {
+	bin := t.TempDir()
+	log := filepath.Join(bin, "calls.log")
+
+	script := "#!/bin/sh\necho \"$ANSIBLE_TASK_TIMEOUT $*\" >> " + log + "\n"
+	if err := os.WriteFile(filepath.Join(bin, "ansible-playbook"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir:    bin,
+			ConnectTimeout:   1500 * time.Millisecond,
+			Inventories:      []string{"tests/inventories/production"},
+			Playbooks:        []string{"tests/test.yml"},
+			SkipVersionCheck: true,
+			TaskTimeout:      5 * time.Minute,
+		},
+	}
+
+	if err := playbook.Exec(); err != nil {
+		t.Fatalf("Exec should execute without error, but received: %v", err)
+	}
+
+	content, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(string(content), "300 ") || !strings.Contains(string(content), "--timeout 2") {
+		t.Errorf("Expected a task timeout of 300s and a connect timeout of 2s, got '%s'", content)
+	}
+}
+
+// TestRunTimeout tests the run is killed once the run timeout is exceeded.
+func TestRunTimeout(t *testing.T) {
+	bin := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(bin, "ansible-playbook"), []byte("#!/bin/sh\nexec sleep 10\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir:    bin,
+			Inventories:      []string{"tests/inventories/production"},
+			Playbooks:        []string{"tests/test.yml"},
+			RunTimeout:       100 * time.Millisecond,
+			SkipVersionCheck: true,
+		},
+	}
+
+	started := time.Now()
+	if err := playbook.Exec(); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the run to time out, got %v", err)
+	}
+
+	if time.Since(started) > 5*time.Second {
+		t.Errorf("Expected the run to be killed, took %s", time.Since(started))
+	}
+}
@@ -0,0 +1,38 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// RawMode switches the terminal to read single keys without echoing them and
+// returns a function restoring the previous mode. It requires stty.
+func RawMode(terminal *os.File) (func() error, error) {
+	state, err := stty(terminal, "-g")
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := stty(terminal, "-icanon", "-echo", "min", "1"); err != nil {
+		return nil, err
+	}
+
+	return func() error {
+		_, err := stty(terminal, state)
+		return err
+	}, nil
+}
+
+func stty(terminal *os.File, args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = terminal
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to configure terminal: %w", err)
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
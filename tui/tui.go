@@ -0,0 +1,245 @@
+// Package tui renders the progress of a run in a terminal from the event
+// stream: the hosts of every play with their task counts and current task,
+// and the details of failed tasks, which can be scrolled. The run can be
+// canceled by a key.
+package tui
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	ansible "github.com/arillso/go.ansible"
+)
+
+const (
+	clearScreen     = "\x1b[H\x1b[2J"
+	defaultFailures = 10
+)
+
+// UI renders the task events of a run. It implements ansible.EventHandler.
+type UI struct {
+	// Out receives the rendered screen.
+	Out io.Writer
+
+	// Failures is the number of failed tasks shown at once, 10 if zero.
+	Failures int
+
+	mu       sync.Mutex
+	plays    []*play
+	task     string
+	failures []ansible.TaskEvent
+	offset   int
+	status   string
+}
+
+type play struct {
+	name  string
+	hosts map[string]*host
+}
+
+type host struct {
+	counts map[string]int
+	task   string
+}
+
+// New returns a UI rendering to the terminal.
+func New(out io.Writer) *UI {
+	return &UI{Out: out}
+}
+
+// Run runs the playbook while rendering its events. Keys read from in
+// control the UI: j and k or the arrow keys scroll the failed tasks, q or
+// ctrl-c cancel the run. The input should be a terminal in raw mode, see
+// RawMode. The output of the commands is discarded unless the playbook has
+// an output.
+func (u *UI) Run(ctx context.Context, playbook *ansible.AnsiblePlaybook, in io.Reader) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	events, output := playbook.Config.Events, playbook.Output
+	defer func() { playbook.Config.Events, playbook.Output = events, output }()
+
+	playbook.Config.Events = u
+	if playbook.Output == nil {
+		playbook.Output = io.Discard
+	}
+
+	if in != nil {
+		go u.readKeys(in, cancel)
+	}
+
+	u.render()
+
+	err := playbook.ExecContext(ctx)
+
+	u.mu.Lock()
+	u.status = "finished"
+	if err != nil {
+		u.status = "failed: " + err.Error()
+	}
+	u.mu.Unlock()
+
+	u.render()
+	return err
+}
+
+// HandleEvent updates the progress with the event and renders it.
+func (u *UI) HandleEvent(event ansible.TaskEvent) {
+	u.mu.Lock()
+
+	switch event.Event {
+	case ansible.EventPlayStart:
+		u.plays = append(u.plays, &play{name: event.Play, hosts: map[string]*host{}})
+	case ansible.EventTaskStart, ansible.EventHandlerStart:
+		u.task = event.Task
+	case ansible.EventTaskResult:
+		h := u.host(event.Host)
+		h.counts[event.Status]++
+		h.task = event.Task
+
+		if (event.Status == ansible.StatusFailed && !event.Ignored) || event.Status == ansible.StatusUnreachable {
+			u.failures = append(u.failures, event)
+		}
+	}
+
+	u.mu.Unlock()
+	u.render()
+}
+
+// Scroll moves the failed tasks shown by lines.
+func (u *UI) Scroll(lines int) {
+	u.mu.Lock()
+	u.offset += lines
+	if last := len(u.failures) - u.failureLines(); u.offset > last {
+		u.offset = last
+	}
+
+	if u.offset < 0 {
+		u.offset = 0
+	}
+	u.mu.Unlock()
+
+	u.render()
+}
+
+// host returns the host of the current play.
+func (u *UI) host(name string) *host {
+	if len(u.plays) == 0 {
+		u.plays = append(u.plays, &play{hosts: map[string]*host{}})
+	}
+
+	p := u.plays[len(u.plays)-1]
+	if _, ok := p.hosts[name]; !ok {
+		p.hosts[name] = &host{counts: map[string]int{}}
+	}
+
+	return p.hosts[name]
+}
+
+func (u *UI) failureLines() int {
+	if u.Failures > 0 {
+		return u.Failures
+	}
+
+	return defaultFailures
+}
+
+func (u *UI) render() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	var b strings.Builder
+	b.WriteString(clearScreen)
+
+	for _, p := range u.plays {
+		fmt.Fprintf(&b, "PLAY %s (%d hosts)\n", p.name, len(p.hosts))
+
+		names := make([]string, 0, len(p.hosts))
+		for name := range p.hosts {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			h := p.hosts[name]
+			fmt.Fprintf(
+				&b,
+				"  %-24s ok=%-4d changed=%-4d failed=%-4d unreachable=%-4d skipped=%-4d %s\n",
+				name,
+				h.counts[ansible.StatusOk],
+				h.counts[ansible.StatusChanged],
+				h.counts[ansible.StatusFailed],
+				h.counts[ansible.StatusUnreachable],
+				h.counts[ansible.StatusSkipped],
+				h.task,
+			)
+		}
+	}
+
+	if u.task != "" && u.status == "" {
+		fmt.Fprintf(&b, "\nTASK %s\n", u.task)
+	}
+
+	if len(u.failures) > 0 {
+		fmt.Fprintf(&b, "\nFailed tasks (%d), j/k to scroll\n", len(u.failures))
+
+		end := u.offset + u.failureLines()
+		if end > len(u.failures) {
+			end = len(u.failures)
+		}
+
+		for _, failure := range u.failures[u.offset:end] {
+			fmt.Fprintf(&b, "  %s | %s | %s: %s\n", failure.Host, failure.Task, failure.Status, failure.Message)
+		}
+	}
+
+	if u.status != "" {
+		fmt.Fprintf(&b, "\nRun %s\n", u.status)
+	} else {
+		b.WriteString("\nq to cancel\n")
+	}
+
+	io.WriteString(u.Out, b.String())
+}
+
+// readKeys handles the keys until the input is closed.
+func (u *UI) readKeys(in io.Reader, cancel func()) {
+	r := bufio.NewReader(in)
+	for {
+		key, err := r.ReadByte()
+		if err != nil {
+			return
+		}
+
+		switch key {
+		case 'q', 0x03:
+			u.mu.Lock()
+			u.status = "canceling"
+			u.mu.Unlock()
+
+			cancel()
+		case 'j':
+			u.Scroll(1)
+		case 'k':
+			u.Scroll(-1)
+		case 0x1b:
+			// Arrow keys are sent as ESC [ A and ESC [ B.
+			if next, _ := r.ReadByte(); next != '[' {
+				continue
+			}
+
+			switch arrow, _ := r.ReadByte(); arrow {
+			case 'A':
+				u.Scroll(-1)
+			case 'B':
+				u.Scroll(1)
+			}
+		}
+	}
+}
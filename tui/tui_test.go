@@ -0,0 +1,79 @@
+package tui
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	ansible "github.com/arillso/go.ansible"
+)
+
+// TestHandleEvent tests the hosts of the play and the failed tasks are
+// rendered and the failed tasks can be scrolled.
+func TestHandleEvent(t *testing.T) {
+	out := &bytes.Buffer{}
+	ui := &UI{Out: out, Failures: 1}
+
+	for _, event := range []ansible.TaskEvent{
+		{Event: ansible.EventPlayStart, Play: "web"},
+		{Event: ansible.EventTaskStart, Task: "Install nginx"},
+		{Event: ansible.EventTaskResult, Task: "Install nginx", Host: "web1", Status: ansible.StatusChanged},
+		{Event: ansible.EventTaskResult, Task: "Install nginx", Host: "web2", Status: ansible.StatusFailed, Message: "no package"},
+		{Event: ansible.EventTaskResult, Task: "Install nginx", Host: "web3", Status: ansible.StatusUnreachable, Message: "timeout"},
+	} {
+		ui.HandleEvent(event)
+	}
+
+	screen := out.String()[strings.LastIndex(out.String(), clearScreen):]
+	for _, expected := range []string{"PLAY web (3 hosts)", "web1", "changed=1", "Failed tasks (2)", "web2 | Install nginx | failed: no package"} {
+		if !strings.Contains(screen, expected) {
+			t.Errorf("Expected the screen to contain '%s', got '%s'", expected, screen)
+		}
+	}
+
+	ui.Scroll(5)
+
+	screen = out.String()[strings.LastIndex(out.String(), clearScreen):]
+	if !strings.Contains(screen, "web3 | Install nginx | unreachable: timeout") || strings.Contains(screen, "no package") {
+		t.Errorf("Expected the second failure only, got '%s'", screen)
+	}
+}
+
+// TestRunCancel tests the run is canceled by the q key.
+func TestRunCancel(t *testing.T) {
+	bin := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(bin, "ansible-playbook"), []byte("#!/bin/sh\nexec sleep 10\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	playbook := &ansible.AnsiblePlaybook{
+		Config: ansible.Config{
+			AnsibleBinDir:    bin,
+			Inventories:      []string{"../tests/inventories/production"},
+			Playbooks:        []string{"../tests/test.yml"},
+			SkipVersionCheck: true,
+		},
+	}
+
+	out := &bytes.Buffer{}
+	started := time.Now()
+
+	err := New(out).Run(context.Background(), playbook, strings.NewReader("q"))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the run to be canceled, got %v", err)
+	}
+
+	if time.Since(started) > 5*time.Second {
+		t.Errorf("Expected the run to stop immediately")
+	}
+
+	if playbook.Config.Events != nil || playbook.Output != nil {
+		t.Errorf("Expected the playbook to be restored")
+	}
+}
@@ -0,0 +1,142 @@
+package ansible
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultForks        = 5
+	defaultTimeout      = 10
+	defaultPollInterval = 15
+
+	// maxSuggestedForks caps the suggested forks, as every fork is a
+	// python process on the controller.
+	maxSuggestedForks = 50
+)
+
+// Tunable settings.
+const (
+	SettingForks        = "forks"
+	SettingTimeout      = "timeout"
+	SettingPollInterval = "poll_interval"
+)
+
+// Advice suggests a value for a setting which is mismatched to the number of
+// hosts of an inventory.
+type Advice struct {
+	Setting   string `json:"setting"`
+	Current   int    `json:"current"`
+	Suggested int    `json:"suggested"`
+	Reason    string `json:"reason"`
+}
+
+func (a Advice) String() string {
+	return fmt.Sprintf("%s %d should be %d: %s", a.Setting, a.Current, a.Suggested, a.Reason)
+}
+
+// TuneFunc is called with the advice for an inventory before the run. The
+// suggested values are applied for the rest of the run if it returns true.
+type TuneFunc func(ctx context.Context, inventory string, advice []Advice) bool
+
+// Advise returns the settings of the configuration which are obviously
+// mismatched to the number of hosts.
+func (c *Config) Advise(hosts int) []Advice {
+	var advice []Advice
+
+	forks := c.Forks
+	if forks <= 0 {
+		forks = defaultForks
+	}
+
+	if hosts > 10*forks && forks < maxSuggestedForks {
+		suggested := hosts / 10
+		if suggested > maxSuggestedForks {
+			suggested = maxSuggestedForks
+		}
+
+		advice = append(advice, Advice{
+			Setting:   SettingForks,
+			Current:   forks,
+			Suggested: suggested,
+			Reason:    fmt.Sprintf("every task runs in %d batches for %d hosts", (hosts+forks-1)/forks, hosts),
+		})
+	}
+
+	timeout := c.connectTimeout()
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	if hosts >= 500 && timeout < 30 {
+		advice = append(advice, Advice{
+			Setting:   SettingTimeout,
+			Current:   timeout,
+			Suggested: 30,
+			Reason:    fmt.Sprintf("connections to %d hosts time out while the controller is busy", hosts),
+		})
+	}
+
+	poll := c.PollInterval
+	if poll <= 0 {
+		poll = defaultPollInterval
+	}
+
+	if hosts >= 100 && poll < 5 {
+		advice = append(advice, Advice{
+			Setting:   SettingPollInterval,
+			Current:   poll,
+			Suggested: defaultPollInterval,
+			Reason:    fmt.Sprintf("async tasks connect to %d hosts every %d seconds to poll their status", hosts, poll),
+		})
+	}
+
+	return advice
+}
+
+// advise prints the advice for the hosts of the inventory and applies it if
+// AutoTune agrees.
+func (p *AnsiblePlaybook) advise(inventory string) error {
+	hosts, err := p.PreviewHosts(inventory)
+	if err != nil {
+		return err
+	}
+
+	advice := p.Config.Advise(len(hosts))
+	if len(advice) == 0 {
+		return nil
+	}
+
+	for _, a := range advice {
+		fmt.Fprintf(p.output(), "advice: %s\n", a)
+	}
+
+	if p.Config.AutoTune == nil || !p.Config.AutoTune(p.context(), inventory, advice) {
+		return nil
+	}
+
+	for _, a := range advice {
+		switch a.Setting {
+		case SettingForks:
+			p.Config.Forks = a.Suggested
+		case SettingTimeout:
+			p.Config.ConnectTimeout = time.Duration(a.Suggested) * time.Second
+			p.Config.Timeout = 0
+		case SettingPollInterval:
+			p.Config.PollInterval = a.Suggested
+		}
+	}
+
+	return nil
+}
+
+// pollIntervalEnv returns the interval of async status polls.
+func (p *AnsiblePlaybook) pollIntervalEnv() []string {
+	if p.Config.PollInterval <= 0 {
+		return nil
+	}
+
+	return []string{"ANSIBLE_POLL_INTERVAL=" + strconv.Itoa(p.Config.PollInterval)}
+}
@@ -0,0 +1,90 @@
+package ansible
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestAdvise tests settings mismatched to the number of hosts are reported.
+func TestAdvise(t *testing.T) {
+	tests := []struct {
+		config   Config
+		hosts    int
+		settings []string
+	}{
+		{Config{Forks: 5}, 40, nil},
+		{Config{Forks: 5}, 2000, []string{SettingForks, SettingTimeout}},
+		{Config{Forks: 50, Timeout: 30}, 2000, nil},
+		{Config{Forks: 20, PollInterval: 1}, 150, []string{SettingPollInterval}},
+	}
+
+	for _, test := range tests {
+		var settings []string
+		for _, advice := range test.config.Advise(test.hosts) {
+			settings = append(settings, advice.Setting)
+		}
+
+		if !reflect.DeepEqual(settings, test.settings) {
+			t.Errorf("Expected advice for %v with %d hosts, got %v", test.settings, test.hosts, settings)
+		}
+	}
+
+	advice := (&Config{Forks: 5}).Advise(2000)[0]
+	if advice.Suggested != maxSuggestedForks {
+		t.Errorf("Expected %d forks to be suggested, got %d", maxSuggestedForks, advice.Suggested)
+	}
+}
+
+// TestAutoTune tests the advice is printed and applied once AutoTune agrees.
+func TestAutoTune(t *testing.T) {
+	bin := t.TempDir()
+	log := filepath.Join(bin, "calls.log")
+
+	script := `#!/bin/sh
+echo "$@" >> ` + log + `
+case "$*" in *--list-hosts*)
+printf '  play #1 (all): all\tTAGS: []\n    pattern: ['"'"'all'"'"']\n    hosts (60):\n'
+for i in $(seq 1 60); do echo "      host$i"; done
+esac
+`
+	if err := os.WriteFile(filepath.Join(bin, "ansible-playbook"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	var output bytes.Buffer
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir: bin,
+			AutoTune: func(ctx context.Context, inventory string, advice []Advice) bool {
+				return true
+			},
+			Forks:            5,
+			Inventories:      []string{"tests/inventories/production"},
+			Playbooks:        []string{"tests/test.yml"},
+			SkipVersionCheck: true,
+		},
+		Output: &output,
+	}
+
+	if err := playbook.Exec(); err != nil {
+		t.Fatalf("Exec should execute without error, but received: %v", err)
+	}
+
+	if !strings.Contains(output.String(), "advice: forks 5 should be 6: every task runs in 12 batches for 60 hosts") {
+		t.Errorf("Expected the forks advice in the output, got '%s'", output.String())
+	}
+
+	content, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(content), "--forks 6") {
+		t.Errorf("Expected the suggested forks to be applied, got '%s'", content)
+	}
+}
@@ -0,0 +1,60 @@
+package ansible
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// exitUnreachable is the exit code of ansible-playbook if hosts were
+// unreachable but no host failed.
+const exitUnreachable = 4
+
+func unreachableHosts(result *RunResult) []string {
+	var hosts []string
+
+	for host, stats := range result.Stats {
+		if stats.Unreachable > 0 {
+			hosts = append(hosts, host)
+		}
+	}
+
+	sort.Strings(hosts)
+	return hosts
+}
+
+// retryUnreachable reruns the playbooks limited to the unreachable hosts as
+// long as a run only failed because of unreachable hosts.
+func (p *AnsiblePlaybook) retryUnreachable(inventory string, result *RunResult, err error) (*RunResult, error) {
+	for attempt := 0; attempt < p.Config.UnreachableRetries; attempt++ {
+		var exitErr *CommandError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode != exitUnreachable {
+			break
+		}
+
+		hosts := unreachableHosts(result)
+		if len(hosts) == 0 {
+			break
+		}
+
+		if p.Config.UnreachableRetryDelay > 0 {
+			if sleepErr := p.sleep(p.Config.UnreachableRetryDelay); sleepErr != nil {
+				return result, sleepErr
+			}
+		}
+
+		fmt.Fprintf(p.output(), "retrying unreachable hosts %s\n", strings.Join(hosts, ", "))
+
+		p.withConfig(func(config *Config) {
+			config.Limit = strings.Join(hosts, ",")
+		}, func() error {
+			result, err = p.runPlaybook(inventory)
+			return err
+		})
+
+		p.Results = append(p.Results, result)
+	}
+
+	return result, err
+}
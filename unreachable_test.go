@@ -0,0 +1,72 @@
+package ansible
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestRetryUnreachable tests that only unreachable hosts are retried.
+func TestRetryUnreachable(t *testing.T) {
+	bin := t.TempDir()
+	log := filepath.Join(bin, "limits.log")
+
+	// The fake playbook run reports web2 unreachable unless it is limited.
+	script := `#!/bin/sh
+while [ $# -gt 0 ]; do [ "$1" = "--limit" ] && limit="$2"; shift; done
+echo "limit=$limit" >> ` + log + `
+echo "PLAY RECAP ***"
+if [ -n "$limit" ]; then echo "web2 : ok=1 changed=0 unreachable=0 failed=0"; exit 0; fi
+echo "web1 : ok=1 changed=0 unreachable=0 failed=0"
+echo "web2 : ok=0 changed=0 unreachable=1 failed=0"
+exit 4
+`
+	if err := os.WriteFile(filepath.Join(bin, "ansible-playbook"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(bin, "ansible"), []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	output := &bytes.Buffer{}
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir:      bin,
+			Inventories:        []string{"tests/inventories/production"},
+			Playbooks:          []string{"tests/test.yml"},
+			RunID:              "run-1",
+			UnreachableRetries: 2,
+		},
+		Output: output,
+	}
+
+	if err := playbook.Exec(); err != nil {
+		t.Fatalf("Exec should succeed after the retry, but received: %v", err)
+	}
+
+	content, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Assert that the retry was limited to the unreachable host.
+	if string(content) != "limit=\nlimit=web2\n" {
+		t.Errorf("Unexpected runs:\n%s", content)
+	}
+
+	if len(playbook.Results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(playbook.Results))
+	}
+
+	// Assert that the retry shares the run and its output.
+	if playbook.Results[1].RunID != "run-1" || playbook.Config.Limit != "" {
+		t.Errorf("Expected the retry to run as part of the run, got %+v", playbook.Results[1])
+	}
+
+	if !strings.Contains(output.String(), "retrying unreachable hosts web2") {
+		t.Errorf("Expected the retry to be reported, got %q", output.String())
+	}
+}
@@ -0,0 +1,32 @@
+package ansible
+
+import (
+	"fmt"
+	"strings"
+)
+
+// validate runs a syntax check for every playbook and inventory combination
+// and reports all combinations which failed.
+func (p *AnsiblePlaybook) validate() error {
+	var failed []string
+
+	for _, inventory := range p.runInventories() {
+		for _, playbook := range p.Config.Playbooks {
+			err := p.withConfig(func(config *Config) {
+				config.SyntaxCheck = true
+				config.Playbooks = []string{playbook}
+			}, func() error {
+				return p.runAuxiliary(p.ansibleCommand(inventory))
+			})
+			if err != nil {
+				failed = append(failed, fmt.Sprintf("%s with inventory %s", playbook, inventory))
+			}
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("syntax check failed for %s", strings.Join(failed, ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package ansible
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestValidateBeforeRun tests that no playbook runs if a syntax check fails.
+func TestValidateBeforeRun(t *testing.T) {
+	bin := t.TempDir()
+	log := filepath.Join(bin, "calls.log")
+
+	// The fake syntax check fails for the staging inventory.
+	script := `#!/bin/sh
+echo "$*" >> ` + log + `
+case "$*" in *--syntax-check*staging*|*staging*--syntax-check*) exit 4;; esac
+`
+	if err := os.WriteFile(filepath.Join(bin, "ansible-playbook"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	output := &bytes.Buffer{}
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir:     bin,
+			Inventories:       []string{"tests/inventories/production", "tests/inventories/staging"},
+			Playbooks:         []string{"tests/test.yml"},
+			SkipVersionCheck:  true,
+			ValidateBeforeRun: true,
+		},
+		Output: output,
+	}
+
+	err := playbook.Exec()
+	if err == nil || !strings.Contains(err.Error(), "tests/test.yml with inventory tests/inventories/staging") {
+		t.Fatalf("Expected a syntax check error for staging, got %v", err)
+	}
+
+	content, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Assert that only syntax checks were executed.
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		if !strings.Contains(line, "--syntax-check") {
+			t.Errorf("Expected only syntax checks, got '%s'", line)
+		}
+	}
+	// Assert that the checks wrote to the output of the run.
+	if !strings.Contains(output.String(), "--syntax-check") {
+		t.Errorf("Expected the syntax checks in the output, got %q", output.String())
+	}
+
+	if playbook.Config.SyntaxCheck {
+		t.Error("Expected the configuration to be restored")
+	}
+}
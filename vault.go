@@ -0,0 +1,74 @@
+package ansible
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// vaultSecret returns the --vault-id argument for the configured vault
+// secret. The returned function removes a temporary password file.
+func (p *AnsiblePlaybook) vaultSecret(vaultID string) (string, func(), error) {
+	cleanup := func() {}
+
+	if p.Config.VaultPassword != "" && p.Config.VaultPasswordFile == "" {
+		if err := p.vaultPass(); err != nil {
+			return "", cleanup, err
+		}
+
+		file := p.Config.VaultPasswordFile
+		cleanup = func() {
+			os.Remove(file)
+			p.Config.VaultPasswordFile = ""
+		}
+	}
+
+	switch {
+	case p.Config.VaultPasswordFile != "" && vaultID != "":
+		return vaultID + "@" + p.Config.VaultPasswordFile, cleanup, nil
+	case p.Config.VaultPasswordFile != "":
+		return p.Config.VaultPasswordFile, cleanup, nil
+	case p.Config.VaultID != "":
+		return p.Config.VaultID, cleanup, nil
+	default:
+		return "", cleanup, errors.New("no vault secret configured")
+	}
+}
+
+// EncryptString encrypts a value with the configured vault secret and
+// returns the !vault block ready to be used in YAML.
+func (p *AnsiblePlaybook) EncryptString(value, vaultID string) (string, error) {
+	secret, cleanup, err := p.vaultSecret(vaultID)
+	defer cleanup()
+
+	if err != nil {
+		return "", err
+	}
+
+	args := []string{
+		"encrypt_string",
+		"--vault-id",
+		secret,
+	}
+
+	if vaultID != "" {
+		args = append(args, "--encrypt-vault-id", vaultID)
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.Command(p.binary("ansible-vault"), args...)
+	cmd.Stdin = strings.NewReader(value)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.Env = p.environ()
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("failed to encrypt string: %s: %w", strings.TrimSpace(stderr.String()), err)
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
@@ -0,0 +1,169 @@
+package ansible
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const vaultHeader = "$ANSIBLE_VAULT;"
+
+// VaultRekey configures the rekey of all vault encrypted files of a
+// directory from the configured vault secret to a new one.
+type VaultRekey struct {
+	Dir                  string
+	NewVaultID           string
+	NewVaultPassword     string
+	NewVaultPasswordFile string
+	DryRun               bool
+}
+
+// FindVaultFiles returns all vault encrypted files below the directory.
+func FindVaultFiles(dir string) ([]string, error) {
+	var files []string
+
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			if entry.Name() == ".git" {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		encrypted, err := isVaultFile(path)
+		if err != nil {
+			return err
+		}
+
+		if encrypted {
+			files = append(files, path)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find vault files: %w", err)
+	}
+
+	return files, nil
+}
+
+func isVaultFile(path string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	header, err := bufio.NewReader(file).Peek(len(vaultHeader))
+	if err != nil {
+		return false, nil
+	}
+
+	return string(header) == vaultHeader, nil
+}
+
+// canDecrypt reports whether the vault file can be decrypted with the vault
+// id argument.
+func (p *AnsiblePlaybook) canDecrypt(secret, path string) bool {
+	cmd := exec.Command(
+		p.binary("ansible-vault"),
+		"decrypt",
+		"--vault-id",
+		secret,
+		"--output",
+		"-",
+		path,
+	)
+	cmd.Env = p.environ()
+
+	return cmd.Run() == nil
+}
+
+// RekeyVault rekeys all vault files of a directory from the configured vault
+// secret to the new one. All files are verified to decrypt with the current
+// secret before any file is changed. With DryRun only the verification runs.
+// The rekeyed, or in dry run mode the verified, files are returned.
+func (p *AnsiblePlaybook) RekeyVault(rekey VaultRekey) ([]string, error) {
+	secret, cleanup, err := p.vaultSecret("")
+	defer cleanup()
+
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := FindVaultFiles(rekey.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var failed []string
+	for _, file := range files {
+		if !p.canDecrypt(secret, file) {
+			failed = append(failed, file)
+		}
+	}
+
+	if len(failed) > 0 {
+		return nil, fmt.Errorf("failed to decrypt vault files with the current secret: %s", strings.Join(failed, ", "))
+	}
+
+	if rekey.DryRun || len(files) == 0 {
+		return files, nil
+	}
+
+	newFile := rekey.NewVaultPasswordFile
+	if rekey.NewVaultPassword != "" {
+		file, err := p.tempFile("vaultPass", []byte(rekey.NewVaultPassword))
+		if err != nil {
+			return nil, fmt.Errorf("failed to write vault password file: %w", err)
+		}
+		defer os.Remove(file)
+
+		newFile = file
+	}
+
+	if newFile == "" {
+		return nil, errors.New("no new vault secret configured")
+	}
+
+	args := []string{
+		"rekey",
+		"--vault-id",
+		secret,
+	}
+
+	if rekey.NewVaultID != "" {
+		args = append(args, "--new-vault-id", rekey.NewVaultID+"@"+newFile)
+	} else {
+		args = append(args, "--new-vault-password-file", newFile)
+	}
+
+	var output bytes.Buffer
+
+	cmd := exec.Command(p.binary("ansible-vault"), append(args, files...)...)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	cmd.Env = p.environ()
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to rekey vault files: %s: %w", strings.TrimSpace(output.String()), err)
+	}
+
+	return files, nil
+}
@@ -0,0 +1,109 @@
+package ansible
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// prepareVaultTree creates a directory with two vault files and a plain file,
+// and a fake ansible-vault which can only decrypt files containing "good".
+func prepareVaultTree(t *testing.T) (string, string) {
+	dir := t.TempDir()
+	bin := t.TempDir()
+
+	files := map[string]string{
+		"group_vars/all/vault.yml": "$ANSIBLE_VAULT;1.1;AES256\ngood\n",
+		"host_vars/web1/vault.yml": "$ANSIBLE_VAULT;1.2;AES256;prod\ngood\n",
+		"playbook.yml":             "---\n- hosts: all\n",
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	script := `#!/bin/sh
+echo "$@" >> ` + filepath.Join(bin, "calls.log") + `
+for last; do :; done
+[ "$1" = "rekey" ] && exit 0
+grep -q good "$last"
+`
+	if err := os.WriteFile(filepath.Join(bin, "ansible-vault"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	return dir, bin
+}
+
+// TestRekeyVault tests that all vault files are verified and rekeyed.
+func TestRekeyVault(t *testing.T) {
+	dir, bin := prepareVaultTree(t)
+
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir: bin,
+			VaultPassword: "old",
+		},
+	}
+
+	files, err := playbook.RekeyVault(VaultRekey{
+		Dir:              dir,
+		NewVaultID:       "prod",
+		NewVaultPassword: "new",
+	})
+	if err != nil {
+		t.Fatalf("RekeyVault failed: %s", err)
+	}
+
+	if len(files) != 2 {
+		t.Errorf("Expected 2 vault files, got %v", files)
+	}
+
+	calls, err := os.ReadFile(filepath.Join(bin, "calls.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(calls), "rekey") || !strings.Contains(string(calls), "--new-vault-id prod@") {
+		t.Errorf("Expected rekey call, got:\n%s", calls)
+	}
+}
+
+// TestRekeyVaultVerify tests that nothing is rekeyed if a file cannot be decrypted.
+func TestRekeyVaultVerify(t *testing.T) {
+	dir, bin := prepareVaultTree(t)
+
+	broken := filepath.Join(dir, "group_vars", "all", "broken.yml")
+	if err := os.WriteFile(broken, []byte("$ANSIBLE_VAULT;1.1;AES256\nbad\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir: bin,
+			VaultPassword: "old",
+		},
+	}
+
+	_, err := playbook.RekeyVault(VaultRekey{Dir: dir, NewVaultPassword: "new"})
+	if err == nil || !strings.Contains(err.Error(), broken) {
+		t.Fatalf("Expected error mentioning %s, got %v", broken, err)
+	}
+
+	calls, err := os.ReadFile(filepath.Join(bin, "calls.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(calls), "rekey") {
+		t.Error("Expected no rekey after a failed verification")
+	}
+}
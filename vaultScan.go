@@ -0,0 +1,219 @@
+package ansible
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// VaultFinding is a vault encrypted file or variable together with the
+// configured vault ids which can decrypt it. A finding without vault ids can
+// not be decrypted by any configured secret.
+type VaultFinding struct {
+	File     string
+	Variable string
+	Label    string
+	VaultIDs []string
+}
+
+// vaultIdentity is a configured vault secret with its vault id and the
+// --vault-id argument passing it to ansible.
+type vaultIdentity struct {
+	id     string
+	secret string
+}
+
+// newVaultIdentity returns the identity of a --vault-id argument, which
+// has the vault id "default" without a label.
+func newVaultIdentity(secret string) vaultIdentity {
+	if id, _, ok := strings.Cut(secret, "@"); ok {
+		return vaultIdentity{id: id, secret: secret}
+	}
+
+	return vaultIdentity{id: "default", secret: secret}
+}
+
+// vaultSecrets returns the identities of all configured vault secrets.
+func (p *AnsiblePlaybook) vaultSecrets() ([]vaultIdentity, func(), error) {
+	var secrets []vaultIdentity
+
+	secret, cleanup, err := p.vaultSecret("")
+	if err == nil {
+		secrets = append(secrets, newVaultIdentity(secret))
+	}
+
+	for _, secret := range p.Config.VaultIDs {
+		secrets = append(secrets, newVaultIdentity(secret))
+	}
+
+	if len(secrets) == 0 {
+		return nil, cleanup, errors.New("no vault secret configured")
+	}
+
+	return secrets, cleanup, nil
+}
+
+// ScanVault reports every vault encrypted file and inline vault variable
+// below the directory and which configured vault ids can decrypt it.
+func (p *AnsiblePlaybook) ScanVault(dir string) ([]VaultFinding, error) {
+	secrets, cleanup, err := p.vaultSecrets()
+	defer cleanup()
+
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := FindVaultFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []VaultFinding
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read vault file: %w", err)
+		}
+
+		findings = append(findings, VaultFinding{
+			File:     file,
+			Label:    vaultLabel(string(content)),
+			VaultIDs: p.decryptableBy(secrets, file),
+		})
+	}
+
+	variables, err := findVaultVariables(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpdir, err := p.tempDir("vaultScan")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create vault scan directory: %w", err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	for i, variable := range variables {
+		tmpfile := filepath.Join(tmpdir, fmt.Sprintf("variable%d", i))
+		if err := p.writeFile(tmpfile, []byte(variable.content)); err != nil {
+			return nil, fmt.Errorf("failed to write vault variable: %w", err)
+		}
+
+		findings = append(findings, VaultFinding{
+			File:     variable.file,
+			Variable: variable.name,
+			Label:    vaultLabel(variable.content),
+			VaultIDs: p.decryptableBy(secrets, tmpfile),
+		})
+	}
+
+	return findings, nil
+}
+
+func (p *AnsiblePlaybook) decryptableBy(secrets []vaultIdentity, path string) []string {
+	ids := []string{}
+
+	for _, secret := range secrets {
+		if p.canDecrypt(secret.secret, path) {
+			ids = append(ids, secret.id)
+		}
+	}
+
+	return ids
+}
+
+// vaultLabel returns the vault id label of the 1.2 vault format.
+func vaultLabel(content string) string {
+	header := strings.SplitN(strings.TrimSpace(content), "\n", 2)[0]
+
+	fields := strings.Split(strings.TrimSpace(header), ";")
+	if len(fields) < 4 {
+		return ""
+	}
+
+	return fields[3]
+}
+
+type vaultVariable struct {
+	file    string
+	name    string
+	content string
+}
+
+// findVaultVariables returns all !vault tagged values of the YAML files below
+// the directory.
+func findVaultVariables(dir string) ([]vaultVariable, error) {
+	var variables []vaultVariable
+
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			if entry.Name() == ".git" {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		ext := filepath.Ext(path)
+		if ext != ".yml" && ext != ".yaml" {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		var node yaml.Node
+		if yaml.Unmarshal(content, &node) != nil {
+			return nil
+		}
+
+		collectVaultNodes(&node, "", func(name, value string) {
+			variables = append(variables, vaultVariable{
+				file:    path,
+				name:    name,
+				content: value,
+			})
+		})
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find vault variables: %w", err)
+	}
+
+	return variables, nil
+}
+
+func collectVaultNodes(node *yaml.Node, name string, found func(name, value string)) {
+	if node.Tag == "!vault" {
+		found(name, node.Value)
+		return
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			if name != "" {
+				key = name + "." + key
+			}
+
+			collectVaultNodes(node.Content[i+1], key, found)
+		}
+	default:
+		for _, child := range node.Content {
+			collectVaultNodes(child, name, found)
+		}
+	}
+}
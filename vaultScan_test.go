@@ -0,0 +1,72 @@
+package ansible
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestScanVault tests reporting vault files and inline variables.
+func TestScanVault(t *testing.T) {
+	dir, bin := prepareVaultTree(t)
+
+	vars := "db_user: app\ndb_password: !vault |\n  $ANSIBLE_VAULT;1.2;AES256;prod\n  bad\n"
+	if err := os.WriteFile(filepath.Join(dir, "group_vars", "all", "vars.yml"), []byte(vars), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir: bin,
+			VaultIDs:      []string{"prod@/etc/vault/prod"},
+		},
+	}
+
+	findings, err := playbook.ScanVault(dir)
+	if err != nil {
+		t.Fatalf("ScanVault failed: %s", err)
+	}
+
+	if len(findings) != 3 {
+		t.Fatalf("Expected 3 findings, got %+v", findings)
+	}
+
+	// Assert that the files are decryptable and the variable is not.
+	for _, finding := range findings {
+		if finding.Variable == "" && (len(finding.VaultIDs) != 1 || finding.VaultIDs[0] != "prod") {
+			t.Errorf("Expected %s to be decryptable, got %+v", finding.File, finding)
+		}
+
+		if finding.Variable != "" && (finding.Variable != "db_password" || finding.Label != "prod" || len(finding.VaultIDs) != 0) {
+			t.Errorf("Unexpected variable finding %+v", finding)
+		}
+	}
+}
+
+// TestScanVaultPassword tests the vault password is reported by the vault
+// id instead of the file it is passed in.
+func TestScanVaultPassword(t *testing.T) {
+	dir, bin := prepareVaultTree(t)
+
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir: bin,
+			VaultPassword: "secret",
+		},
+	}
+
+	findings, err := playbook.ScanVault(dir)
+	if err != nil {
+		t.Fatalf("ScanVault failed: %s", err)
+	}
+
+	if len(findings) != 2 {
+		t.Fatalf("Expected 2 findings, got %+v", findings)
+	}
+
+	for _, finding := range findings {
+		if len(finding.VaultIDs) != 1 || finding.VaultIDs[0] != "default" {
+			t.Errorf("Expected %s to be decryptable by the default vault id, got %+v", finding.File, finding)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package ansible
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestEncryptString tests that the value and vault id are passed to ansible-vault.
+func TestEncryptString(t *testing.T) {
+	bin := t.TempDir()
+
+	// The fake ansible-vault echoes its arguments and the plaintext.
+	script := "#!/bin/sh\necho '!vault |'\necho \"  $*\"\necho \"  $(cat)\"\necho 'Encryption successful' >&2\n"
+	if err := os.WriteFile(filepath.Join(bin, "ansible-vault"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir: bin,
+			VaultPassword: "secret",
+		},
+	}
+
+	block, err := playbook.EncryptString("s3cr3t", "prod")
+	if err != nil {
+		t.Fatalf("EncryptString failed: %s", err)
+	}
+
+	if !strings.HasPrefix(block, "!vault |") || !strings.Contains(block, "s3cr3t") {
+		t.Errorf("Unexpected block: %s", block)
+	}
+
+	if !strings.Contains(block, "--vault-id prod@") || !strings.Contains(block, "--encrypt-vault-id prod") {
+		t.Errorf("Expected vault id arguments, got: %s", block)
+	}
+
+	// Assert that the temporary password file was removed.
+	if playbook.Config.VaultPasswordFile != "" {
+		t.Error("Expected the temporary vault password file to be removed")
+	}
+}
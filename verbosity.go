@@ -0,0 +1,36 @@
+package ansible
+
+import "strconv"
+
+// maxVerbosity is the highest verbosity of ansible, -vvvvvv, which includes
+// the debug output of the connection plugins.
+const maxVerbosity = 6
+
+// verbosity returns the configured verbosity limited to the highest level.
+func (p *AnsiblePlaybook) verbosity() int {
+	if p.Config.Verbose > maxVerbosity {
+		return maxVerbosity
+	}
+
+	return p.Config.Verbose
+}
+
+// verboseArgs returns the verbose flag of the ansible commands unless the
+// verbosity is passed by the environment.
+func (p *AnsiblePlaybook) verboseArgs() []string {
+	if p.Config.VerboseEnv {
+		return nil
+	}
+
+	return (&ArgBuilder{}).Verbose(p.verbosity()).Args()
+}
+
+// verbosityEnv returns ANSIBLE_VERBOSITY if the verbosity is passed by the
+// environment, which applies to every ansible command of the run.
+func (p *AnsiblePlaybook) verbosityEnv() []string {
+	if p.verbosity() <= 0 || !p.Config.VerboseEnv {
+		return nil
+	}
+
+	return []string{"ANSIBLE_VERBOSITY=" + strconv.Itoa(p.verbosity())}
+}
@@ -0,0 +1,34 @@
+package ansible
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestVerbosity tests the verbosity is passed by flags limited to -vvvvvv or
+// by the environment.
+func TestVerbosity(t *testing.T) {
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			GalaxyFile: "requirements.yml",
+			Playbooks:  []string{"tests/test.yml"},
+			Verbose:    8,
+		},
+	}
+
+	for _, cmd := range [][]string{playbook.ansibleCommand("tests/inventories/production").Args, playbook.galaxyCollectionCommand().Args} {
+		if args := strings.Join(cmd, " "); !strings.Contains(args, " -vvvvvv") || strings.Contains(args, "-vvvvvvv") {
+			t.Errorf("Expected -vvvvvv, got %s", args)
+		}
+	}
+
+	playbook.Config.VerboseEnv = true
+
+	if args := strings.Join(playbook.ansibleCommand("tests/inventories/production").Args, " "); strings.Contains(args, " -v") {
+		t.Errorf("Expected no verbose flag, got %s", args)
+	}
+
+	if env := strings.Join(playbook.environ(), "\n"); !strings.Contains(env, "ANSIBLE_VERBOSITY=6") {
+		t.Errorf("Expected ANSIBLE_VERBOSITY=6 in the environment")
+	}
+}
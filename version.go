@@ -0,0 +1,265 @@
+package ansible
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// versionSuffixPattern matches the pre-release or build suffixes of a
+	// version, either semver like -beta.1 and +build.5 or the pre-releases
+	// of Python packages like rc1 and dev0.
+	versionSuffixPattern = regexp.MustCompile(`^(?:[-+][0-9A-Za-z][0-9A-Za-z.+-]*|(?:a|b|rc|dev|post)[0-9]+)$`)
+
+	// versionOperatorPattern matches the spaces between the operators of a
+	// constraint and their versions.
+	versionOperatorPattern = regexp.MustCompile(`(>=|<=|!=|==|>|<|=|~|\^)\s+`)
+)
+
+type version struct {
+	parts [3]int
+	pre   string
+}
+
+func parseVersion(value string) (version, error) {
+	var v version
+
+	value = strings.TrimPrefix(strings.TrimSpace(value), "v")
+	if value == "" {
+		return v, errors.New("empty version")
+	}
+
+	// Split off pre-release suffixes like 2.16.0rc1 or 2.16.0-beta1.
+	end := strings.IndexFunc(value, func(r rune) bool {
+		return r != '.' && (r < '0' || r > '9')
+	})
+	if end >= 0 {
+		if !versionSuffixPattern.MatchString(value[end:]) {
+			return v, fmt.Errorf("invalid version %q", value)
+		}
+
+		v.pre = strings.TrimLeft(value[end:], "-+")
+		value = value[:end]
+	}
+
+	fields := strings.Split(strings.TrimSuffix(value, "."), ".")
+	if len(fields) > 3 {
+		return v, fmt.Errorf("invalid version %q", value)
+	}
+
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return v, fmt.Errorf("invalid version %q", value)
+		}
+
+		v.parts[i] = n
+	}
+
+	return v, nil
+}
+
+func (v version) compare(o version) int {
+	for i := range v.parts {
+		if v.parts[i] != o.parts[i] {
+			if v.parts[i] < o.parts[i] {
+				return -1
+			}
+
+			return 1
+		}
+	}
+
+	switch {
+	case v.pre == o.pre:
+		return 0
+	case v.pre == "":
+		return 1
+	case o.pre == "":
+		return -1
+	case v.pre < o.pre:
+		return -1
+	default:
+		return 1
+	}
+}
+
+type versionCondition struct {
+	op string
+	v  version
+}
+
+func (c versionCondition) match(v version) bool {
+	cmp := v.compare(c.v)
+
+	switch c.op {
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	default:
+		return cmp == 0
+	}
+}
+
+// versionConstraint is a semver range. The outer slice holds alternatives
+// separated by "||", the inner slice conditions which all have to match.
+type versionConstraint [][]versionCondition
+
+func parseVersionConstraint(value string) (versionConstraint, error) {
+	var constraint versionConstraint
+
+	for _, group := range strings.Split(value, "||") {
+		var conditions []versionCondition
+
+		group = versionOperatorPattern.ReplaceAllString(group, "$1")
+
+		for _, term := range strings.FieldsFunc(group, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		}) {
+			parsed, err := parseVersionTerm(term)
+			if err != nil {
+				return nil, err
+			}
+
+			conditions = append(conditions, parsed...)
+		}
+
+		if len(conditions) == 0 {
+			return nil, fmt.Errorf("invalid version constraint %q", value)
+		}
+
+		constraint = append(constraint, conditions)
+	}
+
+	return constraint, nil
+}
+
+func parseVersionTerm(term string) ([]versionCondition, error) {
+	op := ""
+	for _, prefix := range []string{">=", "<=", "!=", "==", ">", "<", "=", "~", "^"} {
+		if strings.HasPrefix(term, prefix) {
+			op = prefix
+			term = strings.TrimSpace(term[len(prefix):])
+			break
+		}
+	}
+
+	// Wildcards like 2.15.x are handled like a tilde range.
+	fields := strings.Split(term, ".")
+	for i, field := range fields {
+		if field == "x" || field == "X" || field == "*" {
+			if op != "" {
+				return nil, fmt.Errorf("invalid version constraint %q", op+term)
+			}
+
+			op = "~"
+			fields = fields[:i]
+			break
+		}
+	}
+
+	if len(fields) == 0 {
+		return []versionCondition{{op: ">=", v: version{}}}, nil
+	}
+
+	v, err := parseVersion(strings.Join(fields, "."))
+	if err != nil {
+		return nil, err
+	}
+
+	switch op {
+	case "~":
+		upper := version{}
+		if len(fields) == 1 {
+			upper.parts[0] = v.parts[0] + 1
+		} else {
+			upper.parts = [3]int{v.parts[0], v.parts[1] + 1, 0}
+		}
+
+		return []versionCondition{{op: ">=", v: v}, {op: "<", v: upper}}, nil
+	case "^":
+		upper := version{parts: [3]int{v.parts[0] + 1, 0, 0}}
+		return []versionCondition{{op: ">=", v: v}, {op: "<", v: upper}}, nil
+	case "", "=", "==":
+		if len(fields) < 3 {
+			return parseVersionTerm("~" + term)
+		}
+
+		return []versionCondition{{op: "=", v: v}}, nil
+	default:
+		return []versionCondition{{op: op, v: v}}, nil
+	}
+}
+
+func (c versionConstraint) match(v version) bool {
+	for _, conditions := range c {
+		matched := true
+		for _, condition := range conditions {
+			if !condition.match(v) {
+				matched = false
+				break
+			}
+		}
+
+		if matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// selectAnsibleVersion picks the binary directory of the highest registered
+// ansible version matching the constraint.
+func (p *AnsiblePlaybook) selectAnsibleVersion() error {
+	constraint, err := parseVersionConstraint(p.Config.AnsibleVersionConstraint)
+	if err != nil {
+		return err
+	}
+
+	var (
+		available []string
+		best      string
+		bestV     version
+	)
+
+	for name := range p.Config.AnsibleVersions {
+		available = append(available, name)
+
+		v, err := parseVersion(name)
+		if err != nil {
+			return fmt.Errorf("invalid registered ansible version %q: %w", name, err)
+		}
+
+		if !constraint.match(v) {
+			continue
+		}
+
+		if best == "" || v.compare(bestV) > 0 {
+			best, bestV = name, v
+		}
+	}
+
+	if best == "" {
+		sort.Strings(available)
+		return fmt.Errorf(
+			"no registered ansible version matches %q, available: %s",
+			p.Config.AnsibleVersionConstraint,
+			strings.Join(available, ", "),
+		)
+	}
+
+	p.Config.AnsibleBinDir = p.Config.AnsibleVersions[best]
+	return nil
+}
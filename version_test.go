@@ -0,0 +1,96 @@
+package ansible
+
+import (
+	"testing"
+)
+
+// TestVersionConstraint tests matching versions against semver ranges.
+func TestVersionConstraint(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		expected   bool
+	}{
+		{">=2.14, <2.16", "2.15.5", true},
+		{">=2.14, <2.16", "2.16.0", false},
+		{"~2.15", "2.15.9", true},
+		{"~2.15", "2.16.0", false},
+		{"^2.14", "2.17.1", true},
+		{"2.15.x", "2.15.0", true},
+		{"2.15.x", "2.14.9", false},
+		{"2.15.5", "2.15.5", true},
+		{"<2.14 || >=2.16", "2.15.0", false},
+		{"<2.14 || >=2.16", "2.16.0", true},
+		{">=2.16", "2.16.0rc1", false},
+		{">= 2.15, < 2.17", "2.16.3", true},
+		{">= 2.15, < 2.17", "2.17.0", false},
+		{" ~ 2.15 || == 2.17.1 ", "2.17.1", true},
+		{">=2.15 <2.17", "2.16.0", true},
+		{">=2.15 <2.17", "2.18.0", false},
+		{">= 2.15 < 2.17", "2.17.0", false},
+		{">=2.16", "2.16.0-beta.1", false},
+		{">=2.16", "2.16.1+build.5", true},
+		{">=2.16", "2.17.0.dev0", true},
+	}
+
+	for _, test := range tests {
+		constraint, err := parseVersionConstraint(test.constraint)
+		if err != nil {
+			t.Fatalf("parseVersionConstraint(%q) failed: %s", test.constraint, err)
+		}
+
+		v, err := parseVersion(test.version)
+		if err != nil {
+			t.Fatalf("parseVersion(%q) failed: %s", test.version, err)
+		}
+
+		if constraint.match(v) != test.expected {
+			t.Errorf("Expected %q matching %q to be %v", test.version, test.constraint, test.expected)
+		}
+	}
+}
+
+// TestInvalidVersion tests versions and constraints with garbage suffixes
+// are rejected.
+func TestInvalidVersion(t *testing.T) {
+	for _, value := range []string{"2.15 <2.17", "2.15.0foo", "2.15.0-", "2.15.0 rc1", "2.x.0"} {
+		if _, err := parseVersion(value); err == nil {
+			t.Errorf("Expected version %q to be rejected", value)
+		}
+	}
+
+	for _, value := range []string{">=2.15<2.17", ">=2.15 <", "~2.15garbage"} {
+		if _, err := parseVersionConstraint(value); err == nil {
+			t.Errorf("Expected constraint %q to be rejected", value)
+		}
+	}
+}
+
+// TestSelectAnsibleVersion tests that the highest matching version is selected.
+func TestSelectAnsibleVersion(t *testing.T) {
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleVersionConstraint: "~2.15",
+			AnsibleVersions: map[string]string{
+				"2.14.11": "/opt/ansible-2.14/bin",
+				"2.15.4":  "/opt/ansible-2.15.4/bin",
+				"2.15.5":  "/opt/ansible-2.15.5/bin",
+				"2.16.0":  "/opt/ansible-2.16/bin",
+			},
+		},
+	}
+
+	if err := playbook.selectAnsibleVersion(); err != nil {
+		t.Fatalf("selectAnsibleVersion should not return an error, but received: %v", err)
+	}
+
+	if playbook.Config.AnsibleBinDir != "/opt/ansible-2.15.5/bin" {
+		t.Errorf("Expected '/opt/ansible-2.15.5/bin', got '%s'", playbook.Config.AnsibleBinDir)
+	}
+
+	// Assert that an unsatisfiable constraint is reported.
+	playbook.Config.AnsibleVersionConstraint = ">=2.17"
+	if err := playbook.selectAnsibleVersion(); err == nil {
+		t.Error("selectAnsibleVersion should return an error if no version matches")
+	}
+}
@@ -0,0 +1,114 @@
+package ansible
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	deprecationMarker = "[DEPRECATION WARNING]:"
+	warningMarker     = "[WARNING]:"
+)
+
+// Warning categories.
+const (
+	WarningInventory   = "inventory"
+	WarningPermissions = "permissions"
+	WarningInterpreter = "interpreter"
+	WarningGeneral     = "general"
+)
+
+var warningHostPattern = regexp.MustCompile(`on host (\S+?)[ ,.]`)
+
+// Warning is a [WARNING] reported by ansible.
+type Warning struct {
+	Category string `json:"category"`
+	Host     string `json:"host,omitempty"`
+	Message  string `json:"message"`
+}
+
+func parseWarnings(output []byte) []Warning {
+	var warnings []Warning
+
+	for _, message := range parseMessages(output, warningMarker) {
+		warning := Warning{
+			Category: WarningGeneral,
+			Message:  message,
+		}
+
+		lower := strings.ToLower(message)
+		switch {
+		case strings.Contains(lower, "inventory") || strings.Contains(lower, "host list") ||
+			strings.Contains(lower, "could not match supplied host pattern"):
+			warning.Category = WarningInventory
+		case strings.Contains(lower, "world writable") || strings.Contains(lower, "world readable") ||
+			strings.Contains(lower, "permissions"):
+			warning.Category = WarningPermissions
+		case strings.Contains(lower, "python interpreter"):
+			warning.Category = WarningInterpreter
+		}
+
+		if match := warningHostPattern.FindStringSubmatch(message); match != nil {
+			warning.Host = match[1]
+		}
+
+		warnings = append(warnings, warning)
+	}
+
+	return warnings
+}
+
+// DeprecationError is returned in strict deprecation mode if a run reported
+// deprecation warnings.
+type DeprecationError struct {
+	Inventory    string
+	Deprecations []string
+}
+
+func (e *DeprecationError) Error() string {
+	return fmt.Sprintf(
+		"run against inventory %s reported %d deprecation warning(s): %s",
+		e.Inventory,
+		len(e.Deprecations),
+		strings.Join(e.Deprecations, "; "),
+	)
+}
+
+// parseMessages returns the messages following the marker. Messages wrapped
+// over several lines are joined until an empty line or the next message.
+func parseMessages(output []byte, marker string) []string {
+	var (
+		messages []string
+		current  []string
+	)
+
+	flush := func() {
+		if len(current) > 0 {
+			messages = append(messages, strings.Join(current, " "))
+			current = nil
+		}
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(ansiEscapePattern.ReplaceAll(output, nil)))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case strings.HasPrefix(line, marker):
+			flush()
+			current = []string{strings.TrimSpace(strings.TrimPrefix(line, marker))}
+		case line == "" || strings.HasPrefix(line, "[") || strings.HasSuffix(line, "***"):
+			flush()
+		case current != nil:
+			current = append(current, line)
+		}
+	}
+
+	flush()
+	return messages
+}
@@ -0,0 +1,83 @@
+package ansible
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testDeprecationOutput = `[DEPRECATION WARNING]: The 'include' module is deprecated. Use include_tasks
+instead. This feature will be removed in version 2.16. Deprecation warnings can
+ be disabled by setting deprecation_warnings=False in ansible.cfg.
+
+PLAY [Deploy] ******************************************************************
+`
+
+// TestParseDeprecations tests joining wrapped deprecation warnings.
+func TestParseDeprecations(t *testing.T) {
+	deprecations := ParseRunResult([]byte(testDeprecationOutput)).Deprecations
+
+	expected := "The 'include' module is deprecated. Use include_tasks instead. This feature will be removed in version 2.16. Deprecation warnings can be disabled by setting deprecation_warnings=False in ansible.cfg."
+	if len(deprecations) != 1 || deprecations[0] != expected {
+		t.Errorf("Unexpected deprecations: %q", deprecations)
+	}
+}
+
+// TestStrictDeprecations tests that deprecation warnings fail the run in strict mode.
+func TestStrictDeprecations(t *testing.T) {
+	bin := t.TempDir()
+
+	script := "#!/bin/sh\ncat >&2 <<'EOF'\n" + testDeprecationOutput + "EOF\n"
+	if err := os.WriteFile(filepath.Join(bin, "ansible-playbook"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir:      bin,
+			Inventories:        []string{"tests/inventories/production"},
+			Playbooks:          []string{"tests/test.yml"},
+			SkipVersionCheck:   true,
+			StrictDeprecations: true,
+		},
+	}
+
+	err := playbook.Exec()
+	if _, ok := err.(*DeprecationError); !ok {
+		t.Errorf("Expected a DeprecationError, got %v", err)
+	}
+}
+
+// TestParseWarnings tests categorizing warnings.
+func TestParseWarnings(t *testing.T) {
+	output := []byte(`[WARNING]: Unable to parse /etc/ansible/hosts as an inventory source
+[WARNING]: Ansible is being run in a world writable directory (/tmp/deploy),
+ignoring it as an ansible.cfg source.
+[WARNING]: Platform linux on host web1 is using the discovered Python
+interpreter at /usr/bin/python3.11, but future installation of another Python
+interpreter could change the meaning of that path.
+[WARNING]: Collection community.general does not support Ansible version 2.15.5
+`)
+
+	warnings := ParseRunResult(output).Warnings
+
+	expected := []struct {
+		category string
+		host     string
+	}{
+		{WarningInventory, ""},
+		{WarningPermissions, ""},
+		{WarningInterpreter, "web1"},
+		{WarningGeneral, ""},
+	}
+
+	if len(warnings) != len(expected) {
+		t.Fatalf("Expected %d warnings, got %+v", len(expected), warnings)
+	}
+
+	for i := range expected {
+		if warnings[i].Category != expected[i].category || warnings[i].Host != expected[i].host {
+			t.Errorf("Expected %+v, got %+v", expected[i], warnings[i])
+		}
+	}
+}
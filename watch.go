@@ -0,0 +1,157 @@
+package ansible
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"time"
+)
+
+const (
+	defaultWatchInterval = time.Second
+	defaultWatchDebounce = 500 * time.Millisecond
+)
+
+type fileState struct {
+	ModTime time.Time
+	Size    int64
+}
+
+// Watch runs the playbooks and runs them again whenever a playbook, a role,
+// group or host vars next to the playbooks, an inventory or the galaxy file
+// changes, until the context is canceled. Changes are polled every
+// WatchInterval and a run starts once no file changed for WatchDebounce.
+// With WatchCheck the runs are in check mode. Failed runs are reported to
+// the output and do not stop watching.
+func (p *AnsiblePlaybook) Watch(ctx context.Context) error {
+	original := p.Config
+	defer func() { p.Config = original }()
+
+	config := original
+	if config.WatchCheck {
+		config.Check = true
+	}
+
+	interval := config.WatchInterval
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+
+	debounce := config.WatchDebounce
+	if debounce <= 0 {
+		debounce = defaultWatchDebounce
+	}
+
+	run := func() {
+		p.Config = config
+		if err := p.ExecContext(ctx); err != nil && ctx.Err() == nil {
+			fmt.Fprintf(p.output(), "watch: run failed: %v\n", err)
+		}
+	}
+
+	p.Config = config
+	files := p.watchFiles()
+	run()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		p.Config = config
+		current := p.watchFiles()
+		if reflect.DeepEqual(current, files) {
+			continue
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(debounce):
+			}
+
+			p.Config = config
+			next := p.watchFiles()
+			if reflect.DeepEqual(next, current) {
+				break
+			}
+
+			current = next
+		}
+
+		fmt.Fprintf(p.output(), "watch: %s changed\n", changedFile(files, current))
+
+		files = current
+		run()
+	}
+}
+
+// watchFiles returns the state of all watched files.
+func (p *AnsiblePlaybook) watchFiles() map[string]fileState {
+	files := map[string]fileState{}
+
+	paths := append([]string{}, p.Config.Inventories...)
+	if p.Config.GalaxyFile != "" {
+		paths = append(paths, p.Config.GalaxyFile)
+	}
+
+	dirs := map[string]bool{}
+	for _, playbook := range p.globPlaybooks(p.Config.Playbooks) {
+		paths = append(paths, playbook)
+		dirs[filepath.Dir(playbook)] = true
+	}
+
+	for _, dir := range sortedKeys(dirs) {
+		for _, sub := range []string{"roles", "group_vars", "host_vars"} {
+			paths = append(paths, filepath.Join(dir, sub))
+		}
+	}
+
+	for _, path := range paths {
+		filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+			if err != nil || entry.IsDir() {
+				return nil
+			}
+
+			if info, err := entry.Info(); err == nil {
+				files[file] = fileState{ModTime: info.ModTime(), Size: info.Size()}
+			}
+
+			return nil
+		})
+	}
+
+	return files
+}
+
+// changedFile returns the first file which was added, changed or removed.
+func changedFile(before, after map[string]fileState) string {
+	var changed []string
+	for file, state := range after {
+		if previous, ok := before[file]; !ok || previous != state {
+			changed = append(changed, file)
+		}
+	}
+
+	for file := range before {
+		if _, ok := after[file]; !ok {
+			changed = append(changed, file)
+		}
+	}
+
+	if len(changed) == 0 {
+		return ""
+	}
+
+	sort.Strings(changed)
+	return changed[0]
+}
@@ -0,0 +1,81 @@
+package ansible
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestWatch tests the playbooks run again in check mode once a playbook
+// changed.
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	log := filepath.Join(dir, "calls.log")
+	site := filepath.Join(dir, "site.yml")
+
+	script := "#!/bin/sh\necho \"$@\" >> " + log + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "ansible-playbook"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(site, []byte("---\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	playbook := &AnsiblePlaybook{
+		Config: Config{
+			AnsibleBinDir:    dir,
+			Inventories:      []string{"tests/inventories/production"},
+			Playbooks:        []string{filepath.Join(dir, "*.yml")},
+			SkipVersionCheck: true,
+			WatchCheck:       true,
+			WatchDebounce:    20 * time.Millisecond,
+			WatchInterval:    10 * time.Millisecond,
+		},
+		Output: &strings.Builder{},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	done := make(chan error)
+	go func() { done <- playbook.Watch(ctx) }()
+
+	runs := func(count int) []string {
+		for ctx.Err() == nil {
+			content, _ := os.ReadFile(log)
+			if calls := strings.Split(strings.TrimSpace(string(content)), "\n"); len(content) > 0 && len(calls) >= count {
+				return calls
+			}
+
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		t.Fatalf("Expected %d runs", count)
+		return nil
+	}
+
+	runs(1)
+
+	if err := os.WriteFile(filepath.Join(dir, "web.yml"), []byte("---\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := runs(2)
+	cancel()
+
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Expected the watch to stop with the context, got %v", err)
+	}
+
+	if !strings.Contains(calls[1], "--check") || !strings.HasSuffix(calls[1], "site.yml "+filepath.Join(dir, "web.yml")) {
+		t.Errorf("Expected a check run of both playbooks, got '%s'", calls[1])
+	}
+
+	if playbook.Config.Check || len(playbook.Config.Playbooks) != 1 {
+		t.Errorf("Expected the configuration to be restored, got %+v", playbook.Config)
+	}
+}
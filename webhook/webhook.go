@@ -0,0 +1,297 @@
+// Package webhook submits jobs for incoming webhooks, e.g. to apply a
+// playbook on every push to the main branch.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/arillso/go.ansible/jobs"
+)
+
+// Webhook sources.
+const (
+	SourceGitHub  = "github"
+	SourceGitLab  = "gitlab"
+	SourceGeneric = "generic"
+)
+
+// Trigger maps the webhooks posted to /<Name> to a run spec.
+type Trigger struct {
+	Name   string
+	Source string
+
+	// Secret verifies the webhooks: the HMAC secret for GitHub and generic
+	// webhooks, which are signed in X-Hub-Signature-256, or the token for
+	// GitLab. It is required unless Insecure is set.
+	Secret string
+
+	// Insecure accepts webhooks of triggers without a secret unverified,
+	// e.g. behind a proxy which authenticates them.
+	Insecure bool
+
+	// Event limits the trigger to an event, e.g. push for GitHub or
+	// tag_push for GitLab.
+	Event string
+
+	// Ref limits the trigger to refs matching the pattern, e.g.
+	// refs/heads/main or refs/tags/v*.
+	Ref string
+
+	// Vars maps extra vars to dot separated payload fields, e.g.
+	// "version": "release.tag_name". Like the webhook_* vars they are passed
+	// as unsafe strings, which Ansible does not template.
+	Vars map[string]string
+
+	Spec jobs.RunSpec
+}
+
+// Listener is a http.Handler submitting the jobs of the triggers.
+type Listener struct {
+	Queue    *jobs.Queue
+	Triggers []Trigger
+}
+
+// event is the information extracted from a webhook.
+type event struct {
+	name string
+	ref  string
+	vars map[string]interface{}
+}
+
+func (l *Listener) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	trigger := l.trigger(strings.Trim(r.URL.Path, "/"))
+	if trigger == nil {
+		http.Error(w, "unknown trigger", http.StatusNotFound)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 10<<20))
+	if err != nil {
+		http.Error(w, "failed to read payload", http.StatusBadRequest)
+		return
+	}
+
+	if !trigger.verify(r, body) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	e := trigger.event(r, payload)
+	if !trigger.matches(e) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	job, err := l.Queue.Submit(trigger.spec(e))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job.Info())
+}
+
+func (l *Listener) trigger(name string) *Trigger {
+	for i := range l.Triggers {
+		if l.Triggers[i].Name == name {
+			return &l.Triggers[i]
+		}
+	}
+
+	return nil
+}
+
+func (t *Trigger) verify(r *http.Request, body []byte) bool {
+	if t.Secret == "" {
+		return t.Insecure
+	}
+
+	if t.Source == SourceGitLab {
+		return subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Gitlab-Token")), []byte(t.Secret)) == 1
+	}
+
+	signature := strings.TrimPrefix(r.Header.Get("X-Hub-Signature-256"), "sha256=")
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(t.Secret))
+	mac.Write(body)
+
+	return hmac.Equal(mac.Sum(nil), expected)
+}
+
+func (t *Trigger) event(r *http.Request, payload map[string]interface{}) event {
+	e := event{vars: map[string]interface{}{}}
+
+	switch t.Source {
+	case SourceGitHub:
+		e.name = r.Header.Get("X-GitHub-Event")
+		e.ref, _ = lookup(payload, "ref").(string)
+		e.vars["webhook_ref"] = e.ref
+		e.vars["webhook_commit"] = lookup(payload, "after")
+		e.vars["webhook_repository"] = lookup(payload, "repository.full_name")
+		e.vars["webhook_sender"] = lookup(payload, "sender.login")
+	case SourceGitLab:
+		e.name, _ = lookup(payload, "object_kind").(string)
+		e.ref, _ = lookup(payload, "ref").(string)
+		e.vars["webhook_ref"] = e.ref
+		e.vars["webhook_commit"] = lookup(payload, "checkout_sha")
+		e.vars["webhook_repository"] = lookup(payload, "project.path_with_namespace")
+		e.vars["webhook_sender"] = lookup(payload, "user_username")
+	default:
+		e.name, _ = lookup(payload, "event").(string)
+		e.ref, _ = lookup(payload, "ref").(string)
+	}
+
+	for name, field := range t.Vars {
+		e.vars[name] = lookup(payload, field)
+	}
+
+	for name, value := range e.vars {
+		if value == nil {
+			delete(e.vars, name)
+		}
+	}
+
+	return e
+}
+
+func (t *Trigger) matches(e event) bool {
+	if t.Event != "" && t.Event != e.name {
+		return false
+	}
+
+	if t.Ref != "" {
+		matched, err := path.Match(t.Ref, e.ref)
+		if err != nil || !matched {
+			return false
+		}
+	}
+
+	return true
+}
+
+// spec returns the run spec of the trigger with the extra vars of the event.
+func (t *Trigger) spec(e event) jobs.RunSpec {
+	spec := t.Spec
+	if spec.Name == "" {
+		spec.Name = t.Name
+	}
+
+	if len(e.vars) > 0 {
+		vars := unsafeVars(e.vars)
+		spec.Config.ExtraVars = append(append([]string{}, spec.Config.ExtraVars...), vars)
+	}
+
+	return spec
+}
+
+// unsafeVars encodes the vars as YAML flow mapping with every string tagged
+// !unsafe, so Ansible does not template values of the payload, e.g. a commit
+// message like "{{ lookup('pipe', 'id') }}".
+func unsafeVars(value interface{}) string {
+	switch value := value.(type) {
+	case string:
+		quoted, _ := json.Marshal(value)
+		return "!unsafe " + string(quoted)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		fields := make([]string, 0, len(keys))
+		for _, key := range keys {
+			quoted, _ := json.Marshal(key)
+			fields = append(fields, string(quoted)+": "+unsafeVars(value[key]))
+		}
+
+		return "{" + strings.Join(fields, ", ") + "}"
+	case []interface{}:
+		items := make([]string, 0, len(value))
+		for _, item := range value {
+			items = append(items, unsafeVars(item))
+		}
+
+		return "[" + strings.Join(items, ", ") + "]"
+	default:
+		encoded, _ := json.Marshal(value)
+		return string(encoded)
+	}
+}
+
+// lookup returns the value of a dot separated field of the payload.
+func lookup(payload map[string]interface{}, field string) interface{} {
+	var value interface{} = payload
+
+	for _, key := range strings.Split(field, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+
+		value = object[key]
+	}
+
+	return value
+}
+
+// Validate checks the triggers of the listener.
+func (l *Listener) Validate() error {
+	names := map[string]bool{}
+
+	for _, trigger := range l.Triggers {
+		if trigger.Name == "" || strings.Contains(trigger.Name, "/") {
+			return fmt.Errorf("invalid trigger name %q", trigger.Name)
+		}
+
+		if names[trigger.Name] {
+			return fmt.Errorf("duplicate trigger %q", trigger.Name)
+		}
+
+		names[trigger.Name] = true
+
+		switch trigger.Source {
+		case SourceGitHub, SourceGitLab, SourceGeneric:
+		default:
+			return fmt.Errorf("invalid source %q of trigger %q", trigger.Source, trigger.Name)
+		}
+
+		if trigger.Secret == "" && !trigger.Insecure {
+			return fmt.Errorf("trigger %q requires a secret", trigger.Name)
+		}
+
+		if _, err := path.Match(trigger.Ref, ""); err != nil {
+			return fmt.Errorf("invalid ref pattern of trigger %q", trigger.Name)
+		}
+	}
+
+	return nil
+}
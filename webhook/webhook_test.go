@@ -0,0 +1,220 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	ansible "github.com/arillso/go.ansible"
+	"github.com/arillso/go.ansible/ansibletest"
+	"github.com/arillso/go.ansible/jobs"
+)
+
+const githubPush = `{
+  "ref": "refs/heads/main",
+  "after": "6113728f27ae82c7b1a177c8d03f9e96e0adf246",
+  "repository": {"full_name": "arillso/site"},
+  "sender": {"login": "octocat"}
+}`
+
+func sign(secret, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func listener(t *testing.T) (*Listener, *ansibletest.Fake) {
+	fake := ansibletest.NewFake(t)
+	queue := jobs.NewQueue(1)
+	t.Cleanup(queue.Close)
+
+	return &Listener{
+		Queue: queue,
+		Triggers: []Trigger{
+			{
+				Name:   "site",
+				Source: SourceGitHub,
+				Secret: "s3cr3t",
+				Event:  "push",
+				Ref:    "refs/heads/main",
+				Spec: jobs.RunSpec{
+					Config: ansible.Config{
+						AnsibleBinDir:    fake.Dir,
+						Inventories:      []string{"../tests/inventories/production"},
+						Playbooks:        []string{"../tests/test.yml"},
+						SkipVersionCheck: true,
+					},
+				},
+			},
+			{
+				Name:   "release",
+				Source: SourceGitLab,
+				Secret: "token",
+				Event:  "tag_push",
+				Ref:    "refs/tags/v*",
+			},
+		},
+	}, fake
+}
+
+// TestGitHubPush tests a signed push submits a job with the payload vars.
+func TestGitHubPush(t *testing.T) {
+	l, fake := listener(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/site", strings.NewReader(githubPush))
+	req.Header.Set("X-GitHub-Event", "push")
+	req.Header.Set("X-Hub-Signature-256", sign("s3cr3t", githubPush))
+
+	rec := httptest.NewRecorder()
+	l.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("Expected the webhook to be accepted, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var info jobs.Info
+	json.NewDecoder(rec.Body).Decode(&info)
+
+	job, err := l.Queue.Get(info.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-job.Done()
+
+	call := fake.AssertCalled(t, "ansible-playbook", 1)[0]
+
+	extraVars := call.FlagValues("--extra-vars")[0]
+	if !strings.HasPrefix(extraVars, `{"webhook_commit": !unsafe "6113728f`) {
+		t.Errorf("Expected the payload vars to be unsafe, got %s", extraVars)
+	}
+
+	var vars map[string]string
+	if err := json.Unmarshal([]byte(strings.ReplaceAll(extraVars, "!unsafe ", "")), &vars); err != nil {
+		t.Fatal(err)
+	}
+
+	if vars["webhook_ref"] != "refs/heads/main" || vars["webhook_repository"] != "arillso/site" || vars["webhook_sender"] != "octocat" {
+		t.Errorf("Expected the payload vars, got %v", vars)
+	}
+}
+
+// TestWebhookRejected tests webhooks which are invalid or do not match.
+func TestWebhookRejected(t *testing.T) {
+	l, _ := listener(t)
+
+	tests := []struct {
+		path, event, signature, body string
+		header                       string
+		status                       int
+	}{
+		{"/site", "push", "sha256=00", githubPush, "X-Hub-Signature-256", http.StatusUnauthorized},
+		{"/site", "issues", sign("s3cr3t", githubPush), githubPush, "X-Hub-Signature-256", http.StatusNoContent},
+		{"/unknown", "push", "", githubPush, "X-Hub-Signature-256", http.StatusNotFound},
+		{"/release", "", "wrong", `{"object_kind": "tag_push", "ref": "refs/tags/v1.0"}`, "X-Gitlab-Token", http.StatusUnauthorized},
+		{"/release", "", "token", `{"object_kind": "tag_push", "ref": "refs/heads/main"}`, "X-Gitlab-Token", http.StatusNoContent},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodPost, test.path, strings.NewReader(test.body))
+		req.Header.Set("X-GitHub-Event", test.event)
+		req.Header.Set(test.header, test.signature)
+
+		rec := httptest.NewRecorder()
+		l.ServeHTTP(rec, req)
+
+		if rec.Code != test.status {
+			t.Errorf("Expected %d for %s, got %d", test.status, test.path, rec.Code)
+		}
+	}
+
+	if len(l.Queue.List()) != 0 {
+		t.Errorf("Expected no jobs to be submitted")
+	}
+}
+
+// TestGenericVars tests payload fields are mapped to extra vars.
+func TestGenericVars(t *testing.T) {
+	trigger := &Trigger{
+		Source: SourceGeneric,
+		Vars:   map[string]string{"version": "release.tag", "missing": "release.notes"},
+	}
+
+	var payload map[string]interface{}
+	json.Unmarshal([]byte(`{"event": "release", "release": {"tag": "1.2.0"}}`), &payload)
+
+	e := trigger.event(httptest.NewRequest(http.MethodPost, "/", nil), payload)
+	if e.name != "release" || len(e.vars) != 1 || e.vars["version"] != "1.2.0" {
+		t.Errorf("Expected the version var, got %+v", e)
+	}
+}
+
+// TestUnsafeVars tests templates in the payload are passed as unsafe
+// strings, so Ansible does not evaluate them.
+func TestUnsafeVars(t *testing.T) {
+	trigger := &Trigger{
+		Source: SourceGeneric,
+		Vars:   map[string]string{"release": "release"},
+	}
+
+	var payload map[string]interface{}
+	json.Unmarshal([]byte(`{
+		"event": "release",
+		"release": {"tag": "{{ lookup('pipe','id') }}", "assets": ["{% raw %}", 3], "draft": false}
+	}`), &payload)
+
+	spec := trigger.spec(trigger.event(httptest.NewRequest(http.MethodPost, "/", nil), payload))
+
+	expected := `{"release": {"assets": [!unsafe "{% raw %}", 3], "draft": false, "tag": !unsafe "{{ lookup('pipe','id') }}"}}`
+	if vars := spec.Config.ExtraVars[len(spec.Config.ExtraVars)-1]; vars != expected {
+		t.Errorf("Expected the unsafe vars %s, got %s", expected, vars)
+	}
+}
+
+// TestValidate tests invalid triggers are rejected.
+func TestValidate(t *testing.T) {
+	l, _ := listener(t)
+	if err := l.Validate(); err != nil {
+		t.Fatalf("Expected the triggers to be valid, got %v", err)
+	}
+
+	l.Triggers = append(l.Triggers, Trigger{Name: "site", Source: SourceGeneric})
+	if err := l.Validate(); err == nil {
+		t.Error("Expected duplicate triggers to be rejected")
+	}
+
+	l.Triggers = []Trigger{{Name: "deploy", Source: "bitbucket"}}
+	if err := l.Validate(); err == nil {
+		t.Error("Expected unknown sources to be rejected")
+	}
+
+	l.Triggers = []Trigger{{Name: "deploy", Source: SourceGeneric}}
+	if err := l.Validate(); err == nil {
+		t.Error("Expected triggers without a secret to be rejected")
+	}
+
+	l.Triggers[0].Insecure = true
+	if err := l.Validate(); err != nil {
+		t.Errorf("Expected insecure triggers to be valid, got %v", err)
+	}
+}
+
+// TestVerifyWithoutSecret tests webhooks of triggers without a secret are
+// only accepted if the trigger is insecure.
+func TestVerifyWithoutSecret(t *testing.T) {
+	trigger := &Trigger{Name: "deploy", Source: SourceGeneric}
+	req := httptest.NewRequest(http.MethodPost, "/deploy", strings.NewReader("{}"))
+
+	if trigger.verify(req, []byte("{}")) {
+		t.Error("Expected the webhook to be rejected")
+	}
+
+	trigger.Insecure = true
+	if !trigger.verify(req, []byte("{}")) {
+		t.Error("Expected the webhook of the insecure trigger to be accepted")
+	}
+}